@@ -1,6 +1,8 @@
 package api
 
 import (
+	"sort"
+
 	"github.com/lunfardo314/proxima/core/vertex"
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/multistate"
@@ -10,6 +12,7 @@ const (
 	PathGetLedgerID             = "/get_ledger_id"
 	PathGetAccountOutputs       = "/get_account_outputs"
 	PathGetChainOutput          = "/get_chain_output"
+	PathGetChainControlled      = "/get_chain_controlled_outputs"
 	PathGetOutput               = "/get_output"
 	PathQueryTxStatus           = "/query_tx_status"
 	PathQueryInclusionScore     = "/query_inclusion_score"
@@ -19,6 +22,8 @@ const (
 	PathGetPeersInfo            = "/peers_info"
 	PathGetLatestReliableBranch = "/get_latest_reliable_branch"
 	PathGetDashboard            = "/dashboard"
+	PathGetPastConeGraph        = "/get_past_cone_graph"
+	PathGetBranchesInSlot       = "/get_branches_in_slot"
 )
 
 type (
@@ -33,7 +38,7 @@ type (
 		LedgerIDBytes string `json:"ledger_id_bytes,omitempty"`
 	}
 
-	// OutputList is returned by 'get_account_outputs'
+	// OutputList is returned by 'get_account_outputs' and 'get_chain_controlled_outputs'
 	OutputList struct {
 		Error
 		// key is hex-encoded outputID bytes
@@ -90,6 +95,13 @@ type (
 		Synced       bool                         `json:"synced"`
 		InSyncWindow bool                         `json:"in_sync_window,omitempty"`
 		PerSequencer map[string]SequencerSyncInfo `json:"per_sequencer,omitempty"`
+		// LatestBranchAgeMs age of the latest committed branch in milliseconds. Big age means node is stalled
+		LatestBranchAgeMs   int64  `json:"latest_branch_age_ms"`
+		LatestCommittedSlot uint32 `json:"latest_committed_slot"`
+		LatestHealthySlot   uint32 `json:"latest_healthy_slot"`
+		NowSlot             uint32 `json:"now_slot"`
+		// SlotsBehind number of slots the latest committed slot is behind the current slot
+		SlotsBehind int `json:"slots_behind"`
 	}
 
 	SequencerSyncInfo struct {
@@ -121,6 +133,18 @@ type (
 		NumIncomingHB             int      `json:"num_incoming_hb"`
 		NumIncomingPull           int      `json:"num_incoming_pull"`
 		NumIncomingTx             int      `json:"num_incoming_tx"`
+		NumGossipParseFailures    int      `json:"num_gossip_parse_failures"`
+		NumGossipRateLimited      int      `json:"num_gossip_rate_limited"`
+		// sync status reported by the peer in heartbeats
+		LatestCommittedSlot uint32 `json:"latest_committed_slot"`
+		Synced              bool   `json:"synced"`
+	}
+
+	// FleetPeers alive peers of several nodes, collected from their peers info.
+	// Key is the host ID of the node, value is the list of its alive peers.
+	// It covers only the queried nodes: peers of peers are not known to the node
+	FleetPeers struct {
+		Nodes map[string][]string `json:"nodes"`
 	}
 
 	// LatestReliableBranch returned by get_latest_reliable_branch
@@ -129,10 +153,72 @@ type (
 		RootData multistate.RootRecordJSONAble `json:"root_record,omitempty"`
 		BranchID ledger.TransactionID          `json:"branch_id,omitempty"`
 	}
+
+	// BranchesInSlot returned by get_branches_in_slot
+	BranchesInSlot struct {
+		Error
+		Slot                ledger.Slot `json:"slot"`
+		LatestCommittedSlot ledger.Slot `json:"latest_committed_slot"`
+		Branches            []Branch    `json:"branches,omitempty"`
+	}
+
+	Branch struct {
+		BranchID ledger.TransactionID          `json:"branch_id"`
+		RootData multistate.RootRecordJSONAble `json:"root_record"`
+	}
+
+	// PastConeGraph returned by get_past_cone_graph
+	PastConeGraph struct {
+		Error
+		// graph of the past cone in DOT format
+		DOT string `json:"dot,omitempty"`
+		// not empty if the graph is incomplete. The incomplete graph is returned anyway
+		Incomplete string `json:"incomplete,omitempty"`
+	}
 )
 
 const ErrGetOutputNotFound = "output not found"
 
+// MaxPastConeGraphVertices bounds number of vertices in the past cone graph returned by the node
+const MaxPastConeGraphVertices = 10_000
+
+// MaxFleetPeersPerNode bounds number of peers of one node included into the FleetPeers
+const MaxFleetPeersPerNode = 256
+
+func NewFleetPeers() *FleetPeers {
+	return &FleetPeers{Nodes: make(map[string][]string)}
+}
+
+// AddPeersInfo adds alive peers of the node. Peers are sorted and bounded by MaxFleetPeersPerNode
+func (t *FleetPeers) AddPeersInfo(peersInfo *PeersInfo) {
+	peers := make([]string, 0, len(peersInfo.Peers))
+	for i := range peersInfo.Peers {
+		if peersInfo.Peers[i].IsAlive {
+			peers = append(peers, peersInfo.Peers[i].ID)
+		}
+	}
+	sort.Strings(peers)
+	if len(peers) > MaxFleetPeersPerNode {
+		peers = peers[:MaxFleetPeersPerNode]
+	}
+	t.Nodes[peersInfo.HostID] = peers
+}
+
+// NumEdges number of distinct links between the nodes and their peers
+func (t *FleetPeers) NumEdges() int {
+	edges := make(map[[2]string]struct{})
+	for node, peers := range t.Nodes {
+		for _, p := range peers {
+			if node < p {
+				edges[[2]string{node, p}] = struct{}{}
+			} else {
+				edges[[2]string{p, node}] = struct{}{}
+			}
+		}
+	}
+	return len(edges)
+}
+
 func CalcTxInclusionScore(inclusion *multistate.TxInclusion, thresholdNumerator, thresholdDenominator int) TxInclusionScore {
 	ret := TxInclusionScore{
 		ThresholdNumerator:   thresholdNumerator,
@@ -142,24 +228,6 @@ func CalcTxInclusionScore(inclusion *multistate.TxInclusion, thresholdNumerator,
 		StrongScore:          0,
 		WeakScore:            0,
 	}
-	if len(inclusion.Inclusion) == 0 {
-		return ret
-	}
-	var includedInBranches, numDominatingBranches, numIncludedInDominating int
-	for i := range inclusion.Inclusion {
-		if inclusion.Inclusion[i].Included {
-			includedInBranches++
-		}
-		if inclusion.Inclusion[i].RootRecord.IsCoverageAboveThreshold(thresholdNumerator, thresholdDenominator) {
-			numDominatingBranches++
-			if inclusion.Inclusion[i].Included {
-				numIncludedInDominating++
-			}
-		}
-	}
-	ret.WeakScore = (includedInBranches * 100) / len(inclusion.Inclusion)
-	if numDominatingBranches > 0 {
-		ret.StrongScore = (numIncludedInDominating * 100) / numDominatingBranches
-	}
+	ret.WeakScore, ret.StrongScore = inclusion.Score(thresholdNumerator, thresholdDenominator)
 	return ret
 }
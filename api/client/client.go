@@ -6,6 +6,7 @@ import (
 	"crypto/ed25519"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -139,9 +140,30 @@ func (c *APIClient) getAccountOutputs(accountable ledger.Accountable, maxOutputs
 	if err != nil {
 		return nil, nil, err
 	}
+	return outputListFromBody(body)
+}
+
+// GetChainControlledOutputs returns outputs locked in the chain with the chain lock. The chain output itself is not included
+func (c *APIClient) GetChainControlledOutputs(chainID ledger.ChainID) ([]*ledger.OutputWithID, *ledger.TransactionID, error) {
+	path := fmt.Sprintf(api.PathGetChainControlled+"?chainid=%s", chainID.StringHex())
+	body, err := c.getBody(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	oData, lrbid, err := outputListFromBody(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	outs, err := txutils.ParseAndSortOutputData(oData, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outs, lrbid, nil
+}
 
+func outputListFromBody(body []byte) ([]*ledger.OutputDataWithID, *ledger.TransactionID, error) {
 	var res api.OutputList
-	err = json.Unmarshal(body, &res)
+	err := json.Unmarshal(body, &res)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -335,6 +357,29 @@ func (c *APIClient) QueryTxIDStatus(txid ledger.TransactionID, slotSpan int) (*v
 	return retTxIDStatus, retInclusion, nil
 }
 
+// GetPastConeGraph returns past cone graph of the transaction in the memDAG of the node, in DOT format.
+// Non-nil incomplete means the graph is returned, but it is incomplete
+func (c *APIClient) GetPastConeGraph(txid ledger.TransactionID, maxVertices int) (dot []byte, incomplete error, err error) {
+	path := fmt.Sprintf(api.PathGetPastConeGraph+"?txid=%s&max=%d", txid.StringHex(), maxVertices)
+	body, err := c.getBody(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res api.PastConeGraph
+	err = json.Unmarshal(body, &res)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unmarshal returned: %v\nbody: '%s'", err, string(body))
+	}
+	if res.Error.Error != "" {
+		return nil, nil, fmt.Errorf("from server: %s", res.Error.Error)
+	}
+	if res.Incomplete != "" {
+		incomplete = errors.New(res.Incomplete)
+	}
+	return []byte(res.DOT), incomplete, nil
+}
+
 func (c *APIClient) QueryTxInclusionScore(txid ledger.TransactionID, thresholdNumerator, thresholdDenominator, slotSpan int) (*api.TxInclusionScore, error) {
 	path := fmt.Sprintf(api.PathQueryInclusionScore+"?txid=%s&threshold=%d-%d&slots=%d",
 		txid.StringHex(), thresholdNumerator, thresholdDenominator, slotSpan)
@@ -692,6 +737,38 @@ func (c *APIClient) GetLatestReliableBranch() (*multistate.RootRecord, *ledger.T
 	return rr, &res.BranchID, nil
 }
 
+// GetBranchesInSlot retrieves root records of branches committed in the slot, by branch transaction ID.
+// If slot is not provided, branches of the latest committed slot are returned. Also returns the latest committed slot
+func (c *APIClient) GetBranchesInSlot(slot ...ledger.Slot) (map[ledger.TransactionID]*multistate.RootRecord, ledger.Slot, error) {
+	path := api.PathGetBranchesInSlot
+	if len(slot) > 0 {
+		path = fmt.Sprintf(api.PathGetBranchesInSlot+"?slot=%d", slot[0])
+	}
+	body, err := c.getBody(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var res api.BranchesInSlot
+	err = json.Unmarshal(body, &res)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unmarshal returned: %v\nbody: '%s'", err, string(body))
+	}
+	if res.Error.Error != "" {
+		return nil, 0, fmt.Errorf("from server: %s", res.Error.Error)
+	}
+
+	ret := make(map[ledger.TransactionID]*multistate.RootRecord, len(res.Branches))
+	for i := range res.Branches {
+		rr, err := res.Branches[i].RootData.Parse()
+		if err != nil {
+			return nil, 0, fmt.Errorf("parse failed: %v", err)
+		}
+		ret[res.Branches[i].BranchID] = rr
+	}
+	return ret, res.LatestCommittedSlot, nil
+}
+
 type MakeTransferTransactionParams struct {
 	Inputs        []*ledger.OutputWithID
 	Target        ledger.Lock
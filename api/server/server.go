@@ -29,10 +29,16 @@ type (
 		GetSyncInfo() *api.SyncInfo
 		GetPeersInfo() *api.PeersInfo
 		LatestReliableState() (multistate.SugaredStateReader, error)
-		SubmitTxBytesFromAPI(txBytes []byte, trace bool)
+		SubmitTxBytesFromAPI(txBytes []byte, trace bool) bool
 		QueryTxIDStatusJSONAble(txid *ledger.TransactionID) vertex.TxIDStatusJSONAble
 		GetTxInclusion(txid *ledger.TransactionID, slotsBack int) *multistate.TxInclusion
 		GetLatestReliableBranch() *multistate.BranchData
+		// GetBranchRootRecordsInSlot returns root records of all branches committed in the slot and the latest committed slot.
+		// If slot is nil, the latest committed slot is taken
+		GetBranchRootRecordsInSlot(slot *ledger.Slot) (map[ledger.TransactionID]multistate.RootRecord, ledger.Slot)
+		// GetPastConeGraphDOT returns past cone of the transaction in the memDAG as a graph in DOT format.
+		// Non-nil incomplete means graph is returned, but it is incomplete
+		GetPastConeGraphDOT(txid *ledger.TransactionID, maxVertices int) (dot []byte, incomplete error, err error)
 	}
 
 	server struct {
@@ -60,6 +66,8 @@ func (srv *server) registerHandlers() {
 	srv.addHandler(api.PathGetAccountOutputs, srv.getAccountOutputs)
 	// GET request format: '/get_chain_output?chainid=<hex-encoded chain ID>'
 	srv.addHandler(api.PathGetChainOutput, srv.getChainOutput)
+	// GET request format: '/get_chain_controlled_outputs?chainid=<hex-encoded chain ID>'
+	srv.addHandler(api.PathGetChainControlled, srv.getChainControlledOutputs)
 	// GET request format: '/get_output?id=<hex-encoded output ID>'
 	srv.addHandler(api.PathGetOutput, srv.getOutput)
 	// GET request format: '/query_txid_status?txid=<hex-encoded transaction ID>[&slots=<slot span>]'
@@ -76,6 +84,11 @@ func (srv *server) registerHandlers() {
 	srv.addHandler(api.PathGetPeersInfo, srv.getPeersInfo)
 	// GET latest reliable branch '/get_latest_reliable_branch'
 	srv.addHandler(api.PathGetLatestReliableBranch, srv.getLatestReliableBranch)
+	// GET request format: '/get_past_cone_graph?txid=<hex-encoded transaction ID>[&max=<max number of vertices>]'
+	srv.addHandler(api.PathGetPastConeGraph, srv.getPastConeGraph)
+	// GET root records of branches in the slot. If slot is omitted, the latest committed slot is taken
+	// '/get_branches_in_slot?slot=<slot>'
+	srv.addHandler(api.PathGetBranchesInSlot, srv.getBranchesInSlot)
 	// GET dashboard for node
 	srv.addHandler(api.PathGetDashboard, srv.getDashboard)
 }
@@ -237,6 +250,50 @@ func (srv *server) getChainOutput(w http.ResponseWriter, r *http.Request) {
 	util.AssertNoError(err)
 }
 
+// getChainControlledOutputs returns all outputs locked in the chain with the chain lock
+func (srv *server) getChainControlledOutputs(w http.ResponseWriter, r *http.Request) {
+	setHeader(w)
+
+	lst, ok := r.URL.Query()["chainid"]
+	if !ok || len(lst) != 1 {
+		writeErr(w, "wrong parameters in request 'get_chain_controlled_outputs'")
+		return
+	}
+	chainID, err := ledger.ChainIDFromHexString(lst[0])
+	if err != nil {
+		writeErr(w, err.Error())
+		return
+	}
+
+	var oData []*ledger.OutputDataWithID
+	resp := &api.OutputList{}
+	err = srv.withLRB(func(rdr multistate.SugaredStateReader) (errRet error) {
+		oData, errRet = rdr.GetChainControlledOutputs(chainID)
+		lrbid := rdr.GetStemOutput().ID.TransactionID()
+		resp.LRBID = lrbid.StringHex()
+		return
+	})
+	if err != nil {
+		writeErr(w, err.Error())
+		return
+	}
+	resp.Outputs = make(map[string]string)
+	for _, o := range oData {
+		if len(resp.Outputs) >= absoluteMaximumOfReturnedOutputs {
+			break
+		}
+		resp.Outputs[o.ID.StringHex()] = hex.EncodeToString(o.OutputData)
+	}
+
+	respBin, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		writeErr(w, err.Error())
+		return
+	}
+	_, err = w.Write(respBin)
+	util.AssertNoError(err)
+}
+
 func (srv *server) getOutput(w http.ResponseWriter, r *http.Request) {
 	setHeader(w)
 
@@ -317,8 +374,9 @@ func (srv *server) submitTx(w http.ResponseWriter, r *http.Request) {
 	// tx tracing on server parameter
 	_, trace := r.URL.Query()["trace"]
 	var txid *ledger.TransactionID
+	var submitted bool
 	err = util.CatchPanicOrError(func() error {
-		srv.SubmitTxBytesFromAPI(slices.Clip(txBytes), trace)
+		submitted = srv.SubmitTxBytesFromAPI(slices.Clip(txBytes), trace)
 		return nil
 	})
 	if err != nil {
@@ -326,6 +384,11 @@ func (srv *server) submitTx(w http.ResponseWriter, r *http.Request) {
 		srv.Tracef(TraceTag, "submit transaction: '%v'", err)
 		return
 	}
+	if !submitted {
+		http.Error(w, "transaction input queue is full, try later", http.StatusServiceUnavailable)
+		srv.Tracef(TraceTag, "submit transaction: input queue is full")
+		return
+	}
 	srv.Tracef(TraceTag, "submitted transaction %s, trace = %v", txid.StringShort, trace)
 
 	writeOk(w)
@@ -526,6 +589,108 @@ func (srv *server) getLatestReliableBranch(w http.ResponseWriter, r *http.Reques
 	util.AssertNoError(err)
 }
 
+func (srv *server) getBranchesInSlot(w http.ResponseWriter, r *http.Request) {
+	setHeader(w)
+
+	var slot *ledger.Slot
+	lst, ok := r.URL.Query()["slot"]
+	if ok {
+		if len(lst) != 1 {
+			writeErr(w, "one slot expected")
+			return
+		}
+		s, err := strconv.ParseUint(lst[0], 10, 32)
+		if err != nil {
+			writeErr(w, fmt.Sprintf("wrong slot parameter: %v", err))
+			return
+		}
+		slot = util.Ref(ledger.Slot(s))
+	}
+
+	var rootRecords map[ledger.TransactionID]multistate.RootRecord
+	var latestSlot ledger.Slot
+	err := util.CatchPanicOrError(func() error {
+		rootRecords, latestSlot = srv.GetBranchRootRecordsInSlot(slot)
+		return nil
+	})
+	if err != nil {
+		writeErr(w, err.Error())
+		return
+	}
+	resp := &api.BranchesInSlot{
+		Slot:                latestSlot,
+		LatestCommittedSlot: latestSlot,
+		Branches:            make([]api.Branch, 0, len(rootRecords)),
+	}
+	if slot != nil {
+		resp.Slot = *slot
+	}
+	for branchID, rr := range rootRecords {
+		resp.Branches = append(resp.Branches, api.Branch{
+			BranchID: branchID,
+			RootData: *rr.JSONAble(),
+		})
+	}
+	respBin, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		writeErr(w, err.Error())
+		return
+	}
+	_, err = w.Write(respBin)
+	util.AssertNoError(err)
+}
+
+const defaultPastConeGraphVertices = 500
+
+func (srv *server) getPastConeGraph(w http.ResponseWriter, r *http.Request) {
+	setHeader(w)
+
+	lst, ok := r.URL.Query()["txid"]
+	if !ok || len(lst) != 1 {
+		writeErr(w, "txid expected")
+		return
+	}
+	txid, err := ledger.TransactionIDFromHexString(lst[0])
+	if err != nil {
+		writeErr(w, err.Error())
+		return
+	}
+
+	maxVertices := defaultPastConeGraphVertices
+	lst, ok = r.URL.Query()["max"]
+	if ok && len(lst) == 1 {
+		maxVertices, err = strconv.Atoi(lst[0])
+		if err != nil || maxVertices < 1 || maxVertices > api.MaxPastConeGraphVertices {
+			writeErr(w, fmt.Sprintf("parameter 'max' must be between 1 and %d", api.MaxPastConeGraphVertices))
+			return
+		}
+	}
+
+	var resp api.PastConeGraph
+	err = util.CatchPanicOrError(func() error {
+		dot, incomplete, err1 := srv.GetPastConeGraphDOT(&txid, maxVertices)
+		if err1 != nil {
+			return err1
+		}
+		resp.DOT = string(dot)
+		if incomplete != nil {
+			resp.Incomplete = incomplete.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		writeErr(w, err.Error())
+		return
+	}
+	respBin, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		writeErr(w, err.Error())
+		return
+	}
+	_, err = w.Write(respBin)
+	util.AssertNoError(err)
+}
+
 // calcTxInclusionScore calculates inclusion score response from inclusion data
 func (srv *server) calcTxInclusionScore(inclusion *multistate.TxInclusion, thresholdNumerator, thresholdDenominator int) api.TxInclusionScore {
 	srv.Tracef(TraceTagQueryInclusion, "calcTxInclusionScore: %s, threshold: %d/%d", inclusion.String(), thresholdNumerator, thresholdDenominator)
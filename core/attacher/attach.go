@@ -27,6 +27,7 @@ func AttachTxID(txid ledger.TransactionID, env Environment, opts ...AttachTxOpti
 	}
 	env.Tracef(TraceTagAttach, "AttachTxID: %s%s", txid.StringShort, by)
 
+	pullNew := false
 	env.WithGlobalWriteLock(func() {
 		vid = env.GetVertexNoLock(&txid)
 		if vid != nil {
@@ -48,6 +49,7 @@ func AttachTxID(txid ledger.TransactionID, env Environment, opts ...AttachTxOpti
 			vid = vertex.WrapTxID(txid)
 			vid.SetAttachmentDepthNoLock(options.depth)
 			env.AddVertexNoLock(vid)
+			pullNew = options.pullNonBranch
 			return
 		}
 		// it is a branch transaction
@@ -73,6 +75,18 @@ func AttachTxID(txid ledger.TransactionID, env Environment, opts ...AttachTxOpti
 			env.TraceTx(&txid, "AttachTxID: added new branch vertex and pulled")
 		}
 	})
+	if pullNew {
+		// pulling outside global lock
+		vid.UnwrapVirtualTx(func(v *vertex.VirtualTransaction) {
+			if v.PullRulesDefined() {
+				return
+			}
+			env.Tracef(TraceTagAttach, "AttachTxID: pull new non-branch %s%s", txid.StringShort, by)
+			repeatPullAfter, _, nPeers := env.TxPullParameters()
+			v.SetPullNeeded()
+			pullFromStoreOrPeers(env, v, vid, repeatPullAfter, nPeers, nil, nil)
+		})
+	}
 	return
 }
 
@@ -92,7 +106,8 @@ func AttachTransaction(tx *transaction.Transaction, env Environment, opts ...Att
 	env.TraceTx(tx.ID(), "AttachTransaction")
 
 	vid = AttachTxID(*tx.ID(), env, WithInvokedBy("addTx"))
-	vid.UnwrapVirtualTx(func(v *vertex.VirtualTransaction) {
+	repeated := false
+	vid.Unwrap(vertex.UnwrapOptions{VirtualTx: func(v *vertex.VirtualTransaction) {
 		if vid.FlagsUpNoLock(vertex.FlagVertexTxAttachmentStarted) {
 			// case with already attached transaction
 			if options.attachmentCallback != nil {
@@ -126,7 +141,7 @@ func AttachTransaction(tx *transaction.Transaction, env Environment, opts ...Att
 				env.MarkWorkProcessStarted(vid.IDShortString())
 				env.TraceTx(&vid.ID, "runMilestoneAttacher: start")
 
-				runMilestoneAttacher(vid, metadata, options.attachmentCallback, env, options.ctx)
+				runMilestoneAttacher(vid, env, options)
 
 				env.TraceTx(&vid.ID, "runMilestoneAttacher: exit")
 				env.MarkWorkProcessStopped(vid.IDShortString())
@@ -144,10 +159,37 @@ func AttachTransaction(tx *transaction.Transaction, env Environment, opts ...Att
 		}
 
 		env.PostEventNewTransaction(vid)
-	})
+	}, Vertex: func(_ *vertex.Vertex) {
+		// case with already attached transaction, which was converted to full vertex by previous attachment
+		repeated = true
+	}})
+	if repeated && options.attachmentCallback != nil {
+		// outside the vertex lock
+		callbackWhenAttachmentFinished(vid, env, options.attachmentCallback)
+	}
 	return
 }
 
+// callbackWhenAttachmentFinished calls attachment callback of the repeated attachment asynchronously.
+// For sequencer milestone it is called when the attacher, started by the first attachment, sets the status of the vertex
+func callbackWhenAttachmentFinished(vid *vertex.WrappedTx, env Environment, callback func(vid *vertex.WrappedTx, err error)) {
+	call := func() {
+		go func() {
+			env.IncCounter("call")
+			defer env.DecCounter("call")
+
+			callback(vid, vid.GetError())
+		}()
+	}
+	if !vid.IsSequencerMilestone() {
+		call()
+		return
+	}
+	vid.OnStatusDefined(func(_ vertex.Status) {
+		call()
+	})
+}
+
 // AttachTransactionFromBytes used for testing
 func AttachTransactionFromBytes(txBytes []byte, env Environment, opts ...AttachTxOption) (*vertex.WrappedTx, error) {
 	tx, err := transaction.FromBytes(txBytes, transaction.MainTxValidationOptions...)
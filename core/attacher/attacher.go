@@ -6,6 +6,7 @@ import (
 	"sort"
 
 	"github.com/lunfardo314/proxima/core/vertex"
+	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/multistate"
 	"github.com/lunfardo314/proxima/util"
@@ -124,6 +125,9 @@ func (a *attacher) solidifyStemOfTheVertex(v *vertex.Vertex, vidUnwrapped *verte
 		return false
 
 	case vertex.Undefined:
+		if a.isBeyondRetentionHorizon(stemVid) {
+			return false
+		}
 		return a.pullIfNeeded(stemVid)
 	}
 	panic("wrong vertex state")
@@ -192,11 +196,52 @@ func (a *attacher) solidifySequencerBaseline(v *vertex.Vertex, vidUnwrapped *ver
 	case vertex.Undefined:
 		a.Tracef(TraceTagSolidifySequencerBaseline, "baselineDirection %s is UNDEF -> pullIfNeeded", baselineDirection.IDShortString)
 
+		if a.isBeyondRetentionHorizon(baselineDirection) {
+			return false
+		}
+
 		return a.pullIfNeeded(baselineDirection)
 	}
 	panic("wrong vertex state")
 }
 
+// checkBaselineCoverage enforces minimum baseline coverage policy, if enabled. The policy is local to the node and
+// depends on the branches known at the moment, so it only prevents the sequencer from building on the branch.
+// It is not checked when milestones are attached, i.e. it never makes a milestone BAD
+func checkBaselineCoverage(env Environment, baseline *vertex.WrappedTx) error {
+	minCoverage := env.MinBaselineCoverage()
+	if minCoverage.Numerator <= 0 || minCoverage.Denominator <= 0 {
+		return nil
+	}
+	return CheckBaselineCoverage(&baseline.ID, baseline.GetLedgerCoverage(), env.BestBranchCoverage(baseline.Slot()), minCoverage)
+}
+
+// CheckBaselineCoverage returns ErrLowBaselineCoverage if ledger coverage of the branch is below
+// minCoverage fraction of the best coverage in the same slot. Zero fraction means policy is disabled
+func CheckBaselineCoverage(branchID *ledger.TransactionID, coverage, bestCoverage uint64, minCoverage global.Fraction) error {
+	if minCoverage.Numerator <= 0 || minCoverage.Denominator <= 0 {
+		return nil
+	}
+	// this order to avoid overflow
+	if threshold := (bestCoverage / uint64(minCoverage.Denominator)) * uint64(minCoverage.Numerator); coverage < threshold {
+		return fmt.Errorf("%w: coverage of the branch %s is %s, less than %s of the best coverage %s in the slot",
+			ErrLowBaselineCoverage, branchID.StringShort(), util.Th(coverage), minCoverage.String(), util.Th(bestCoverage))
+	}
+	return nil
+}
+
+// isBeyondRetentionHorizon checks if undefined baseline direction is older than the state retention horizon.
+// Its baseline state is not in the multi-state DB and can't be solidified, so pulling it makes no sense
+func (a *attacher) isBeyondRetentionHorizon(vid *vertex.WrappedTx) bool {
+	horizon := a.StateRetentionHorizon()
+	if vid.Slot() >= horizon {
+		return false
+	}
+	a.setError(fmt.Errorf("%w: baseline direction %s is older than the state retention horizon (slot %d)",
+		ErrReferencesPrunedState, vid.IDShortString(), horizon))
+	return true
+}
+
 func (a *attacher) attachVertexNonBranch(vid *vertex.WrappedTx) (ok, defined bool) {
 	a.Assertf(!vid.IsBranchTransaction(), "!vid.IsBranchTransaction(): %s", vid.IDShortString)
 
@@ -538,7 +583,7 @@ func (a *attacher) attachRooted(wOut vertex.WrappedOutput) (ok bool, isRooted bo
 	out, err := stateReader.GetOutputWithID(wOut.DecodeID())
 	if errors.Is(err, multistate.ErrNotFound) {
 		// output has not been found in the state -> Bad (already consumed)
-		err = fmt.Errorf("output %s is already consumed in the baseline state %s", wOut.IDShortString(), a.baseline.IDShortString())
+		err = fmt.Errorf("%w in the baseline state %s (double spend)", &ConflictError{OutputID: *wOut.DecodeID()}, a.baseline.IDShortString())
 		a.setError(err)
 		a.Tracef(TraceTagAttachOutput, "%v", err)
 		return false, false, true
@@ -656,9 +701,15 @@ func (a *attacher) attachInputID(consumerVertex *vertex.Vertex, consumerTxUnwrap
 
 	// conflict detection. We check if input is not consumed by some known transaction in the attacher scope
 	if conflict := vidInputTx.AttachConsumer(inputOid.Index(), consumerTxUnwrapped, a.checkConflictsFunc(consumerVertex, consumerTxUnwrapped)); conflict != nil {
-		err := fmt.Errorf("input %s of consumer %s conflicts with another consumer %s in the baseline state %s (double spend)",
-			inputOid.StringShort(), consumerTxUnwrapped.IDShortString(), conflict.IDShortString(), a.baseline.IDShortString())
-		a.setError(err)
+		conflictErr := &ConflictError{
+			OutputID:  inputOid,
+			Consumers: []ledger.TransactionID{consumerTxUnwrapped.ID},
+		}
+		if conflict.ID != (ledger.TransactionID{}) {
+			// not the transaction being constructed by the incremental attacher
+			conflictErr.Consumers = append(conflictErr.Consumers, conflict.ID)
+		}
+		a.setError(fmt.Errorf("%w in the baseline state %s (double spend)", conflictErr, a.baseline.IDShortString()))
 		return nil, false
 	}
 	return vidInputTx, true
@@ -700,12 +751,22 @@ func (a *attacher) setBaseline(baselineVID *vertex.WrappedTx, currentTS ledger.T
 		return false
 	}
 
-	rr, found := multistate.FetchRootRecord(a.StateStore(), baselineVID.ID)
-	a.Assertf(found, "setBaseline: can't fetch root record for %s", baselineVID.IDShortString)
+	var baselineCoverage uint64
+	if a.doNotLoadBranch {
+		// good branch vertex always has coverage, which is the same as in the root record.
+		// Supply is only needed for branch transactions
+		cov := baselineVID.GetLedgerCoverageP()
+		a.Assertf(cov != nil, "setBaseline: coverage of the baseline %s is not set", baselineVID.IDShortString)
+		baselineCoverage = *cov
+	} else {
+		rr, found := multistate.FetchRootRecord(a.StateStore(), baselineVID.ID)
+		a.Assertf(found, "setBaseline: can't fetch root record for %s", baselineVID.IDShortString)
+		a.baselineSupply = rr.Supply
+		baselineCoverage = rr.LedgerCoverage
+	}
 
 	a.baseline = baselineVID
-	a.baselineSupply = rr.Supply
-	a.accumulatedCoverage = rr.LedgerCoverage >> (int(currentTS.Slot() - baselineVID.Slot()))
+	a.accumulatedCoverage = baselineCoverage >> (int(currentTS.Slot() - baselineVID.Slot()))
 
 	if currentTS.IsSlotBoundary() {
 		a.Assertf(baselineVID.Slot() < currentTS.Slot(), "baselineVID.Slot() < currentTS.Slot()")
@@ -713,6 +774,7 @@ func (a *attacher) setBaseline(baselineVID *vertex.WrappedTx, currentTS ledger.T
 		a.Assertf(baselineVID.Slot() == currentTS.Slot(), "baselineVID.Slot() == currentTS.Slot()")
 		a.accumulatedCoverage >>= 1
 	}
+	a.baselineCoverage = a.accumulatedCoverage
 	return true
 }
 
@@ -730,12 +792,26 @@ func (a *attacher) adjustCoverage() {
 		return
 	}
 	// sequencer output is not rooted (branch is just endorsed) -> add its inflation to the accumulatedCoverage
-	seqOut := multistate.MustSequencerOutputOfBranch(a.StateStore(), baseSeqOut.VID.ID).Output
+	var seqOut *ledger.Output
+	if a.doNotLoadBranch {
+		seqOut = baseSeqOut.VID.MustOutputAt(baseSeqOut.Index)
+	} else {
+		seqOut = multistate.MustSequencerOutputOfBranch(a.StateStore(), baseSeqOut.VID.ID).Output
+	}
 
 	a.coverageAdjustment = seqOut.Inflation(true)
 	a.accumulatedCoverage += a.coverageAdjustment
 }
 
+// CoverageDelta is the marginal ledger coverage contributed by the past cone on top of the baseline:
+// the accumulatedCoverage minus the part of it inherited from the baseline branch.
+// It is the sum of amounts of newly rooted outputs plus the coverage adjustment, if any.
+// Note that ledger coverage is a plain uint64, there is no separate LedgerCoverage type with
+// per-slot deltas: the baseline part is the baseline's coverage halved once per slot back (see setBaseline)
+func (a *attacher) CoverageDelta() uint64 {
+	return a.accumulatedCoverage - a.baselineCoverage
+}
+
 // IsCoverageAdjusted for consistency assertions
 func (a *attacher) IsCoverageAdjusted() bool {
 	return a.coverageAdjusted
@@ -747,6 +823,7 @@ func (a *attacher) dumpLines(prefix ...string) *lines.Lines {
 	ret.Add("attacher %s", a.name)
 	ret.Add("   baseline: %s", a.baseline.IDShortString())
 	ret.Add("   accumulatedCoverage: %s", util.Th(a.accumulatedCoverage))
+	ret.Add("   coverageDelta: %s", util.Th(a.CoverageDelta()))
 	ret.Add("   baselineSupply: %s", util.Th(a.baselineSupply))
 	ret.Add("   vertices:")
 	ret.Append(a.linesVertices(prefix...))
@@ -61,6 +61,9 @@ func NewIncrementalAttacher(name string, env Environment, targetTs ledger.Time,
 		return nil, fmt.Errorf("NewIncrementalAttacher %s: failed to determine valid baselineDirection branch of %s. baseline direction: %s",
 			name, extend.IDShortString(), baselineDirection.IDShortString())
 	}
+	if err := checkBaselineCoverage(env, baseline); err != nil {
+		return nil, fmt.Errorf("NewIncrementalAttacher %s: %w", name, err)
+	}
 
 	ret := &IncrementalAttacher{
 		attacher: newPastConeAttacher(env, name),
@@ -215,6 +218,13 @@ func (a *IncrementalAttacher) commitStateDelta() {
 	a.referenced.commitDelta()
 }
 
+// _tagAlongUndo is the attacher state before the tag-along input was inserted,
+// plus transactions referenced by the insertion
+type _tagAlongUndo struct {
+	saved      *_stateSnapshot
+	referenced set.Set[*vertex.WrappedTx]
+}
+
 // InsertEndorsement preserves consistency in case of failure
 func (a *IncrementalAttacher) InsertEndorsement(endorsement *vertex.WrappedTx) error {
 	util.Assertf(!a.IsClosed(), "a.IsClosed()")
@@ -229,6 +239,8 @@ func (a *IncrementalAttacher) InsertEndorsement(endorsement *vertex.WrappedTx) e
 		return err
 	}
 	a.commitStateDelta()
+	// snapshots taken before the endorsement would roll it back -> inputs inserted so far can't be undone
+	a.tagAlongUndo = nil
 	return nil
 }
 
@@ -292,13 +304,53 @@ func (a *IncrementalAttacher) InsertTagAlongInput(wOut vertex.WrappedOutput) (bo
 	a.inputs = append(a.inputs, wOut)
 	util.AssertNoError(a.err)
 
+	if a.undoEnabled {
+		a.tagAlongUndo = append(a.tagAlongUndo, _tagAlongUndo{
+			saved:      saved,
+			referenced: a.referenced.delta.Clone(),
+		})
+	}
 	a.commitStateDelta()
 	return true, nil
 }
 
+// EnableUndo makes the attacher to keep the state before each tag-along input inserted after the call,
+// so that it can be removed by UndoLastInput. It costs a copy of the past cone per input, so it is disabled by default
+func (a *IncrementalAttacher) EnableUndo() {
+	a.undoEnabled = true
+}
+
+// UndoLastInput removes the tag-along input inserted last by InsertTagAlongInput and restores the attacher state
+// (past cone, rooted outputs, coverage and references) as it was before the insertion.
+// Undo must be enabled by EnableUndo before the input is inserted.
+// Extend and stem inputs, as well as endorsements, can't be removed. Tag-along inputs inserted before the last
+// endorsement can't be removed either. Undo is not possible after coverage has been adjusted
+func (a *IncrementalAttacher) UndoLastInput() error {
+	util.Assertf(!a.IsClosed(), "a.IsClosed()")
+	if !a.undoEnabled {
+		return fmt.Errorf("UndoLastInput: undo is not enabled")
+	}
+	if len(a.tagAlongUndo) == 0 {
+		return fmt.Errorf("UndoLastInput: no tag-along inputs to remove")
+	}
+	if a.coverageAdjusted {
+		return fmt.Errorf("UndoLastInput: coverage already adjusted")
+	}
+	last := a.tagAlongUndo[len(a.tagAlongUndo)-1]
+	a.tagAlongUndo = a.tagAlongUndo[:len(a.tagAlongUndo)-1]
+
+	a.attacher.vertices = last.saved.vertices
+	a.attacher.rooted = last.saved.rooted
+	a.accumulatedCoverage = last.saved.coverage
+	a.referenced.unReference(last.referenced)
+	a.inputs = a.inputs[:len(a.inputs)-1]
+	return nil
+}
+
 // MakeSequencerTransaction creates sequencer transaction from the incremental attacher.
-// Increments slotInflation by the amount inflated in the transaction
-func (a *IncrementalAttacher) MakeSequencerTransaction(seqName string, privateKey ed25519.PrivateKey, cmdParser SequencerCommandParser) (*transaction.Transaction, error) {
+// Increments slotInflation by the amount inflated in the transaction.
+// Optional outputCache is consulted before loading inputs from vertices and is populated with consumed outputs
+func (a *IncrementalAttacher) MakeSequencerTransaction(seqName string, privateKey ed25519.PrivateKey, cmdParser SequencerCommandParser, outputCache txbuilder.OutputCache) (*transaction.Transaction, error) {
 	util.Assertf(!a.IsClosed(), "!a.IsDisposed()")
 	otherInputs := make([]*ledger.OutputWithID, 0, len(a.inputs))
 
@@ -310,17 +362,17 @@ func (a *IncrementalAttacher) MakeSequencerTransaction(seqName string, privateKe
 	for i, wOut := range a.inputs {
 		switch {
 		case i == 0:
-			if chainIn, err = wOut.VID.OutputWithIDAt(a.inputs[0].Index); err != nil {
+			if chainIn, err = loadOutput(wOut, outputCache); err != nil {
 				return nil, err
 			}
 		case i == 1 && a.targetTs.Tick() == 0:
 			var stemInTmp ledger.OutputWithID
-			if stemInTmp, err = a.stemOutput.VID.OutputWithIDAt(a.stemOutput.Index); err != nil {
+			if stemInTmp, err = loadOutput(a.stemOutput, outputCache); err != nil {
 				return nil, err
 			}
 			stemIn = &stemInTmp
 		default:
-			o, err := wOut.VID.OutputWithIDAt(wOut.Index)
+			o, err := loadOutput(wOut, outputCache)
 			if err != nil {
 				return nil, err
 			}
@@ -349,6 +401,7 @@ func (a *IncrementalAttacher) MakeSequencerTransaction(seqName string, privateKe
 		PrivateKey:        privateKey,
 		PutInflation:      true,
 		ReturnInputLoader: true,
+		OutputCache:       outputCache,
 	})
 	if err != nil {
 		return nil, err
@@ -405,3 +458,19 @@ func (a *IncrementalAttacher) Extending() vertex.WrappedOutput {
 func (a *IncrementalAttacher) Endorsing() []*vertex.WrappedTx {
 	return a.endorse
 }
+
+// loadOutput returns output from the cache, if present. Otherwise, loads it from the vertex
+func loadOutput(wOut vertex.WrappedOutput, outputCache txbuilder.OutputCache) (ledger.OutputWithID, error) {
+	oid := ledger.NewOutputID(&wOut.VID.ID, wOut.Index)
+	o, err := outputCache.Load(oid, func() (*ledger.Output, error) {
+		ret, err := wOut.VID.OutputAt(wOut.Index)
+		if err == nil && ret == nil {
+			err = fmt.Errorf("output %s is not available", oid.StringShort())
+		}
+		return ret, err
+	})
+	if err != nil {
+		return ledger.OutputWithID{}, err
+	}
+	return ledger.OutputWithID{ID: oid, Output: o}, nil
+}
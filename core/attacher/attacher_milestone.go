@@ -21,14 +21,23 @@ const (
 	periodicCheckEach       = 50 * time.Millisecond
 )
 
-func runMilestoneAttacher(
-	vid *vertex.WrappedTx,
-	metadata *txmetadata.TransactionMetadata,
-	callback func(vid *vertex.WrappedTx, err error),
-	env Environment,
-	ctx context.Context,
-) {
-	a := newMilestoneAttacher(vid, env, metadata, ctx)
+// runMilestoneAttacher runs attacher of the sequencer milestone with the options provided to AttachTransaction
+func runMilestoneAttacher(vid *vertex.WrappedTx, env Environment, options *_attacherOptions) {
+	ctx := options.ctx
+	if options.attachTimeout > 0 {
+		if ctx == nil {
+			ctx = env.Ctx()
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, options.attachTimeout, ErrAttachTimeout)
+		defer cancel()
+	}
+	a := newMilestoneAttacher(vid, env, options.metadata, ctx)
+	a.doNotGossip = options.doNotGossip
+	// branch transaction is committed to the state, so it always needs branch data of the baseline from the state
+	a.doNotLoadBranch = options.doNotLoadBranch && !vid.IsBranchTransaction()
+	a.pullFromPeer = options.pullFromPeer
+	callback := options.attachmentCallback
 	var err error
 
 	defer func() {
@@ -177,7 +186,12 @@ func (a *milestoneAttacher) lazyRepeat(loopName string, fun func() vertex.Status
 			a.Tracef(TraceTagAttachMilestone, "poked")
 
 		case <-a.ctx.Done():
-			a.setError(fmt.Errorf("%w. Undefined past cone: %s", global.ErrInterrupted, a.undefinedListLines().Join(", ")))
+			if errors.Is(context.Cause(a.ctx), ErrAttachTimeout) {
+				a.setError(fmt.Errorf("%w after %v. Undefined past cone: %s",
+					ErrAttachTimeout, time.Since(a.finals.started), a.undefinedListLines().Join(", ")))
+			} else {
+				a.setError(fmt.Errorf("%w. Undefined past cone: %s", global.ErrInterrupted, a.undefinedListLines().Join(", ")))
+			}
 			return vertex.Bad
 
 		case <-time.After(periodicCheckEach):
@@ -352,7 +366,7 @@ func (a *milestoneAttacher) logFinalStatusString(msData *ledger.MilestoneData) s
 		if a.finals.baseline != nil {
 			bl = a.finals.baseline.StringShort()
 		}
-		msg += fmt.Sprintf(", base: %s, cov: %s", bl, util.Th(a.finals.coverage))
+		msg += fmt.Sprintf(", base: %s, cov: %s, cov delta: %s", bl, util.Th(a.finals.coverage), util.Th(a.finals.coverageDelta))
 		if a.VerbosityLevel() > 0 {
 			if a.vid.IsBranchTransaction() {
 				msg += fmt.Sprintf(", slot inflation: %s, supply: %s", util.Th(a.finals.slotInflation), util.Th(a.finals.supply))
@@ -53,10 +53,10 @@ func (a *milestoneAttacher) _checkConsistencyBeforeFinalization() (err error) {
 	}
 	for vid, flags := range a.vertices {
 		if !flags.FlagsUp(flagAttachedVertexKnown) {
-			return fmt.Errorf("wrong flags 1 %08b in %s", flags, vid.IDShortString())
+			return fmt.Errorf("wrong flags 1 %s in %s", flags, vid.IDShortString())
 		}
 		if !flags.FlagsUp(flagAttachedVertexDefined) && vid != a.vid {
-			return fmt.Errorf("wrong flags 2 %08b in %s", flags, vid.IDShortString())
+			return fmt.Errorf("wrong flags 2 %s in %s", flags, vid.IDShortString())
 		}
 		if vid == a.vid {
 			if vid.GetTxStatus() == vertex.Bad {
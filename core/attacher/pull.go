@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/lunfardo314/proxima/core/vertex"
 )
 
@@ -31,12 +32,12 @@ func (a *attacher) pullIfNeededUnwrapped(virtualTx *vertex.VirtualTransaction, d
 	if virtualTx.PullRulesDefined() {
 		a.Tracef(TraceTagPull, "pullIfNeededUnwrapped: %s. Pull rules defined", deptVID.IDShortString)
 
-		if virtualTx.PullPatienceExpired(maxPullAttempts) {
+		if patience := vertex.PullPatience(repeatPullAfter, maxPullAttempts); virtualTx.PullPatienceExpired(patience) {
 			// solidification deadline
 			a.Log().Errorf("SOLIDIFICATION FAILURE %s at depth %d, hex: %s attacher: %s ",
 				deptVID.IDShortString(), deptVID.GetAttachmentDepthNoLock(), deptVID.ID.StringHex(), a.Name())
-			a.setError(fmt.Errorf("%w(%d x %v): can't solidify dependency %s",
-				ErrSolidificationDeadline, maxPullAttempts, repeatPullAfter, deptVID.IDShortString()))
+			a.setError(fmt.Errorf("%w (pulled for %v, patience %v): can't solidify dependency %s",
+				ErrSolidificationDeadline, virtualTx.PullingFor().Round(time.Millisecond), patience, deptVID.IDShortString()))
 			return false
 		}
 		if virtualTx.PullNeeded() {
@@ -65,32 +66,54 @@ func (a *attacher) pull(virtualTx *vertex.VirtualTransaction, deptVID *vertex.Wr
 	a.Tracef(TraceTagPull, "pull IN %s", deptVID.IDShortString)
 	defer a.Tracef(TraceTagPull, "pull OUT %s", deptVID.IDShortString)
 
-	// TODO prevent repetitive reading from DB every pull
+	pullFromStoreOrPeers(a, virtualTx, deptVID, repeatPullAfter, nPeers, a.pokeMe, a.pullFromPeer)
+	return true
+}
 
-	txBytesWithMetadata := a.TxBytesStore().GetTxBytesWithMetadata(&deptVID.ID)
+// pullFromStoreOrPeers loads transaction from the store, if it is there, otherwise pulls it from peers.
+// pokeMe, if not nil, is called before pulling from peers.
+// fromPeer, if not nil, is the peer the transaction is pulled from until it fails repeatedly
+func pullFromStoreOrPeers(env Environment, virtualTx *vertex.VirtualTransaction, deptVID *vertex.WrappedTx, repeatPullAfter time.Duration, nPeers int, pokeMe func(vid *vertex.WrappedTx), fromPeer *peer.ID) {
+	// the store is checked only before the first pull attempt. Transaction bytes stored later
+	// come with the transaction itself, so repeated pulls skip reading from DB
+	var txBytesWithMetadata []byte
+	if virtualTx.PullAttempts() == 0 {
+		txBytesWithMetadata = env.TxBytesStore().GetTxBytesWithMetadata(&deptVID.ID)
+	}
 	if len(txBytesWithMetadata) > 0 {
-		a.Tracef(TraceTagPull, "pull found in store %s", deptVID.IDShortString)
+		env.Tracef(TraceTagPull, "pull found in store %s", deptVID.IDShortString)
 
 		virtualTx.SetPullNotNeeded()
 
 		go func() {
-			a.IncCounter("store")
-			defer a.DecCounter("store")
+			env.IncCounter("store")
+			defer env.DecCounter("store")
 
-			if _, err := a.TxBytesFromStoreIn(txBytesWithMetadata); err != nil {
-				a.Log().Errorf("TxBytesFromStoreIn %s returned '%v'", deptVID.IDShortString(), err)
+			if _, err := env.TxBytesFromStoreIn(txBytesWithMetadata); err != nil {
+				env.Log().Errorf("TxBytesFromStoreIn %s returned '%v'", deptVID.IDShortString(), err)
 			}
 		}()
-		return true
+		return
 	}
-	a.Tracef(TraceTagPull, "pull NOT found in store %s", deptVID.IDShortString)
+	env.Tracef(TraceTagPull, "pull NOT found in store %s", deptVID.IDShortString)
 	// failed to load txBytes from store -> pull it from peers
-	a.pokeMe(deptVID)
+	if pokeMe != nil {
+		pokeMe(deptVID)
+	}
 
 	// add transaction to the wanted/expected list
 
-	a.AddWantedTransaction(&deptVID.ID)
-	nPulls := a.PullFromNPeers(nPeers, &deptVID.ID)
-	virtualTx.SetPullHappened(nPulls, repeatPullAfter)
-	return true
+	env.AddWantedTransaction(&deptVID.ID)
+	if fromPeer == nil {
+		nPulls := env.PullFromNPeers(nPeers, &deptVID.ID)
+		virtualTx.SetPullHappened(nPulls, repeatPullAfter)
+		return
+	}
+	// each repeated pull means the previous pulls from the peer were not answered
+	nPulls, fromPeerOnly := env.PullFromPeer(*fromPeer, &deptVID.ID, virtualTx.NumPullsFromPeer(), nPeers)
+	if fromPeerOnly {
+		virtualTx.SetPullFromPeerHappened(repeatPullAfter)
+	} else {
+		virtualTx.SetPullHappened(nPulls, repeatPullAfter)
+	}
 }
@@ -6,6 +6,9 @@ import (
 	"github.com/lunfardo314/proxima/util/set"
 )
 
+// referenceHolder is the name of the attacher as the holder of vertex references, see vertex.ReferenceHolders
+const referenceHolder = "attacher"
+
 // referencedSet represents a buffer for referencing transactions with the rollback possibility if beginDelta is not called
 type referencedSet struct {
 	committed set.Set[*vertex.WrappedTx]
@@ -31,7 +34,7 @@ func (r *referencedSet) commitDelta() {
 
 func (r *referencedSet) rollbackDelta() {
 	r.delta.ForEach(func(vid *vertex.WrappedTx) bool {
-		vid.UnReference()
+		vid.UnReference(referenceHolder)
 		return true
 	})
 	r.delta = nil
@@ -45,7 +48,7 @@ func (r *referencedSet) reference(vid *vertex.WrappedTx) bool {
 	if r.delta != nil && r.delta.Contains(vid) {
 		return true
 	}
-	if !vid.Reference() {
+	if !vid.Reference(referenceHolder) {
 		// failed to reference
 		return false
 	}
@@ -58,6 +61,17 @@ func (r *referencedSet) reference(vid *vertex.WrappedTx) bool {
 	return true
 }
 
+// unReference un-references committed transactions and removes them from the set
+func (r *referencedSet) unReference(vids set.Set[*vertex.WrappedTx]) {
+	vids.ForEach(func(vid *vertex.WrappedTx) bool {
+		if r.committed.Contains(vid) {
+			vid.UnReference(referenceHolder)
+			delete(r.committed, vid)
+		}
+		return true
+	})
+}
+
 func (r *referencedSet) mustReference(vid *vertex.WrappedTx) {
 	util.Assertf(r.reference(vid), "r.reference(vid)")
 }
@@ -65,7 +79,7 @@ func (r *referencedSet) mustReference(vid *vertex.WrappedTx) {
 func (r *referencedSet) unReferenceAll() {
 	r.rollbackDelta()
 	r.committed.ForEach(func(vid *vertex.WrappedTx) bool {
-		vid.UnReference()
+		vid.UnReference(referenceHolder)
 		return true
 	})
 	r.committed = set.New[*vertex.WrappedTx]()
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/lunfardo314/proxima/core/txmetadata"
 	"github.com/lunfardo314/proxima/core/vertex"
 	"github.com/lunfardo314/proxima/global"
@@ -30,12 +32,16 @@ type (
 		TxBytesStore() global.TxBytesStore
 		TxBytesFromStoreIn(txBytesWithMetadata []byte) (*ledger.TransactionID, error)
 		AddWantedTransaction(txid *ledger.TransactionID)
+		StateRetentionHorizon() ledger.Slot
+		MinBaselineCoverage() global.Fraction
+		BestBranchCoverage(slot ledger.Slot) uint64
 	}
 
 	pullEnvironment interface {
 		PokeMe(me, with *vertex.WrappedTx)
 		PokeAllWith(wanted *vertex.WrappedTx)
 		PullFromNPeers(nPeers int, txid *ledger.TransactionID) int
+		PullFromPeer(id peer.ID, txid *ledger.TransactionID, failedPulls, nPeersFallback int) (int, bool)
 	}
 
 	postEventEnvironment interface {
@@ -63,6 +69,7 @@ type (
 		closed              bool
 		pokeMe              func(vid *vertex.WrappedTx)
 		accumulatedCoverage uint64 // accumulated accumulatedCoverage
+		baselineCoverage    uint64 // part of the accumulatedCoverage inherited from the baseline
 		coverageAdjustment  uint64
 		coverageAdjusted    bool
 		slotInflation       uint64
@@ -70,6 +77,11 @@ type (
 		baselineSupply uint64
 		// trace this local attacher with all tags
 		forceTrace string
+		// branch data of the baseline (coverage, sequencer output) is taken from the baseline vertex
+		// instead of loading it from the state store
+		doNotLoadBranch bool
+		// if not nil, dependencies are pulled from the peer the transaction was received from first
+		pullFromPeer *peer.ID
 		// for incremental attacher we need slightly extended conflict checker
 		checkConflictsFunc func(consumerVertex *vertex.Vertex, consumerTx *vertex.WrappedTx) checkConflictingConsumersFunc
 	}
@@ -83,6 +95,10 @@ type (
 		inputs     []vertex.WrappedOutput
 		targetTs   ledger.Time
 		stemOutput vertex.WrappedOutput
+		// one for each tag-along input, in the order of insertion. Used to undo insertion of the input.
+		// Only kept if undo is enabled
+		undoEnabled  bool
+		tagAlongUndo []_tagAlongUndo
 	}
 
 	// milestoneAttacher is used to attach a sequencer transaction
@@ -96,6 +112,7 @@ type (
 		pokeClosingMutex sync.RWMutex
 		finals           attachFinals
 		closed           bool
+		doNotGossip      bool
 	}
 
 	_attacherOptions struct {
@@ -105,6 +122,11 @@ type (
 		enforceTimestamp   bool
 		ctx                context.Context
 		depth              int
+		doNotGossip        bool
+		attachTimeout      time.Duration
+		doNotLoadBranch    bool
+		pullNonBranch      bool
+		pullFromPeer       *peer.ID
 	}
 	AttachTxOption func(*_attacherOptions)
 
@@ -113,6 +135,7 @@ type (
 		numInputs          int
 		numOutputs         int
 		coverage           uint64
+		coverageDelta      uint64
 		slotInflation      uint64
 		supply             uint64
 		root               common.VCommitment
@@ -130,6 +153,15 @@ type (
 
 	Flags uint8
 
+	// ConflictError is returned by the attacher when the output is consumed by more than one transaction
+	// in the past cone (double spend). Consumers are the conflicting consumers known to the attacher.
+	// In the incremental attacher one of the consumers may be the transaction being constructed, it is not listed.
+	// Consumers are empty when the output is already consumed in the baseline state
+	ConflictError struct {
+		OutputID  ledger.OutputID
+		Consumers []ledger.TransactionID
+	}
+
 	checkConflictingConsumersFunc func(existingConsumers set.Set[*vertex.WrappedTx]) (conflict *vertex.WrappedTx)
 
 	SequencerCommandParser interface {
@@ -142,22 +174,57 @@ type (
 	}
 )
 
-var ErrSolidificationDeadline = errors.New("solidification deadline")
+var (
+	ErrSolidificationDeadline = errors.New("solidification deadline")
+	ErrAttachTimeout          = errors.New("attach timeout")
+	ErrReferencesPrunedState  = errors.New("references pruned state")
+	ErrLowBaselineCoverage    = errors.New("low coverage of the baseline")
+)
 
 func (f Flags) FlagsUp(fl Flags) bool {
 	return f&fl == fl
 }
 
+var flagNames = []struct {
+	flag Flags
+	name string
+}{
+	{flagAttachedVertexKnown, "KNOWN"},
+	{flagAttachedVertexDefined, "DEFINED"},
+	{flagAttachedVertexCheckedIfRooted, "CHECKED_ROOTED"},
+	{flagAttachedVertexEndorsementsSolid, "ENDORSEMENTS_SOLID"},
+	{flagAttachedVertexInputsSolid, "INPUTS_SOLID"},
+	{flagAttachedVertexAskedForPoke, "ASKED_FOR_POKE"},
+}
+
+// String renders local flags which are up, for example 'KNOWN|DEFINED|INPUTS_SOLID'. Unknown bits are rendered in hex
 func (f Flags) String() string {
-	return fmt.Sprintf("%08b known: %v, defined: %v, checkedRooted: %v, endorsementsOk: %v, inputsOk: %v, asked for poke: %v",
-		f,
-		f.FlagsUp(flagAttachedVertexKnown),
-		f.FlagsUp(flagAttachedVertexDefined),
-		f.FlagsUp(flagAttachedVertexCheckedIfRooted),
-		f.FlagsUp(flagAttachedVertexEndorsementsSolid),
-		f.FlagsUp(flagAttachedVertexInputsSolid),
-		f.FlagsUp(flagAttachedVertexAskedForPoke),
-	)
+	names := make([]string, 0, len(flagNames))
+	rest := f
+	for _, fn := range flagNames {
+		if f.FlagsUp(fn.flag) {
+			names = append(names, fn.name)
+			rest &^= fn.flag
+		}
+	}
+	if rest != 0 {
+		names = append(names, fmt.Sprintf("0x%02x", byte(rest)))
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, "|")
+}
+
+func (e *ConflictError) Error() string {
+	if len(e.Consumers) == 0 {
+		return fmt.Sprintf("output %s is already consumed", e.OutputID.StringShort())
+	}
+	consumer, another := e.Consumers[0].StringShort(), "<the transaction being constructed>"
+	if len(e.Consumers) > 1 {
+		another = e.Consumers[1].StringShort()
+	}
+	return fmt.Sprintf("input %s of consumer %s conflicts with another consumer %s", e.OutputID.StringShort(), consumer, another)
 }
 
 func WithTransactionMetadata(metadata *txmetadata.TransactionMetadata) AttachTxOption {
@@ -182,6 +249,48 @@ func WithEnforceTimestampBeforeRealTime(options *_attacherOptions) {
 	options.enforceTimestamp = true
 }
 
+// WithDoNotGossip sequencer milestone is not gossiped to peers after attachment
+func WithDoNotGossip(options *_attacherOptions) {
+	options.doNotGossip = true
+}
+
+// WithAttachTimeout limits duration of the sequencer milestone attachment. If the milestone is not solid
+// when the timeout expires, the attacher is closed and the transaction becomes BAD with ErrAttachTimeout.
+// Not positive value means no timeout, which is the default. In that case attachment is limited only by
+// the solidification deadline of dependencies and by the context
+func WithAttachTimeout(d time.Duration) AttachTxOption {
+	return func(options *_attacherOptions) {
+		options.attachTimeout = d
+	}
+}
+
+// WithDoNotLoadBranch the milestone attacher started by AttachTransaction does not read branch data of the baseline
+// from the state store: root record and sequencer output of the baseline branch are taken from the baseline vertex
+// in the memDAG. Outputs of the past cone are still checked against the state of the baseline, through the state
+// reader cached by the environment (GetStateReaderForTheBranch). The option is ignored for branch transactions,
+// because they are committed to the state. It has no effect on AttachTxID.
+// The option does not combine with the minimum baseline coverage policy: the policy is enforced only by the
+// incremental attacher of the sequencer, never by the milestone attacher
+func WithDoNotLoadBranch(options *_attacherOptions) {
+	options.doNotLoadBranch = true
+}
+
+// WithPullNonBranch AttachTxID starts pull of the new non-branch transaction immediately: the transaction is loaded
+// from the tx store, if it is there, otherwise it is pulled from peers. By default, the new non-branch
+// transaction is put to the memDAG without pulling, and it is pulled by the attacher only when needed.
+// The option has no effect on the existing vertex and on branch transactions
+func WithPullNonBranch(options *_attacherOptions) {
+	options.pullNonBranch = true
+}
+
+// WithPullFromPeer the milestone attacher pulls missing dependencies from the peer the transaction was received from.
+// Pull is escalated to other peers only after repeated failures. It has no effect on AttachTxID
+func WithPullFromPeer(id peer.ID) AttachTxOption {
+	return func(options *_attacherOptions) {
+		options.pullFromPeer = &id
+	}
+}
+
 func WithInvokedBy(name string) AttachTxOption {
 	return func(options *_attacherOptions) {
 		options.calledBy = name
@@ -19,6 +19,7 @@ func (a *milestoneAttacher) wrapUpAttacher() {
 	a.finals.numVertices = len(a.vertices)
 
 	a.finals.coverage = a.accumulatedCoverage
+	a.finals.coverageDelta = a.CoverageDelta()
 	//a.Assertf(a.finals.accumulatedCoverage > 0, "final accumulatedCoverage must be positive")
 	a.finals.slotInflation = a.slotInflation
 
@@ -47,6 +48,9 @@ func (a *milestoneAttacher) wrapUpAttacher() {
 	}
 	a.Tracef(TraceTagAttachMilestone, "%s: calculated metadata: %s", a.name, calculatedMetadata.String)
 
+	if a.doNotGossip {
+		return
+	}
 	a.vid.Unwrap(vertex.UnwrapOptions{Vertex: func(v *vertex.Vertex) {
 		// gossip tx if needed
 		a.GossipAttachedTransaction(v.Tx, &calculatedMetadata)
@@ -81,8 +85,10 @@ func (a *milestoneAttacher) commitBranch() {
 		a.TraceTx(&vid.ID, "commitBranch in attacher %s: added to the baseline state %s", a.name, bsName)
 		// ADD OUTPUT mutations only for not consumed outputs
 		producedOutputIndices := vid.NotConsumedOutputIndices(allVerticesSet)
-		for _, idx := range producedOutputIndices {
-			muts.InsertAddOutputMutation(vid.OutputID(idx), vid.MustOutputAt(idx))
+		producedOutputs, err := vid.OutputsAt(producedOutputIndices)
+		util.AssertNoError(err)
+		for i, idx := range producedOutputIndices {
+			muts.InsertAddOutputMutation(vid.OutputID(idx), producedOutputs[i])
 			a.finals.numCreatedOutputs++
 		}
 	}
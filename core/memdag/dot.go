@@ -0,0 +1,161 @@
+package memdag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dominikbraun/graph"
+	"github.com/lunfardo314/proxima/core/vertex"
+	"github.com/lunfardo314/proxima/util"
+)
+
+// dotWriter writes graph in DOT format directly to the writer as the DAG is traversed.
+// Write errors are sticky in bufio.Writer and are returned by Flush
+type dotWriter struct {
+	w         *bufio.Writer
+	numPoints int
+}
+
+// dotEdge edge to the dependency of the vertex. Several outputs of the same transaction may be consumed,
+// so labels are collected
+type dotEdge struct {
+	target      string
+	labels      []string
+	endorsement bool
+}
+
+func newDotWriter(w io.Writer) *dotWriter {
+	return &dotWriter{w: bufio.NewWriter(w)}
+}
+
+func (dw *dotWriter) writeAttributes(attr map[string]string) {
+	if len(attr) == 0 {
+		return
+	}
+	keys := util.KeysSorted(attr, func(k1, k2 string) bool { return k1 < k2 })
+	_, _ = dw.w.WriteString(" [ ")
+	for i, k := range keys {
+		if i > 0 {
+			_, _ = dw.w.WriteString(", ")
+		}
+		_, _ = fmt.Fprintf(dw.w, "%s=%s", k, strconv.Quote(attr[k]))
+	}
+	_, _ = dw.w.WriteString(" ]")
+}
+
+func (dw *dotWriter) node(id string, attr []func(*graph.VertexProperties)) {
+	props := graph.VertexProperties{Attributes: make(map[string]string)}
+	for _, a := range attr {
+		a(&props)
+	}
+	_, _ = fmt.Fprintf(dw.w, "\t%s", strconv.Quote(id))
+	dw.writeAttributes(props.Attributes)
+	_, _ = dw.w.WriteString(";\n")
+}
+
+// point writes point node, used for nil dependencies. Returns id of the node
+func (dw *dotWriter) point(xlabel string) string {
+	id := fmt.Sprintf("p%d", dw.numPoints)
+	dw.numPoints++
+	attr := map[string]string{"shape": "point"}
+	if xlabel != "" {
+		attr["xlabel"] = xlabel
+		attr["fontsize"] = "10"
+	}
+	_, _ = fmt.Fprintf(dw.w, "\t%s", strconv.Quote(id))
+	dw.writeAttributes(attr)
+	_, _ = dw.w.WriteString(";\n")
+	return id
+}
+
+func (dw *dotWriter) edge(source string, e *dotEdge) {
+	attr := make(map[string]string)
+	if len(e.labels) > 0 {
+		attr["label"] = strings.Join(e.labels, ", ")
+		attr["fontsize"] = "10"
+	}
+	if e.endorsement {
+		attr["color"] = "red"
+	}
+	_, _ = fmt.Fprintf(dw.w, "\t%s -> %s", strconv.Quote(source), strconv.Quote(e.target))
+	dw.writeAttributes(attr)
+	_, _ = dw.w.WriteString(";\n")
+}
+
+// edges writes edges to inputs and endorsements of the vertex. Edges to the same dependency are
+// deduplicated: labels of consumed outputs are merged, endorsement of the consumed transaction only changes color
+func (dw *dotWriter) edges(vid *vertex.WrappedTx) {
+	id := vid.IDVeryShort()
+	edges := make([]*dotEdge, 0)
+	edgeTo := func(target string) *dotEdge {
+		idx := slices.IndexFunc(edges, func(e *dotEdge) bool { return e.target == target })
+		if idx >= 0 {
+			return edges[idx]
+		}
+		edges = append(edges, &dotEdge{target: target})
+		return edges[len(edges)-1]
+	}
+	vid.RUnwrap(vertex.UnwrapOptions{Vertex: func(v *vertex.Vertex) {
+		v.ForEachInputDependency(func(i byte, inp *vertex.WrappedTx) bool {
+			if inp == nil {
+				oid := v.Tx.MustInputAt(i)
+				edgeTo(dw.point(oid.StringVeryShort()))
+				return true
+			}
+			o := v.GetConsumedOutput(i)
+			outIndex := v.Tx.MustOutputIndexOfTheInput(i)
+			amountStr := "???"
+			if o != nil {
+				amountStr = util.Th(o.Amount())
+			}
+			e := edgeTo(inp.IDVeryShort())
+			if label := fmt.Sprintf("%s(#%d)", amountStr, outIndex); !slices.Contains(e.labels, label) {
+				e.labels = append(e.labels, label)
+			}
+			return true
+		})
+		v.ForEachEndorsement(func(i byte, vEnd *vertex.WrappedTx) bool {
+			if vEnd == nil {
+				edgeTo(dw.point("")).endorsement = true
+				return true
+			}
+			edgeTo(vEnd.IDVeryShort()).endorsement = true
+			return true
+		})
+	}})
+	for _, e := range edges {
+		dw.edge(id, e)
+	}
+}
+
+// WriteDOT writes graph of the DAG in DOT format directly to the writer, vertex by vertex, without building
+// the whole graph in memory. Nodes and edges are the same as in the graph made by MakeGraph
+func (d *MemDAG) WriteDOT(w io.Writer) error {
+	dw := newDotWriter(w)
+	_, _ = dw.w.WriteString("strict digraph {\n")
+	colors := newNodeColors(ColorBySequencer)
+	for _, vid := range d.Vertices() {
+		dw.node(vid.IDVeryShort(), graphNodeAttributes(vid, colors, false))
+		dw.edges(vid)
+	}
+	_, _ = dw.w.WriteString("}\n")
+	return dw.w.Flush()
+}
+
+// SaveDOT saves graph of the DAG to the file <fname>.gv with WriteDOT
+func (d *MemDAG) SaveDOT(fname string) error {
+	f, err := os.Create(fname + ".gv")
+	if err != nil {
+		return err
+	}
+	err = d.WriteDOT(f)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
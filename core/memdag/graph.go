@@ -1,13 +1,14 @@
 package memdag
 
 import (
+	"errors"
 	"fmt"
 	"math"
-	"os"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/dominikbraun/graph"
-	"github.com/dominikbraun/graph/draw"
 	"github.com/lunfardo314/proxima/core/txmetadata"
 	"github.com/lunfardo314/proxima/core/vertex"
 	"github.com/lunfardo314/proxima/global"
@@ -15,8 +16,13 @@ import (
 	"github.com/lunfardo314/proxima/ledger/transaction"
 	"github.com/lunfardo314/proxima/multistate"
 	"github.com/lunfardo314/proxima/util"
+	"github.com/lunfardo314/proxima/util/graphviz"
+	"github.com/lunfardo314/proxima/util/set"
 )
 
+// truncatedEdgeColor color of edges to dependencies cut off from the past cone graph
+const truncatedEdgeColor = "orange"
+
 var (
 	fontsizeAttribute    = graph.VertexAttribute("fontsize", "10")
 	simpleNodeAttributes = []func(*graph.VertexProperties){
@@ -62,10 +68,100 @@ func sequencerNodeAttributes(v *vertex.Vertex, coverage uint64, dict map[ledger.
 	return ret
 }
 
-func makeGraphNode(vid *vertex.WrappedTx, gr graph.Graph[string, string], seqDict map[ledger.ChainID]int, highlighted bool) {
+// NodeColoring mode of coloring sequencer vertices in the graph
+type NodeColoring byte
+
+const (
+	// ColorBySequencer each sequencer has its own color. Default
+	ColorBySequencer = NodeColoring(iota)
+	// ColorByCoverage sequencer vertices are shaded from low to high ledger coverage, the heaviest are the darkest
+	ColorByCoverage
+)
+
+// nodeColors coloring context of the graph
+type nodeColors struct {
+	mode        NodeColoring
+	seqDict     map[ledger.ChainID]int
+	maxCoverage uint64
+}
+
+const numCoverageColors = 9 // ylorrd9
+
+func newNodeColors(mode NodeColoring, vertices ...[]*vertex.WrappedTx) *nodeColors {
+	ret := &nodeColors{
+		mode:    mode,
+		seqDict: make(map[ledger.ChainID]int),
+	}
+	if mode == ColorByCoverage {
+		for _, vids := range vertices {
+			for _, vid := range vids {
+				if lcp := vid.GetLedgerCoverageP(); lcp != nil && *lcp > ret.maxCoverage {
+					ret.maxCoverage = *lcp
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// coverageNodeAttributes shade of the color is proportional to the coverage relative to the maximum coverage in the graph
+func (c *nodeColors) coverageNodeAttributes(coverage uint64) []func(*graph.VertexProperties) {
+	level := 1
+	if c.maxCoverage > 0 {
+		level = 1 + int((numCoverageColors-1)*coverage/c.maxCoverage)
+	}
+	return []func(*graph.VertexProperties){
+		fontsizeAttribute,
+		graph.VertexAttribute("colorscheme", "ylorrd9"),
+		graph.VertexAttribute("style", "filled"),
+		graph.VertexAttribute("color", "9"),
+		graph.VertexAttribute("fillcolor", strconv.Itoa(level)),
+		graph.VertexAttribute("xlabel", util.Th(coverage)),
+	}
+}
+
+// graphErrors collects errors while making the graph. Graph is still made, however it may be incomplete
+type graphErrors []error
+
+func (e *graphErrors) add(err error, format string, args ...any) {
+	if err != nil {
+		*e = append(*e, fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), err))
+	}
+}
+
+func (e *graphErrors) join() error {
+	return errors.Join(*e...)
+}
+
+// addInputEdge adds edge to the consumed transaction. Several outputs of the same transaction
+// may be consumed, so the label of the existing edge is extended. Same output added twice is an error
+func addInputEdge(gr graph.Graph[string, string], source, target, label string) error {
+	err := gr.AddEdge(source, target, graph.EdgeAttribute("label", label), graph.EdgeAttribute("fontsize", "10"))
+	if !errors.Is(err, graph.ErrEdgeAlreadyExists) {
+		return err
+	}
+	edge, errEdge := gr.Edge(source, target)
+	if errEdge != nil {
+		return errEdge
+	}
+	existing, ok := edge.Properties.Attributes["label"]
+	if !ok {
+		return err
+	}
+	if slices.Contains(strings.Split(existing, ", "), label) {
+		return err
+	}
+	return gr.UpdateEdge(source, target, graph.EdgeAttribute("label", existing+", "+label))
+}
+
+func makeGraphNode(vid *vertex.WrappedTx, gr graph.Graph[string, string], colors *nodeColors, highlighted bool, errs *graphErrors) {
 	id := vid.IDVeryShort()
+	errs.add(gr.AddVertex(id, graphNodeAttributes(vid, colors, highlighted)...), "vertex %s", id)
+}
+
+// graphNodeAttributes attributes of the graph node depend on type and status of the vertex
+func graphNodeAttributes(vid *vertex.WrappedTx, colors *nodeColors, highlighted bool) []func(*graph.VertexProperties) {
 	attr := simpleNodeAttributes
-	var err error
 
 	status := vid.GetTxStatus()
 	lcp := vid.GetLedgerCoverageP()
@@ -76,7 +172,11 @@ func makeGraphNode(vid *vertex.WrappedTx, gr graph.Graph[string, string], seqDic
 	vid.RUnwrap(vertex.UnwrapOptions{
 		Vertex: func(v *vertex.Vertex) {
 			if v.Tx.IsSequencerMilestone() {
-				attr = sequencerNodeAttributes(v, lc, seqDict)
+				if colors.mode == ColorByCoverage {
+					attr = colors.coverageNodeAttributes(lc)
+				} else {
+					attr = sequencerNodeAttributes(v, lc, colors.seqDict)
+				}
 			}
 			switch status {
 			case vertex.Bad:
@@ -91,39 +191,48 @@ func makeGraphNode(vid *vertex.WrappedTx, gr graph.Graph[string, string], seqDic
 			if highlighted {
 				attr = append(attr, graph.VertexAttribute("penwidth", "3"))
 			}
-			err = gr.AddVertex(id, attr...)
 		},
 		VirtualTx: func(v *vertex.VirtualTransaction) {
-			err = gr.AddVertex(id, finalTxAttributes...)
+			if colors.mode == ColorByCoverage && vid.IsSequencerMilestone() {
+				attr = colors.coverageNodeAttributes(lc)
+			} else {
+				attr = finalTxAttributes
+			}
 		},
 		Deleted: func() {
-			err = gr.AddVertex(id, orphanedTxAttributes...)
+			attr = orphanedTxAttributes
 		},
 	})
-	util.AssertNoError(err)
-	if vid.GetTxStatus() == vertex.Bad {
-		attr = append(attr, graph.VertexAttribute("color", "ret"))
-	}
+	return attr
 }
 
 var nilCount int
 
-func makeGraphEdges(vid *vertex.WrappedTx, gr graph.Graph[string, string]) {
+// addPointVertex adds point vertex, used for nil and out of scope dependencies. Returns id of the vertex
+func addPointVertex(gr graph.Graph[string, string], xlabel string, errs *graphErrors) string {
+	idNil := fmt.Sprintf("%d", nilCount)
+	nilCount++
+	attr := []func(*graph.VertexProperties){graph.VertexAttribute("shape", "point")}
+	if xlabel != "" {
+		attr = append(attr, graph.VertexAttribute("xlabel", xlabel), fontsizeAttribute)
+	}
+	errs.add(gr.AddVertex(idNil, attr...), "point vertex %s (%s)", idNil, xlabel)
+	return idNil
+}
+
+// makeGraphEdges makes edges to inputs and endorsements of the vertex. Dependencies for which optional
+// function 'outOfScope' returns true are rendered as points, same as nil dependencies
+func makeGraphEdges(vid *vertex.WrappedTx, gr graph.Graph[string, string], errs *graphErrors, outOfScope ...func(dep *vertex.WrappedTx) bool) {
+	isOutOfScope := func(dep *vertex.WrappedTx) bool {
+		return len(outOfScope) > 0 && outOfScope[0](dep)
+	}
 	id := vid.IDVeryShort()
 	vid.RUnwrap(vertex.UnwrapOptions{Vertex: func(v *vertex.Vertex) {
 		v.ForEachInputDependency(func(i byte, inp *vertex.WrappedTx) bool {
-			if inp == nil {
-				idNil := fmt.Sprintf("%d", nilCount)
+			if inp == nil || isOutOfScope(inp) {
 				oid := v.Tx.MustInputAt(i)
-				err := gr.AddVertex(idNil,
-					graph.VertexAttribute("shape", "point"),
-					graph.VertexAttribute("xlabel", oid.StringVeryShort()),
-					graph.VertexAttribute("fontsize", "10"),
-				)
-				util.AssertNoError(err)
-				nilCount++
-				err = gr.AddEdge(id, idNil)
-				util.AssertNoError(err)
+				idNil := addPointVertex(gr, oid.StringVeryShort(), errs)
+				errs.add(gr.AddEdge(id, idNil), "edge %s -> point %s", id, idNil)
 				return true
 			}
 			o := v.GetConsumedOutput(i)
@@ -132,137 +241,305 @@ func makeGraphEdges(vid *vertex.WrappedTx, gr graph.Graph[string, string]) {
 			if o != nil {
 				amountStr = util.Th(o.Amount())
 			}
-			edgeAttributes := []func(_ *graph.EdgeProperties){
-				graph.EdgeAttribute("label", fmt.Sprintf("%s(#%d)", amountStr, outIndex)),
-				graph.EdgeAttribute("fontsize", "10"),
-			}
-			_ = gr.AddEdge(id, inp.IDVeryShort(), edgeAttributes...)
-			return true
-		})
-		v.ForEachEndorsement(func(i byte, vEnd *vertex.WrappedTx) bool {
-			if vEnd == nil {
-				idNil := fmt.Sprintf("%d", nilCount)
-				err := gr.AddVertex(idNil, graph.VertexAttribute("shape", "point"))
-				util.AssertNoError(err)
-				nilCount++
-				err = gr.AddEdge(id, idNil)
-				util.AssertNoError(err)
-				return true
-			}
-			_ = gr.AddEdge(id, vEnd.IDVeryShort(), graph.EdgeAttribute("color", "red"))
-			//util.Assertf(err == nil || errors.Is(err, graph.ErrEdgeAlreadyExists), "%v", err)
+			err := addInputEdge(gr, id, inp.IDVeryShort(), fmt.Sprintf("%s(#%d)", amountStr, outIndex))
+			errs.add(err, "input edge %s -> %s", id, inp.IDVeryShort())
 			return true
 		})
+		makeEndorsementEdges(v, id, gr, errs, isOutOfScope)
 	}})
 }
 
-func (d *MemDAG) MakeGraph(additionalVertices ...*vertex.WrappedTx) graph.Graph[string, string] {
+func makeEndorsementEdges(v *vertex.Vertex, id string, gr graph.Graph[string, string], errs *graphErrors, outOfScope ...func(dep *vertex.WrappedTx) bool) {
+	v.ForEachEndorsement(func(i byte, vEnd *vertex.WrappedTx) bool {
+		if vEnd == nil {
+			idNil := addPointVertex(gr, "", errs)
+			errs.add(gr.AddEdge(id, idNil), "edge %s -> nil endorsement %s", id, idNil)
+			return true
+		}
+		if len(outOfScope) > 0 && outOfScope[0](vEnd) {
+			idNil := addPointVertex(gr, vEnd.IDVeryShort(), errs)
+			errs.add(gr.AddEdge(id, idNil, graph.EdgeAttribute("color", "red")), "edge %s -> point %s", id, idNil)
+			return true
+		}
+		err := gr.AddEdge(id, vEnd.IDVeryShort(), graph.EdgeAttribute("color", "red"))
+		if errors.Is(err, graph.ErrEdgeAlreadyExists) {
+			// endorsed transaction is also consumed
+			err = gr.UpdateEdge(id, vEnd.IDVeryShort(), graph.EdgeAttribute("color", "red"))
+		}
+		errs.add(err, "endorsement edge %s -> %s", id, vEnd.IDVeryShort())
+		return true
+	})
+}
+
+// MakeGraph makes graph of the DAG. Returned error means graph is incomplete
+func (d *MemDAG) MakeGraph(additionalVertices ...*vertex.WrappedTx) (graph.Graph[string, string], error) {
+	return d.MakeGraphWithColoring(ColorBySequencer, additionalVertices...)
+}
+
+// MakeGraphWithColoring makes graph of the DAG with sequencer vertices colored according to the mode.
+// Returned error means graph is incomplete
+func (d *MemDAG) MakeGraphWithColoring(coloring NodeColoring, additionalVertices ...*vertex.WrappedTx) (graph.Graph[string, string], error) {
 	ret := graph.New(graph.StringHash, graph.Directed(), graph.Acyclic())
 
 	vertices := d.Vertices()
-	seqDict := make(map[ledger.ChainID]int)
+	colors := newNodeColors(coloring, vertices, additionalVertices)
+	var errs graphErrors
 	for _, vid := range vertices {
-		makeGraphNode(vid, ret, seqDict, false)
+		makeGraphNode(vid, ret, colors, false, &errs)
 	}
 	for _, vid := range additionalVertices {
-		makeGraphNode(vid, ret, seqDict, true)
+		makeGraphNode(vid, ret, colors, true, &errs)
 	}
 	for _, vid := range vertices {
-		makeGraphEdges(vid, ret)
+		makeGraphEdges(vid, ret, &errs)
 	}
 	for _, vid := range additionalVertices {
-		makeGraphEdges(vid, ret)
+		makeGraphEdges(vid, ret, &errs)
 	}
-	return ret
+	return ret, errs.join()
 }
 
-func (d *MemDAG) SaveGraph(fname string) {
-	gr := d.MakeGraph()
-	dotFile, _ := os.Create(fname + ".gv")
-	err := draw.DOT(gr, dotFile)
-	util.AssertNoError(err)
-	_ = dotFile.Close()
+// MakeGraphSlotRange makes graph of vertices of the DAG with slots in the range [fromSlot, toSlot].
+// Dependencies outside the range are rendered as points labeled with short output or transaction ID.
+// Returned error means graph is incomplete
+func (d *MemDAG) MakeGraphSlotRange(fromSlot, toSlot ledger.Slot) (graph.Graph[string, string], error) {
+	ret := graph.New(graph.StringHash, graph.Directed(), graph.Acyclic())
+
+	inRange := func(txid *ledger.TransactionID) bool {
+		return fromSlot <= txid.Slot() && txid.Slot() <= toSlot
+	}
+	outOfRange := func(vid *vertex.WrappedTx) bool {
+		return !inRange(&vid.ID)
+	}
+	vertices := d.Vertices(inRange)
+	colors := newNodeColors(ColorBySequencer)
+	var errs graphErrors
+	for _, vid := range vertices {
+		makeGraphNode(vid, ret, colors, false, &errs)
+	}
+	for _, vid := range vertices {
+		makeGraphEdges(vid, ret, &errs, outOfRange)
+	}
+	return ret, errs.join()
 }
 
-func MakeGraphPastCone(vid *vertex.WrappedTx, maxVertices ...int) graph.Graph[string, string] {
+// colors of vertices in the diff graph
+const (
+	diffOnlyAColor = "lightskyblue"
+	diffOnlyBColor = "salmon"
+	diffBothColor  = "gray90"
+)
+
+// MakeGraphDiff makes graph of the union of two sets of vertices, for example taken from DAGs of two nodes.
+// Vertices are matched by transaction ID. Vertices present only in A, only in B and in both are colored differently.
+// Dependencies outside the union are rendered as points. Returned error means graph is incomplete
+func MakeGraphDiff(a, b set.Set[*vertex.WrappedTx]) (graph.Graph[string, string], error) {
 	ret := graph.New(graph.StringHash, graph.Directed(), graph.Acyclic())
 
+	byIDA := make(map[ledger.TransactionID]*vertex.WrappedTx)
+	byIDB := make(map[ledger.TransactionID]*vertex.WrappedTx)
+	a.ForEach(func(vid *vertex.WrappedTx) bool {
+		byIDA[vid.ID] = vid
+		return true
+	})
+	b.ForEach(func(vid *vertex.WrappedTx) bool {
+		byIDB[vid.ID] = vid
+		return true
+	})
+	outOfUnion := func(vid *vertex.WrappedTx) bool {
+		_, inA := byIDA[vid.ID]
+		_, inB := byIDB[vid.ID]
+		return !inA && !inB
+	}
+	union := make([]*vertex.WrappedTx, 0, len(byIDA)+len(byIDB))
+	for _, vid := range byIDA {
+		union = append(union, vid)
+	}
+	for txid, vid := range byIDB {
+		if _, inA := byIDA[txid]; !inA {
+			union = append(union, vid)
+		}
+	}
+	var errs graphErrors
+	for _, vid := range union {
+		_, inA := byIDA[vid.ID]
+		_, inB := byIDB[vid.ID]
+		fillColor := diffBothColor
+		switch {
+		case inA && !inB:
+			fillColor = diffOnlyAColor
+		case !inA && inB:
+			fillColor = diffOnlyBColor
+		}
+		attr := []func(*graph.VertexProperties){
+			fontsizeAttribute,
+			graph.VertexAttribute("style", "filled"),
+			graph.VertexAttribute("fillcolor", fillColor),
+		}
+		if vid.IsBranchTransaction() {
+			attr = append(attr, graph.VertexAttribute("shape", "box"))
+		} else if vid.IsSequencerMilestone() {
+			attr = append(attr, graph.VertexAttribute("penwidth", "2"))
+		}
+		errs.add(ret.AddVertex(vid.IDVeryShort(), attr...), "vertex %s", vid.IDVeryShort())
+	}
+	for _, vid := range union {
+		makeGraphEdges(vid, ret, &errs, outOfUnion)
+	}
+	return ret, errs.join()
+}
+
+// saveGraph saves graph in DOT format and optionally renders it in the format, see graphviz.SaveGraphAs.
+// Graph is saved even if it is incomplete, the error is returned
+func saveGraph(gr graph.Graph[string, string], graphErr error, fname string, format ...string) error {
+	f := graphviz.FormatDOT
+	if len(format) > 0 {
+		f = format[0]
+	}
+	if err := graphviz.SaveGraphAs(gr, fname, f); err != nil {
+		return err
+	}
+	if graphErr != nil {
+		return fmt.Errorf("graph %s is incomplete: %w", fname, graphErr)
+	}
+	return nil
+}
+
+// SaveGraph saves graph of the DAG, optionally in the format. Returned error means graph was not saved or it is incomplete
+func (d *MemDAG) SaveGraph(fname string, format ...string) error {
+	gr, err := d.MakeGraph()
+	return saveGraph(gr, err, fname, format...)
+}
+
+// MakeGraphPastCone makes graph of the past cone of the vertex. Returned error means graph is incomplete
+func MakeGraphPastCone(vid *vertex.WrappedTx, maxVertices ...int) (graph.Graph[string, string], error) {
 	max := math.MaxUint16
 	if len(maxVertices) > 0 && maxVertices[0] < math.MaxUint16 {
 		max = maxVertices[0]
 	}
+	return MakeGraphPastConeBounded(vid, max, 0)
+}
 
-	seqDict := make(map[ledger.ChainID]int)
-	count := 0
+// MakeGraphPastConeBounded makes graph of the past cone of the vertex. The past cone is traversed breadth-first
+// up to maxVertices vertices and up to maxDepth levels of dependencies (0 means unbounded). Deeper vertices are not visited.
+// Dependencies which were cut off are shown as points and edges to them are colored with truncatedEdgeColor.
+// Returned error means graph is incomplete
+func MakeGraphPastConeBounded(vid *vertex.WrappedTx, maxVertices, maxDepth int) (graph.Graph[string, string], error) {
+	ret := graph.New(graph.StringHash, graph.Directed(), graph.Acyclic())
 
-	mkNode := func(vidCur *vertex.WrappedTx) bool {
-		if count > max {
-			return false
+	included := set.New[*vertex.WrappedTx](vid)
+	ordered := []*vertex.WrappedTx{vid}
+	level := []*vertex.WrappedTx{vid}
+	for depth := 0; len(level) > 0 && (maxDepth <= 0 || depth < maxDepth); depth++ {
+		next := make([]*vertex.WrappedTx, 0)
+		for _, vidCur := range level {
+			forEachDependency(vidCur, func(dep *vertex.WrappedTx) bool {
+				if included.Contains(dep) {
+					return true
+				}
+				if len(ordered) >= maxVertices {
+					return false
+				}
+				included.Insert(dep)
+				ordered = append(ordered, dep)
+				next = append(next, dep)
+				return true
+			})
 		}
-		count++
-		makeGraphNode(vidCur, ret, seqDict, false)
-		return true
+		level = next
 	}
-	vid.TraversePastConeDepthFirst(vertex.UnwrapOptionsForTraverse{
-		Vertex: func(vidCur *vertex.WrappedTx, _ *vertex.Vertex) bool {
-			return mkNode(vidCur)
-		},
-		VirtualTx: func(vidCur *vertex.WrappedTx, vCur *vertex.VirtualTransaction) bool {
-			return mkNode(vidCur)
-		},
-		Deleted: func(vidCur *vertex.WrappedTx) bool {
-			return mkNode(vidCur)
-		},
-	})
-	count = 0
-	vid.TraversePastConeDepthFirst(vertex.UnwrapOptionsForTraverse{
-		Vertex: func(vidCur *vertex.WrappedTx, _ *vertex.Vertex) bool {
-			makeGraphEdges(vidCur, ret)
+
+	colors := newNodeColors(ColorBySequencer)
+	var errs graphErrors
+	for _, vidCur := range ordered {
+		makeGraphNode(vidCur, ret, colors, false, &errs)
+	}
+	// dependencies cut off by the bounds
+	truncated := make([][2]string, 0)
+	for _, vidCur := range ordered {
+		forEachDependency(vidCur, func(dep *vertex.WrappedTx) bool {
+			if included.Contains(dep) {
+				return true
+			}
+			err := ret.AddVertex(dep.IDVeryShort(),
+				graph.VertexAttribute("shape", "point"),
+				graph.VertexAttribute("xlabel", dep.IDVeryShort()),
+				graph.VertexAttribute("color", truncatedEdgeColor),
+				fontsizeAttribute,
+			)
+			if !errors.Is(err, graph.ErrVertexAlreadyExists) {
+				errs.add(err, "truncated vertex %s", dep.IDVeryShort())
+			}
+			truncated = append(truncated, [2]string{vidCur.IDVeryShort(), dep.IDVeryShort()})
 			return true
-		},
-	})
-	return ret
+		})
+	}
+	for _, vidCur := range ordered {
+		makeGraphEdges(vidCur, ret, &errs)
+	}
+	for _, e := range truncated {
+		err := ret.UpdateEdge(e[0], e[1], graph.EdgeAttribute("color", truncatedEdgeColor))
+		errs.add(err, "truncated edge %s -> %s", e[0], e[1])
+	}
+	return ret, errs.join()
 }
 
-func SaveGraphPastCone(vid *vertex.WrappedTx, fname string) {
-	gr := MakeGraphPastCone(vid, 500)
-	dotFile, _ := os.Create(fname + ".gv")
-	err := draw.DOT(gr, dotFile)
-	util.AssertNoError(err)
-	_ = dotFile.Close()
+// forEachDependency iterates inputs and endorsements of the vertex, which are solidified
+func forEachDependency(vid *vertex.WrappedTx, fun func(dep *vertex.WrappedTx) bool) {
+	vid.RUnwrap(vertex.UnwrapOptions{Vertex: func(v *vertex.Vertex) {
+		exit := false
+		v.ForEachInputDependency(func(_ byte, dep *vertex.WrappedTx) bool {
+			if dep != nil {
+				exit = !fun(dep)
+			}
+			return !exit
+		})
+		if exit {
+			return
+		}
+		v.ForEachEndorsement(func(_ byte, dep *vertex.WrappedTx) bool {
+			return dep == nil || fun(dep)
+		})
+	}})
+}
+
+func SaveGraphPastCone(vid *vertex.WrappedTx, fname string, format ...string) error {
+	gr, err := MakeGraphPastCone(vid, 500)
+	return saveGraph(gr, err, fname, format...)
 }
 
+// SaveTree saves tree of branches of the state store. Forwarding wrapper kept for compatibility,
+// branch tree rendering does not depend on the DAG, see multistate.SaveBranchTree
 func (d *MemDAG) SaveTree(fname string) {
 	multistate.SaveBranchTree(d.StateStore(), fname)
 }
 
-func (d *MemDAG) SaveSequencerGraph(fname string) {
-	gr := d.MakeSequencerGraph()
-	dotFile, _ := os.Create(fname + ".gv")
-	err := draw.DOT(gr, dotFile)
-	util.AssertNoError(err)
-	_ = dotFile.Close()
+func (d *MemDAG) SaveSequencerGraph(fname string, format ...string) error {
+	gr, err := d.MakeSequencerGraph()
+	return saveGraph(gr, err, fname, format...)
 }
 
-func (d *MemDAG) MakeSequencerGraph() graph.Graph[string, string] {
+// MakeSequencerGraph makes graph of sequencer transactions in the DAG. Returned error means graph is incomplete
+func (d *MemDAG) MakeSequencerGraph() (graph.Graph[string, string], error) {
 	ret := graph.New(graph.StringHash, graph.Directed(), graph.Acyclic())
 
-	seqDict := make(map[ledger.ChainID]int)
+	colors := newNodeColors(ColorBySequencer)
+	var errs graphErrors
 	seqVertices := make([]*vertex.WrappedTx, 0)
 	for _, vid := range d.Vertices() {
 		if !vid.IsSequencerMilestone() {
 			continue
 		}
-		makeGraphNode(vid, ret, seqDict, false)
+		makeGraphNode(vid, ret, colors, false, &errs)
 		seqVertices = append(seqVertices, vid)
 	}
 	for _, vid := range seqVertices {
-		makeSequencerGraphEdges(vid, ret)
+		makeSequencerGraphEdges(vid, ret, &errs)
 	}
-	return ret
+	return ret, errs.join()
 }
 
-func makeSequencerGraphEdges(vid *vertex.WrappedTx, gr graph.Graph[string, string]) {
+func makeSequencerGraphEdges(vid *vertex.WrappedTx, gr graph.Graph[string, string], errs *graphErrors) {
 	id := vid.IDVeryShort()
 
 	vid.RUnwrap(vertex.UnwrapOptions{Vertex: func(v *vertex.Vertex) {
@@ -283,28 +560,12 @@ func makeSequencerGraphEdges(vid *vertex.WrappedTx, gr graph.Graph[string, strin
 				if o != nil {
 					amountStr = util.Th(o.Amount())
 				}
-				edgeAttributes := []func(_ *graph.EdgeProperties){
-					graph.EdgeAttribute("label", fmt.Sprintf("%s(#%d)", amountStr, outIndex)),
-					graph.EdgeAttribute("fontsize", "10"),
-				}
-				_ = gr.AddEdge(id, inp.IDVeryShort(), edgeAttributes...)
-			}
-			return true
-		})
-		v.ForEachEndorsement(func(i byte, vEnd *vertex.WrappedTx) bool {
-			if vEnd == nil {
-				idNil := fmt.Sprintf("%d", nilCount)
-				err := gr.AddVertex(idNil, graph.VertexAttribute("shape", "point"))
-				util.AssertNoError(err)
-				nilCount++
-				err = gr.AddEdge(id, idNil)
-				util.AssertNoError(err)
-				return true
+				err := addInputEdge(gr, id, inp.IDVeryShort(), fmt.Sprintf("%s(#%d)", amountStr, outIndex))
+				errs.add(err, "input edge %s -> %s", id, inp.IDVeryShort())
 			}
-			_ = gr.AddEdge(id, vEnd.IDVeryShort(), graph.EdgeAttribute("color", "red"))
-			//util.Assertf(err == nil || errors.Is(err, graph.ErrEdgeAlreadyExists), "%v", err)
 			return true
 		})
+		makeEndorsementEdges(v, id, gr, errs)
 	}})
 }
 
@@ -350,7 +611,7 @@ func (d *MemDAG) loadPastConeFromTxStore(txid ledger.TransactionID, txStore glob
 	return vid
 }
 
-func SavePastConeFromTxStore(tip ledger.TransactionID, txStore global.TxBytesGet, oldestSlot ledger.Slot, fname string) {
+func SavePastConeFromTxStore(tip ledger.TransactionID, txStore global.TxBytesGet, oldestSlot ledger.Slot, fname string) error {
 	tmpDag := MakeDAGFromTxStore(txStore, oldestSlot, tip)
-	tmpDag.SaveGraph(fname)
+	return tmpDag.SaveGraph(fname)
 }
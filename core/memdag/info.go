@@ -32,7 +32,7 @@ func (d *MemDAG) InfoLines(verbose ...bool) *lines.Lines {
 			return ledger.LessTxID(vertices[i].ID, vertices[j].ID)
 		})
 		for _, vid := range vertices {
-			ln.Add("    %s, referenced by: %d", vid.ShortString(), vid.NumReferences())
+			ln.Add("    %s, referenced by: %d %v", vid.ShortString(), vid.NumReferences(), vid.ReferenceHolders())
 		}
 
 		ln.Add("---- cached state readers (verbose)")
@@ -62,6 +62,23 @@ func (d *MemDAG) VerticesInSlotAndAfter(slot ledger.Slot) []*vertex.WrappedTx {
 	return ret
 }
 
+// WantedTransactions returns sorted IDs of virtual transactions which are being pulled,
+// i.e. the missing transactions which block solidification
+func (d *MemDAG) WantedTransactions() []ledger.TransactionID {
+	ret := make([]ledger.TransactionID, 0)
+	for _, vid := range d.Vertices() {
+		vid.RUnwrap(vertex.UnwrapOptions{VirtualTx: func(v *vertex.VirtualTransaction) {
+			if v.PullInProgress() {
+				ret = append(ret, vid.ID)
+			}
+		}})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ledger.LessTxID(ret[i], ret[j])
+	})
+	return ret
+}
+
 func (d *MemDAG) LinesVerticesInSlotAndAfter(slot ledger.Slot) *lines.Lines {
 	return vertex.VerticesLines(d.VerticesInSlotAndAfter(slot))
 }
@@ -0,0 +1,129 @@
+package vertex
+
+import (
+	"encoding/json"
+
+	"github.com/lunfardo314/proxima/util/set"
+)
+
+type (
+	DependencyTreeJSONAble struct {
+		Root     string                     `json:"root"`
+		MaxDepth int                        `json:"max_depth"`
+		Vertices []DependencyTreeVertexJSON `json:"vertices"`
+	}
+
+	DependencyTreeVertexJSON struct {
+		ID        string `json:"id"`
+		Depth     int    `json:"depth"`
+		VirtualTx bool   `json:"virtual_tx,omitempty"`
+		Deleted   bool   `json:"deleted,omitempty"`
+		Status    string `json:"status"`
+		Coverage  uint64 `json:"coverage,omitempty"`
+		Err       string `json:"err,omitempty"`
+		// true if dependencies of the vertex are not included because of the depth limit
+		Truncated    bool                `json:"truncated,omitempty"`
+		Inputs       []DependencyRefJSON `json:"inputs,omitempty"`
+		Endorsements []DependencyRefJSON `json:"endorsements,omitempty"`
+	}
+
+	// DependencyRefJSON is input or endorsement of the vertex. ID is empty if dependency is not solidified yet
+	DependencyRefJSON struct {
+		Index    byte   `json:"index"`
+		ID       string `json:"id,omitempty"`
+		Resolved bool   `json:"resolved"`
+	}
+)
+
+// DependencyTreeJSON returns JSON dump of the vertex and its past cone up to maxDepth steps along inputs
+// and endorsements. Each vertex is listed once, with the shortest distance from the root.
+// Vertices are listed in the breadth-first order, so the output is deterministic for the same past cone
+func (vid *WrappedTx) DependencyTreeJSON(maxDepth int) ([]byte, error) {
+	return json.MarshalIndent(vid.DependencyTree(maxDepth), "", "  ")
+}
+
+// DependencyTree collects the dependency tree of the vertex. See DependencyTreeJSON.
+// It does not use TraversePastConeDepthFirst: the depth-first traverse visits the whole past cone with nested
+// read locks and does not stop at the depth limit, and the depth at which it first reaches a vertex is not the
+// shortest one. Breadth-first traverse by levels visits only vertices within maxDepth, each at its shortest depth,
+// and locks one vertex at a time
+func (vid *WrappedTx) DependencyTree(maxDepth int) *DependencyTreeJSONAble {
+	if maxDepth < 0 {
+		maxDepth = 0
+	}
+	ret := &DependencyTreeJSONAble{
+		Root:     vid.ID.String(),
+		MaxDepth: maxDepth,
+		Vertices: make([]DependencyTreeVertexJSON, 0),
+	}
+	visited := set.New[*WrappedTx](vid)
+	level := []*WrappedTx{vid}
+
+	for depth := 0; len(level) > 0; depth++ {
+		nextLevel := make([]*WrappedTx, 0)
+		for _, v := range level {
+			node, deps := v.dependencyTreeNode(depth)
+			if depth >= maxDepth {
+				node.Truncated = len(deps) > 0
+			} else {
+				for _, dep := range deps {
+					if visited.InsertNew(dep) {
+						nextLevel = append(nextLevel, dep)
+					}
+				}
+			}
+			ret.Vertices = append(ret.Vertices, node)
+		}
+		level = nextLevel
+	}
+	return ret
+}
+
+// dependencyTreeNode returns node of the dependency tree and solid dependencies of the vertex
+func (vid *WrappedTx) dependencyTreeNode(depth int) (ret DependencyTreeVertexJSON, deps []*WrappedTx) {
+	ret = DependencyTreeVertexJSON{
+		ID:    vid.ID.String(),
+		Depth: depth,
+	}
+	deps = make([]*WrappedTx, 0)
+	refs := func(i byte, dep *WrappedTx) DependencyRefJSON {
+		if dep == nil {
+			return DependencyRefJSON{Index: i}
+		}
+		deps = append(deps, dep)
+		return DependencyRefJSON{Index: i, ID: dep.ID.String(), Resolved: true}
+	}
+	vid.RUnwrap(UnwrapOptions{
+		Vertex: func(v *Vertex) {
+			ret.Status, ret.Coverage, ret.Err = vid._statusCoverageErr()
+			v.ForEachInputDependency(func(i byte, vidInput *WrappedTx) bool {
+				ret.Inputs = append(ret.Inputs, refs(i, vidInput))
+				return true
+			})
+			v.ForEachEndorsement(func(i byte, vidEndorsed *WrappedTx) bool {
+				ret.Endorsements = append(ret.Endorsements, refs(i, vidEndorsed))
+				return true
+			})
+		},
+		VirtualTx: func(_ *VirtualTransaction) {
+			ret.VirtualTx = true
+			ret.Status, ret.Coverage, ret.Err = vid._statusCoverageErr()
+		},
+		Deleted: func() {
+			ret.Deleted = true
+			ret.Status = vid.GetTxStatusNoLock().String()
+		},
+	})
+	return
+}
+
+func (vid *WrappedTx) _statusCoverageErr() (status string, coverage uint64, errStr string) {
+	status = vid.GetTxStatusNoLock().String()
+	if cov := vid.GetLedgerCoverageNoLock(); cov != nil {
+		coverage = *cov
+	}
+	if vid.err != nil {
+		errStr = vid.err.Error()
+	}
+	return
+}
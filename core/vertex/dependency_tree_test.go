@@ -0,0 +1,82 @@
+package vertex
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/ledger/transaction"
+	"github.com/lunfardo314/proxima/ledger/txbuilder"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyTreeJSON(t *testing.T) {
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	addr := ledger.AddressED25519FromPrivateKey(privKey)
+
+	makeTx := func(in *ledger.OutputWithID) *transaction.Transaction {
+		td := txbuilder.NewTransferData(privKey, addr, in.Timestamp().AddTicks(ledger.TransactionPace())).
+			WithAmount(in.Output.Amount()).
+			WithTargetLock(addr).
+			MustWithInputs(in)
+		txBytes, err := txbuilder.MakeSimpleTransferTransaction(td)
+		require.NoError(t, err)
+		tx, err := transaction.FromBytes(txBytes)
+		require.NoError(t, err)
+		return tx
+	}
+
+	// virtualTx <- tx1 <- tx2
+	inTxID := ledger.RandomTransactionID(false)
+	vidIn := WrapTxID(inTxID)
+	tx1 := makeTx(&ledger.OutputWithID{
+		ID: ledger.NewOutputID(&inTxID, 0),
+		Output: ledger.NewOutput(func(o *ledger.Output) {
+			o.WithAmount(1_000_000).WithLock(addr)
+		}),
+	})
+	v1 := New(tx1)
+	require.True(t, v1.ReferenceInput(0, vidIn))
+	vid1 := v1.Wrap()
+
+	tx2 := makeTx(&ledger.OutputWithID{
+		ID:     ledger.NewOutputID(tx1.ID(), 0),
+		Output: tx1.MustProducedOutputAt(0),
+	})
+	v2 := New(tx2)
+	require.True(t, v2.ReferenceInput(0, vid1))
+	vid2 := v2.Wrap()
+
+	t.Run("depth limit", func(t *testing.T) {
+		tree := vid2.DependencyTree(1)
+		require.EqualValues(t, 2, len(tree.Vertices))
+		require.EqualValues(t, vid2.ID.String(), tree.Vertices[0].ID)
+		require.False(t, tree.Vertices[0].Truncated)
+		require.EqualValues(t, []DependencyRefJSON{{Index: 0, ID: vid1.ID.String(), Resolved: true}}, tree.Vertices[0].Inputs)
+		require.EqualValues(t, vid1.ID.String(), tree.Vertices[1].ID)
+		require.EqualValues(t, 1, tree.Vertices[1].Depth)
+		require.True(t, tree.Vertices[1].Truncated)
+	})
+	t.Run("full", func(t *testing.T) {
+		data, err := vid2.DependencyTreeJSON(10)
+		require.NoError(t, err)
+		var tree DependencyTreeJSONAble
+		require.NoError(t, json.Unmarshal(data, &tree))
+		require.EqualValues(t, 3, len(tree.Vertices))
+		require.EqualValues(t, vidIn.ID.String(), tree.Vertices[2].ID)
+		require.True(t, tree.Vertices[2].VirtualTx)
+		require.EqualValues(t, Undefined.String(), tree.Vertices[2].Status)
+		for _, v := range tree.Vertices {
+			require.False(t, v.Truncated)
+		}
+	})
+	t.Run("unresolved", func(t *testing.T) {
+		vid := New(tx2).Wrap()
+		tree := vid.DependencyTree(10)
+		require.EqualValues(t, 1, len(tree.Vertices))
+		require.EqualValues(t, []DependencyRefJSON{{Index: 0}}, tree.Vertices[0].Inputs)
+	})
+}
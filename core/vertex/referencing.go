@@ -1,6 +1,9 @@
 package vertex
 
 import (
+	"fmt"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/lunfardo314/proxima/ledger"
@@ -23,9 +26,21 @@ import (
 
 const vertexTTLSlots = 5
 
+// __trackReferenceHolders is global var which enables counting of references by named holders
+var __trackReferenceHolders atomic.Bool
+
+// TrackReferenceHolders enables or disables counting of references by named holders, see ReferenceHolders.
+// Disabled by default, because it allocates a map in each referenced vertex. It is meant for debugging
+// of the memDAG pruning and should be set before vertices are created
+func TrackReferenceHolders(enable bool) {
+	__trackReferenceHolders.Store(enable)
+}
+
 // Reference increments reference counter for the vertex which is not deleted yet (counter > 0).
 // It also sets TTL for vertex if it has noo references (counter == 1)
-func (vid *WrappedTx) Reference() bool {
+// Optional holder is the name of the part of the system which holds the reference. It is only used in ReferenceHolders
+// and only if tracking is enabled by TrackReferenceHolders. The holder must un-reference the vertex with the same name
+func (vid *WrappedTx) Reference(holder ...string) bool {
 	vid.mutex.Lock()
 	defer vid.mutex.Unlock()
 
@@ -38,15 +53,29 @@ func (vid *WrappedTx) Reference() bool {
 		vid.dontPruneUntil = time.Now().Add(vertexTTLSlots * ledger.L().ID.SlotDuration())
 	}
 	vid.numReferences++
+	if len(holder) > 0 && __trackReferenceHolders.Load() {
+		if vid.referenceHolders == nil {
+			vid.referenceHolders = make(map[string]uint32)
+		}
+		vid.referenceHolders[holder[0]]++
+	}
 	return true
 }
 
 // UnReference decrements reference counter down to 1. It panics if counter value 1 is decremented because
 // the value 0 is reserved for the deleted vertices (handled by DoPruningIfRelevant)
-func (vid *WrappedTx) UnReference() {
+func (vid *WrappedTx) UnReference(holder ...string) {
 	vid.mutex.Lock()
 	defer vid.mutex.Unlock()
 
+	if len(holder) > 0 && vid.referenceHolders != nil {
+		if n := vid.referenceHolders[holder[0]]; n > 1 {
+			vid.referenceHolders[holder[0]] = n - 1
+		} else {
+			delete(vid.referenceHolders, holder[0])
+		}
+	}
+
 	// must be references >= 1. Only pruner can put it to 0
 	vid.numReferences--
 	util.Assertf(vid.numReferences >= 1, "UnReference: reference counter can't go below 1: %s", vid.ID.StringShort)
@@ -119,8 +148,44 @@ func (vid *WrappedTx) DoPruningIfRelevant(nowis time.Time) (markedForDeletion, u
 	return
 }
 
-func (vid *WrappedTx) MustReference() {
-	util.Assertf(vid.Reference(), "MustReference: failed with %s", vid.IDShortString)
+// PruningCandidate returns what DoPruningIfRelevant would do with the vertex at the moment, without changing it.
+// Must be kept consistent with DoPruningIfRelevant
+func (vid *WrappedTx) PruningCandidate(nowis time.Time) (markedForDeletion, unreferencedPastCone bool, references uint32) {
+	vid.RUnwrap(UnwrapOptions{
+		Vertex: func(v *Vertex) {
+			references = vid.numReferences
+			attached := vid.FlagsUpNoLock(FlagVertexTxAttachmentStarted | FlagVertexTxAttachmentFinished)
+			switch references {
+			case 0:
+				markedForDeletion = true
+			case 1:
+				if attached && nowis.After(vid.dontPruneUntil) {
+					unreferencedPastCone = true
+					markedForDeletion = true
+				}
+			default:
+				if attached && (nowis.After(vid.dontPruneUntil) || vid.GetTxStatusNoLock() == Bad) {
+					unreferencedPastCone = true
+				}
+			}
+		},
+		VirtualTx: func(v *VirtualTransaction) {
+			references = vid.numReferences
+			switch references {
+			case 0:
+				markedForDeletion = true
+			case 1:
+				if nowis.After(vid.dontPruneUntil) || vid.GetTxStatusNoLock() == Bad {
+					markedForDeletion = true
+				}
+			}
+		},
+	})
+	return
+}
+
+func (vid *WrappedTx) MustReference(holder ...string) {
+	util.Assertf(vid.Reference(holder...), "MustReference: failed with %s", vid.IDShortString)
 }
 
 func (vid *WrappedTx) NumReferences() int {
@@ -129,3 +194,29 @@ func (vid *WrappedTx) NumReferences() int {
 
 	return int(vid.numReferences)
 }
+
+// ReferenceHolders explains the reference counter. It returns the reference by the memDAG itself,
+// references by named holders and the number of references by unnamed holders (usually other vertices),
+// for example [memDAG, attacher(2), tippool(1), unnamed(3)]. Returns nil for deleted vertex.
+// All references are unnamed if tracking of holders is disabled
+func (vid *WrappedTx) ReferenceHolders() []string {
+	vid.mutex.RLock()
+	defer vid.mutex.RUnlock()
+
+	if vid.numReferences == 0 {
+		return nil
+	}
+	ret := []string{"memDAG"}
+	named := make([]string, 0, len(vid.referenceHolders))
+	var numNamed uint32
+	for holder, n := range vid.referenceHolders {
+		named = append(named, fmt.Sprintf("%s(%d)", holder, n))
+		numNamed += n
+	}
+	sort.Strings(named)
+	ret = append(ret, named...)
+	if vid.numReferences > numNamed+1 {
+		ret = append(ret, fmt.Sprintf("unnamed(%d)", vid.numReferences-numNamed-1))
+	}
+	return ret
+}
@@ -0,0 +1,72 @@
+package vertex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	ledger.InitWithTestingLedgerIDData()
+}
+
+func TestPruningCandidate(t *testing.T) {
+	vid := WrapTxID(ledger.RandomTransactionID(false))
+
+	// too early to prune
+	marked, unrefPastCone, refs := vid.PruningCandidate(time.Now())
+	require.False(t, marked)
+	require.False(t, unrefPastCone)
+	require.EqualValues(t, 1, refs)
+
+	// report does not change the vertex and agrees with the real pruning
+	future := time.Now().Add(2 * vertexTTLSlots * ledger.SlotDuration())
+	marked, unrefPastCone, refs = vid.PruningCandidate(future)
+	require.True(t, marked)
+	require.False(t, unrefPastCone)
+	require.EqualValues(t, 1, refs)
+	require.EqualValues(t, 1, vid.NumReferences())
+
+	marked1, unrefPastCone1, refs1 := vid.DoPruningIfRelevant(future)
+	require.EqualValues(t, marked, marked1)
+	require.EqualValues(t, unrefPastCone, unrefPastCone1)
+	require.EqualValues(t, refs, refs1)
+
+	marked, _, refs = vid.PruningCandidate(future)
+	require.True(t, marked)
+	require.EqualValues(t, 0, refs)
+}
+
+func TestReferenceHolders(t *testing.T) {
+	vid := WrapTxID(ledger.RandomTransactionID(false))
+	// holders are not tracked by default
+	require.True(t, vid.Reference("tippool"))
+	require.EqualValues(t, []string{"memDAG", "unnamed(1)"}, vid.ReferenceHolders())
+	vid.UnReference("tippool")
+	require.True(t, vid.referenceHolders == nil)
+
+	TrackReferenceHolders(true)
+	defer TrackReferenceHolders(false)
+
+	require.EqualValues(t, []string{"memDAG"}, vid.ReferenceHolders())
+
+	require.True(t, vid.Reference("tippool"))
+	require.True(t, vid.Reference("attacher"))
+	require.True(t, vid.Reference("attacher"))
+	require.True(t, vid.Reference())
+	require.EqualValues(t, 5, vid.NumReferences())
+	require.EqualValues(t, []string{"memDAG", "attacher(2)", "tippool(1)", "unnamed(1)"}, vid.ReferenceHolders())
+
+	vid.UnReference("attacher")
+	vid.UnReference("tippool")
+	vid.UnReference()
+	require.EqualValues(t, 2, vid.NumReferences())
+	require.EqualValues(t, []string{"memDAG", "attacher(1)"}, vid.ReferenceHolders())
+
+	vid.UnReference("attacher")
+	vid.DoPruningIfRelevant(time.Now().Add(2 * vertexTTLSlots * ledger.SlotDuration()))
+	require.EqualValues(t, 0, vid.NumReferences())
+	require.Nil(t, vid.ReferenceHolders())
+}
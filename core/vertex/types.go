@@ -2,6 +2,7 @@ package vertex
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -32,7 +33,10 @@ type (
 		pullRulesDefined bool
 		needsPull        bool
 		nextPull         time.Time
+		pullStarted      time.Time
 		timesPulled      int
+		pullAttempts     int
+		pullsFromPeer    int
 	}
 
 	// WrappedTx value of *WrappedTx is used as transaction identity on the UTXO tangle, a vertex
@@ -50,6 +54,9 @@ type (
 
 		// keeping track of references for orphaning/GC
 		numReferences uint32
+		// number of references by named holders. Nil if vertex was never referenced by the named holder
+		// or tracking of holders is disabled
+		referenceHolders map[string]uint32
 		// dontPruneUntil interpreted depending on value of references
 		// - if references > 1, dontPruneUntil is the deadline until when the past cone should not be un-referenced
 		// - if references == 1, dontPruneUntil is clock time, until which it should not be deleted
@@ -59,6 +66,10 @@ type (
 
 		// notification callback. Must be func(vid *WrappedTx)
 		onPoke atomic.Value
+		// status transition callback. Must be func(old, new Status)
+		onStatusChange atomic.Value
+		// callbacks waiting for the status to become Good or Bad. Nil when none is waiting
+		onStatusDefined []func(status Status)
 
 		_genericVertex
 
@@ -170,13 +181,33 @@ func (f *Flags) SetFlagsUp(fl Flags) {
 	*f = *f | fl
 }
 
-func (f *Flags) String() string {
-	return fmt.Sprintf("defined=%v, validated=%v, attachStarted=%v, attachFinished=%v",
-		f.FlagsUp(FlagVertexDefined),
-		f.FlagsUp(FlagVertexConstraintsValid),
-		f.FlagsUp(FlagVertexTxAttachmentStarted),
-		f.FlagsUp(FlagVertexTxAttachmentFinished),
-	)
+var flagNames = []struct {
+	flag Flags
+	name string
+}{
+	{FlagVertexDefined, "DEFINED"},
+	{FlagVertexConstraintsValid, "CONSTRAINTS_VALID"},
+	{FlagVertexTxAttachmentStarted, "ATTACH_STARTED"},
+	{FlagVertexTxAttachmentFinished, "ATTACH_FINISHED"},
+}
+
+// String renders flags which are up, for example 'DEFINED|CONSTRAINTS_VALID'. Unknown bits are rendered in hex
+func (f Flags) String() string {
+	names := make([]string, 0, len(flagNames))
+	rest := f
+	for _, fn := range flagNames {
+		if f&fn.flag == fn.flag {
+			names = append(names, fn.name)
+			rest &^= fn.flag
+		}
+	}
+	if rest != 0 {
+		names = append(names, fmt.Sprintf("0x%02x", byte(rest)))
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, "|")
 }
 
 func (s *TxIDStatus) Lines(prefix ...string) *lines.Lines {
@@ -37,6 +37,7 @@ func _newVID(g _genericVertex, txid ledger.TransactionID, seqID *ledger.ChainID)
 	}
 	ret.SequencerID.Store(seqID)
 	ret.onPoke.Store(func() {})
+	ret.onStatusChange.Store(func(_, _ Status) {})
 	return ret
 }
 
@@ -100,12 +101,21 @@ func (vid *WrappedTx) GetTxStatusNoLock() Status {
 }
 
 func (vid *WrappedTx) SetTxStatusGood() {
+	notify := vid.setTxStatusGood()
+	notify()
+}
+
+// setTxStatusGood returns status change notification, to be called after the lock is released
+func (vid *WrappedTx) setTxStatusGood() func() {
 	vid.mutex.Lock()
 	defer vid.mutex.Unlock()
 
 	util.Assertf(vid.GetTxStatusNoLock() != Bad, "vid.GetTxStatusNoLock() != Bad (%s)", vid.StringNoLock)
 
+	oldStatus := vid.GetTxStatusNoLock()
 	vid.flags.SetFlagsUp(FlagVertexDefined)
+	onDefined := vid.takeOnStatusDefinedNoLock()
+	return func() { vid.statusChanged(oldStatus, Good, onDefined) }
 }
 
 func (vid *WrappedTx) SetSequencerAttachmentFinished() {
@@ -120,13 +130,23 @@ func (vid *WrappedTx) SetSequencerAttachmentFinished() {
 }
 
 func (vid *WrappedTx) SetTxStatusBad(reason error) {
+	notify := vid.setTxStatusBad(reason)
+	notify()
+}
+
+// setTxStatusBad returns status change notification, to be called after the lock is released
+func (vid *WrappedTx) setTxStatusBad(reason error) func() {
 	vid.mutex.Lock()
 	defer vid.mutex.Unlock()
 
+	oldStatus := vid.GetTxStatusNoLock()
 	vid.SetTxStatusBadNoLock(reason)
 	vid.SetFlagsUpNoLock(FlagVertexTxAttachmentFinished)
+	onDefined := vid.takeOnStatusDefinedNoLock()
+	return func() { vid.statusChanged(oldStatus, Bad, onDefined) }
 }
 
+// SetTxStatusBadNoLock does not invoke status change callback, because it is called under the lock
 func (vid *WrappedTx) SetTxStatusBadNoLock(reason error) {
 	util.Assertf(reason != nil, "SetTxStatusBadNoLock: reason must be not nil")
 	util.Assertf(vid.GetTxStatusNoLock() != Good || errors.Is(reason, global.ErrInterrupted),
@@ -166,6 +186,48 @@ func (vid *WrappedTx) Poke() {
 	vid.onPoke.Load().(func())()
 }
 
+// OnStatusChange sets callback which is called when status of the transaction changes, i.e. Undefined -> Good or
+// Undefined -> Bad. The callback is called outside the vertex lock, so it can access the vertex. nil removes the callback
+func (vid *WrappedTx) OnStatusChange(fun func(old, new Status)) {
+	if fun == nil {
+		vid.onStatusChange.Store(func(_, _ Status) {})
+	} else {
+		vid.onStatusChange.Store(fun)
+	}
+}
+
+// OnStatusDefined adds callback which is called once, when status of the transaction becomes Good or Bad.
+// If the status is already Good or Bad, the callback is called immediately.
+// Unlike OnStatusChange, any number of callbacks can be added. Callbacks are called outside the vertex lock
+func (vid *WrappedTx) OnStatusDefined(fun func(status Status)) {
+	vid.mutex.Lock()
+	status := vid.GetTxStatusNoLock()
+	if status == Undefined {
+		vid.onStatusDefined = append(vid.onStatusDefined, fun)
+	}
+	vid.mutex.Unlock()
+
+	if status != Undefined {
+		fun(status)
+	}
+}
+
+func (vid *WrappedTx) takeOnStatusDefinedNoLock() []func(status Status) {
+	ret := vid.onStatusDefined
+	vid.onStatusDefined = nil
+	return ret
+}
+
+func (vid *WrappedTx) statusChanged(old, new Status, onDefined []func(status Status)) {
+	if old == new {
+		return
+	}
+	vid.onStatusChange.Load().(func(_, _ Status))(old, new)
+	for _, fun := range onDefined {
+		fun(new)
+	}
+}
+
 // WrapTxID creates VID with virtualTx which only contains txid.
 // Also sets solidification deadline, after which IsPullDeadlineDue will start returning true
 // The pull deadline will be dropped after transaction will become available and virtualTx will be converted
@@ -180,7 +242,7 @@ func (vid *WrappedTx) ShortString() string {
 	vid.Unwrap(UnwrapOptions{
 		Vertex: func(v *Vertex) {
 			mode = "vertex"
-			flagsStr = fmt.Sprintf(", %08b", vid.flags)
+			flagsStr = ", " + vid.flags.String()
 			status = vid.GetTxStatusNoLock().String()
 			if vid.err != nil {
 				reason = fmt.Sprintf(" err: '%v'", vid.err)
@@ -250,6 +312,37 @@ func (vid *WrappedTx) OutputAt(idx byte) (*ledger.Output, error) {
 	return vid._outputAt(idx)
 }
 
+// OutputsAt returns outputs at indices, resolved under single lock of the vertex.
+// Unlike OutputAt, it returns error if any of outputs is not available in the virtual tx or vertex is deleted
+func (vid *WrappedTx) OutputsAt(indices []byte) (ret []*ledger.Output, err error) {
+	ret = make([]*ledger.Output, len(indices))
+	vid.RUnwrap(UnwrapOptions{
+		Vertex: func(v *Vertex) {
+			for i, idx := range indices {
+				if ret[i], err = v.Tx.ProducedOutputAt(idx); err != nil {
+					return
+				}
+			}
+		},
+		VirtualTx: func(v *VirtualTransaction) {
+			var available bool
+			for i, idx := range indices {
+				if ret[i], available = v.OutputAt(idx); !available {
+					err = fmt.Errorf("OutputsAt: output #%d of %s is not available", idx, vid.IDShortString())
+					return
+				}
+			}
+		},
+		Deleted: func() {
+			err = fmt.Errorf("OutputsAt: %w: %s", ErrDeletedVertexAccessed, vid.IDShortString())
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
 func (vid *WrappedTx) MustOutputAt(idx byte) *ledger.Output {
 	ret, err := vid.OutputAt(idx)
 	util.AssertNoError(err)
@@ -440,7 +533,7 @@ func (vid *WrappedTx) LinesNoLock(prefix ...string) *lines.Lines {
 		ret.Add("---- transaction ----\n" + v.Tx.LinesShort(prefix...).String())
 	case _virtualTx:
 		if v.needsPull {
-			ret.Add("Pull: number of pulls: %d, next pull in %v", v.timesPulled, time.Until(v.nextPull))
+			ret.Add("Pull: number of pulls: %d, attempts: %d, next pull in %v", v.timesPulled, v.pullAttempts, time.Until(v.nextPull))
 		} else {
 			ret.Add("Pull: not needed")
 		}
@@ -621,7 +714,7 @@ func (vid *WrappedTx) String() (ret string) {
 				cov = *vid.coverage
 			}
 			t := "vertex (" + vid.GetTxStatusNoLock().String() + ")"
-			ret = fmt.Sprintf("%20s %s :: in: %d, out: %d, consumed: %d, conflicts: %d, ref: %d, Flags: %08b, err: '%v', cov: %s",
+			ret = fmt.Sprintf("%20s %s :: in: %d, out: %d, consumed: %d, conflicts: %d, ref: %d, Flags: %s, err: '%v', cov: %s",
 				t,
 				vid.ID.StringShort(),
 				v.Tx.NumInputs(),
@@ -640,7 +733,7 @@ func (vid *WrappedTx) String() (ret string) {
 			v.mutex.RLock()
 			defer v.mutex.RUnlock()
 
-			ret = fmt.Sprintf("%20s %s:: out: %d, consumed: %d, conflicts: %d, flags: %08b, err: %v",
+			ret = fmt.Sprintf("%20s %s:: out: %d, consumed: %d, conflicts: %d, flags: %s, err: %v",
 				t,
 				vid.ID.StringShort(),
 				len(v.outputs),
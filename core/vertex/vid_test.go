@@ -0,0 +1,129 @@
+package vertex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnStatusChange(t *testing.T) {
+	type transition struct{ old, new Status }
+
+	t.Run("good", func(t *testing.T) {
+		vid := WrapTxID(ledger.RandomTransactionID(false))
+		transitions := make([]transition, 0)
+		vid.OnStatusChange(func(old, new Status) {
+			// callback is called outside the lock
+			require.EqualValues(t, new, vid.GetTxStatus())
+			transitions = append(transitions, transition{old, new})
+		})
+		vid.SetTxStatusGood()
+		vid.SetTxStatusGood()
+		require.EqualValues(t, []transition{{Undefined, Good}}, transitions)
+	})
+	t.Run("bad", func(t *testing.T) {
+		vid := WrapTxID(ledger.RandomTransactionID(false))
+		transitions := make([]transition, 0)
+		vid.OnStatusChange(func(old, new Status) {
+			require.EqualValues(t, new, vid.GetTxStatus())
+			transitions = append(transitions, transition{old, new})
+		})
+		vid.SetTxStatusBad(errors.New("bad"))
+		require.EqualValues(t, []transition{{Undefined, Bad}}, transitions)
+	})
+	t.Run("removed", func(t *testing.T) {
+		vid := WrapTxID(ledger.RandomTransactionID(false))
+		called := false
+		vid.OnStatusChange(func(_, _ Status) {
+			called = true
+		})
+		vid.OnStatusChange(nil)
+		vid.SetTxStatusGood()
+		require.False(t, called)
+	})
+	t.Run("failed assertion releases the lock", func(t *testing.T) {
+		vid := WrapTxID(ledger.RandomTransactionID(false))
+		called := false
+		vid.OnStatusChange(func(_, _ Status) {
+			called = true
+		})
+		vid.SetTxStatusGood()
+		called = false
+		require.Panics(t, func() {
+			vid.SetTxStatusBad(errors.New("bad"))
+		})
+		require.False(t, called)
+		require.EqualValues(t, Good, vid.GetTxStatus())
+	})
+}
+
+func TestOnStatusDefined(t *testing.T) {
+	t.Run("all callbacks called once", func(t *testing.T) {
+		vid := WrapTxID(ledger.RandomTransactionID(false))
+		statuses := make([]Status, 0)
+		for i := 0; i < 3; i++ {
+			vid.OnStatusDefined(func(status Status) {
+				// callback is called outside the lock
+				require.EqualValues(t, status, vid.GetTxStatus())
+				statuses = append(statuses, status)
+			})
+		}
+		require.EqualValues(t, 0, len(statuses))
+		vid.SetTxStatusBad(errors.New("bad"))
+		require.EqualValues(t, []Status{Bad, Bad, Bad}, statuses)
+		vid.SetTxStatusBad(errors.New("bad again"))
+		require.EqualValues(t, 3, len(statuses))
+	})
+	t.Run("already defined", func(t *testing.T) {
+		vid := WrapTxID(ledger.RandomTransactionID(false))
+		vid.SetTxStatusGood()
+		var status Status
+		vid.OnStatusDefined(func(s Status) {
+			status = s
+		})
+		require.EqualValues(t, Good, status)
+	})
+	t.Run("together with status change callback", func(t *testing.T) {
+		vid := WrapTxID(ledger.RandomTransactionID(false))
+		changed, defined := false, false
+		vid.OnStatusChange(func(_, _ Status) {
+			changed = true
+		})
+		vid.OnStatusDefined(func(_ Status) {
+			defined = true
+		})
+		vid.SetTxStatusGood()
+		require.True(t, changed)
+		require.True(t, defined)
+	})
+}
+
+func TestOutputsAt(t *testing.T) {
+	v := newVirtualTx()
+	o0 := ledger.NewOutput(func(o *ledger.Output) {
+		o.WithAmount(1000).WithLock(ledger.ChainLockFromChainID(ledger.RandomChainID()))
+	})
+	o2 := ledger.NewOutput(func(o *ledger.Output) {
+		o.WithAmount(2000).WithLock(ledger.ChainLockFromChainID(ledger.RandomChainID()))
+	})
+	require.NoError(t, v.addOutput(0, o0))
+	require.NoError(t, v.addOutput(2, o2))
+	vid := v.wrapWithID(ledger.RandomTransactionID(false))
+
+	outs, err := vid.OutputsAt([]byte{2, 0})
+	require.NoError(t, err)
+	require.EqualValues(t, []*ledger.Output{o2, o0}, outs)
+
+	_, err = vid.OutputsAt([]byte{0, 1})
+	require.Error(t, err)
+}
+
+func TestFlagsString(t *testing.T) {
+	require.EqualValues(t, "NONE", Flags(0).String())
+	require.EqualValues(t, "DEFINED|CONSTRAINTS_VALID", (FlagVertexDefined | FlagVertexConstraintsValid).String())
+	require.EqualValues(t, "ATTACH_STARTED|ATTACH_FINISHED",
+		(FlagVertexTxAttachmentFinished | FlagVertexTxAttachmentStarted).String())
+	require.EqualValues(t, "DEFINED|0x80", (FlagVertexDefined | Flags(0b10000000)).String())
+}
@@ -3,6 +3,7 @@ package vertex
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/lunfardo314/proxima/ledger"
@@ -99,6 +100,34 @@ func (v *VirtualTransaction) OutputAt(idx byte) (*ledger.Output, bool) {
 	return nil, false
 }
 
+// HasAllOutputs returns true if outputs at all indices are available in the virtual tx
+func (v *VirtualTransaction) HasAllOutputs(indices ...byte) bool {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	for _, idx := range indices {
+		if _, isAvailable := v.outputs[idx]; !isAvailable {
+			return false
+		}
+	}
+	return true
+}
+
+// KnownOutputIndices returns sorted indices of outputs available in the virtual tx
+func (v *VirtualTransaction) KnownOutputIndices() []byte {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	ret := make([]byte, 0, len(v.outputs))
+	for idx := range v.outputs {
+		ret = append(ret, idx)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i] < ret[j]
+	})
+	return ret
+}
+
 func (v *VirtualTransaction) sequencerOutputs() (*ledger.Output, *ledger.Output) {
 	if v.sequencerOutputIndices == nil {
 		return nil, nil
@@ -151,7 +180,10 @@ func (v *VirtualTransaction) SetPullNeeded() {
 	v.pullRulesDefined = true
 	v.needsPull = true
 	v.timesPulled = 0
+	v.pullAttempts = 0
+	v.pullsFromPeer = 0
 	v.nextPull = time.Now()
+	v.pullStarted = v.nextPull
 }
 
 func (v *VirtualTransaction) SetPullNotNeeded() {
@@ -159,7 +191,7 @@ func (v *VirtualTransaction) SetPullNotNeeded() {
 	v.needsPull = false
 }
 
-// SetPullHappened increases pull counter and sets nex pull deadline
+// SetPullHappened increases pull counter and sets next pull deadline with exponential backoff
 func (v *VirtualTransaction) SetPullHappened(nTimes int, repeatAfter time.Duration) {
 	util.Assertf(v.pullRulesDefined, "v.pullRulesDefined")
 	if nTimes <= 0 {
@@ -167,11 +199,60 @@ func (v *VirtualTransaction) SetPullHappened(nTimes int, repeatAfter time.Durati
 		return
 	}
 	v.timesPulled += nTimes
-	v.nextPull = time.Now().Add(repeatAfter)
+	v.pullAttempts++
+	v.nextPull = time.Now().Add(PullBackoff(repeatAfter, v.pullAttempts))
+}
+
+// SetPullFromPeerHappened same as SetPullHappened with the pull request sent to the specific peer
+func (v *VirtualTransaction) SetPullFromPeerHappened(repeatAfter time.Duration) {
+	v.SetPullHappened(1, repeatAfter)
+	v.pullsFromPeer++
+}
+
+// NumPullsFromPeer returns number of pull requests sent to the specific peer since pull rules were defined.
+// While transaction is still virtual, all of them were not answered
+func (v *VirtualTransaction) NumPullsFromPeer() int {
+	return v.pullsFromPeer
+}
+
+// maxPullBackoff is the maximum period between pulls, unless repeat period itself is longer
+const maxPullBackoff = 8 * time.Second
+
+// PullBackoff returns period until the next pull after the attempt. It starts with repeatAfter and doubles with
+// each attempt up to maxPullBackoff
+func PullBackoff(repeatAfter time.Duration, attempt int) time.Duration {
+	maxBackoff := max(repeatAfter, maxPullBackoff)
+	ret := repeatAfter
+	for i := 1; i < attempt && ret < maxBackoff; i++ {
+		ret *= 2
+	}
+	return min(ret, maxBackoff)
+}
+
+// PullInProgress returns true if pull rules require the transaction to be pulled, regardless of the next pull deadline
+func (v *VirtualTransaction) PullInProgress() bool {
+	return v.pullRulesDefined && v.needsPull
+}
+
+// PullAttempts returns number of pull attempts since pull rules were defined
+func (v *VirtualTransaction) PullAttempts() int {
+	return v.pullAttempts
+}
+
+// PullPatience returns wall-clock time the transaction is pulled before solidification deadline. It is the time
+// maxPullAttempts pulls would take without backoff, so backoff does not make the deadline longer
+func PullPatience(repeatAfter time.Duration, maxPullAttempts int) time.Duration {
+	return time.Duration(maxPullAttempts) * repeatAfter
+}
+
+// PullingFor returns time elapsed since pull rules required the transaction to be pulled
+func (v *VirtualTransaction) PullingFor() time.Duration {
+	return time.Since(v.pullStarted)
 }
 
-func (v *VirtualTransaction) PullPatienceExpired(maxPullAttempts int) bool {
-	return v.PullNeeded() && v.timesPulled >= maxPullAttempts
+// PullPatienceExpired returns true if it is time to pull again and the transaction is being pulled longer than patience
+func (v *VirtualTransaction) PullPatienceExpired(patience time.Duration) bool {
+	return v.PullNeeded() && v.PullingFor() >= patience
 }
 
 func (v *VirtualTransaction) PullNeeded() bool {
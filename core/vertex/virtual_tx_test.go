@@ -0,0 +1,102 @@
+package vertex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullBackoff(t *testing.T) {
+	t.Run("progression", func(t *testing.T) {
+		expected := []time.Duration{
+			500 * time.Millisecond,
+			time.Second,
+			2 * time.Second,
+			4 * time.Second,
+			8 * time.Second,
+			8 * time.Second,
+			8 * time.Second,
+		}
+		for i, d := range expected {
+			require.EqualValues(t, d, PullBackoff(500*time.Millisecond, i+1))
+		}
+	})
+	t.Run("long repeat period", func(t *testing.T) {
+		require.EqualValues(t, 10*time.Second, PullBackoff(10*time.Second, 1))
+		require.EqualValues(t, 10*time.Second, PullBackoff(10*time.Second, 5))
+	})
+	t.Run("deadline", func(t *testing.T) {
+		const repeatAfter = 500 * time.Millisecond
+		v := newVirtualTx()
+		v.SetPullNeeded()
+		require.True(t, v.PullNeeded())
+
+		for attempt := 1; attempt <= 6; attempt++ {
+			before := time.Now()
+			v.SetPullHappened(2, repeatAfter)
+			require.False(t, v.PullNeeded())
+			require.EqualValues(t, attempt, v.pullAttempts)
+			require.EqualValues(t, 2*attempt, v.timesPulled)
+			require.False(t, v.nextPull.Before(before.Add(PullBackoff(repeatAfter, attempt))))
+		}
+		// pull which did not happen does not count
+		v.SetPullHappened(0, repeatAfter)
+		require.EqualValues(t, 6, v.pullAttempts)
+
+		// patience is measured in wall-clock time since pull rules were defined, regardless of the backoff
+		patience := PullPatience(repeatAfter, 10)
+		require.EqualValues(t, 5*time.Second, patience)
+		v.nextPull = time.Now()
+		require.False(t, v.PullPatienceExpired(patience))
+		v.pullStarted = time.Now().Add(-patience)
+		require.True(t, v.PullPatienceExpired(patience))
+		require.True(t, v.PullingFor() >= patience)
+		// not expired until it is time to pull again
+		v.nextPull = time.Now().Add(time.Second)
+		require.False(t, v.PullPatienceExpired(patience))
+
+		// new pull rules reset the backoff
+		v.SetPullNeeded()
+		require.EqualValues(t, 0, v.pullAttempts)
+		require.True(t, v.PullNeeded())
+	})
+	t.Run("from peer", func(t *testing.T) {
+		const repeatAfter = 500 * time.Millisecond
+		v := newVirtualTx()
+		v.SetPullNeeded()
+
+		before := time.Now()
+		v.SetPullFromPeerHappened(repeatAfter)
+		require.False(t, v.PullNeeded())
+		require.EqualValues(t, 1, v.PullAttempts())
+		require.EqualValues(t, 1, v.NumPullsFromPeer())
+		require.False(t, v.nextPull.Before(before.Add(repeatAfter)))
+
+		// pull escalated to other peers is not counted as pull from the peer
+		v.SetPullHappened(3, repeatAfter)
+		require.EqualValues(t, 2, v.PullAttempts())
+		require.EqualValues(t, 1, v.NumPullsFromPeer())
+
+		v.SetPullNeeded()
+		require.EqualValues(t, 0, v.NumPullsFromPeer())
+	})
+}
+
+func TestVirtualTxOutputs(t *testing.T) {
+	v := newVirtualTx()
+	require.True(t, v.HasAllOutputs())
+	require.False(t, v.HasAllOutputs(0))
+	require.EqualValues(t, 0, len(v.KnownOutputIndices()))
+
+	o := ledger.NewOutput(func(o *ledger.Output) {
+		o.WithAmount(1000).WithLock(ledger.ChainLockFromChainID(ledger.RandomChainID()))
+	})
+	require.NoError(t, v.addOutput(5, o))
+	require.NoError(t, v.addOutput(1, o))
+
+	require.True(t, v.HasAllOutputs(1, 5))
+	require.False(t, v.HasAllOutputs(1, 2, 5))
+	require.EqualValues(t, []byte{1, 5}, v.KnownOutputIndices())
+}
@@ -1,6 +1,8 @@
 package poker
 
 import (
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/lunfardo314/proxima/core/vertex"
@@ -23,7 +25,14 @@ type (
 	Poker struct {
 		*work_process.WorkProcess[Input]
 		environment
-		m map[*vertex.WrappedTx]waitingList
+		mutex sync.RWMutex
+		m     map[*vertex.WrappedTx]waitingList
+	}
+
+	// WantedInfo wanted transaction with the number of transactions waiting to be poked with it
+	WantedInfo struct {
+		Wanted     *vertex.WrappedTx
+		NumWaiting int
 	}
 
 	waitingList struct {
@@ -63,6 +72,9 @@ func New(env environment) *Poker {
 }
 
 func (d *Poker) consume(inp Input) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
 	switch inp.Cmd {
 	case CommandAdd:
 		d.Assertf(inp.Wanted != nil, "inp.Wanted != nil")
@@ -137,6 +149,46 @@ func (d *Poker) PokeAllWith(vid *vertex.WrappedTx) {
 	})
 }
 
+// NumWanted returns number of wanted transactions with at least one waiting transaction
+func (d *Poker) NumWanted() int {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return len(d.m)
+}
+
+// NumWaiting returns number of distinct transactions registered to be poked
+func (d *Poker) NumWaiting() int {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	waiting := make(map[*vertex.WrappedTx]struct{})
+	for _, lst := range d.m {
+		for _, vid := range lst.waiting {
+			waiting[vid] = struct{}{}
+		}
+	}
+	return len(waiting)
+}
+
+// MostWanted returns up to n wanted transactions sorted descending by the number of waiting transactions
+func (d *Poker) MostWanted(n int) []WantedInfo {
+	d.mutex.RLock()
+	ret := make([]WantedInfo, 0, len(d.m))
+	for wanted, lst := range d.m {
+		ret = append(ret, WantedInfo{Wanted: wanted, NumWaiting: len(lst.waiting)})
+	}
+	d.mutex.RUnlock()
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].NumWaiting > ret[j].NumWaiting
+	})
+	if len(ret) > n {
+		ret = ret[:n]
+	}
+	return ret
+}
+
 //func (d *Poker) saveDependencyDAG(fname string, max int) {
 //	nodes := make([]depdag.Node, 0, len(d.m))
 //
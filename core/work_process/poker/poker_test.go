@@ -54,3 +54,46 @@ func TestBasic(t *testing.T) {
 	glb.WaitAllWorkProcessesStop()
 	require.EqualValues(t, howManyPokes*howManyTx, int(counter.Load()))
 }
+
+func TestIntrospection(t *testing.T) {
+	glb := global.NewDefault()
+	p := New(glb)
+
+	wanted := make([]*vertex.WrappedTx, 3)
+	for i := range wanted {
+		wanted[i] = vertex.WrapTxID(ledger.RandomTransactionID(true))
+	}
+	waiting := make([]*vertex.WrappedTx, 5)
+	for i := range waiting {
+		waiting[i] = vertex.WrapTxID(ledger.RandomTransactionID(true))
+	}
+	require.EqualValues(t, 0, p.NumWanted())
+	require.EqualValues(t, 0, p.NumWaiting())
+
+	// wanted[i] is waited by i+1 transactions
+	for i, w := range wanted {
+		for j := 0; j <= i; j++ {
+			p.consume(Input{Wanted: w, WhoIsWaiting: waiting[j], Cmd: CommandAdd})
+		}
+	}
+	// repeated registration is ignored
+	p.consume(Input{Wanted: wanted[0], WhoIsWaiting: waiting[0], Cmd: CommandAdd})
+
+	require.EqualValues(t, 3, p.NumWanted())
+	require.EqualValues(t, 3, p.NumWaiting())
+
+	top := p.MostWanted(2)
+	require.EqualValues(t, 2, len(top))
+	require.True(t, top[0].Wanted == wanted[2])
+	require.EqualValues(t, 3, top[0].NumWaiting)
+	require.True(t, top[1].Wanted == wanted[1])
+	require.EqualValues(t, 2, top[1].NumWaiting)
+
+	p.consume(Input{Wanted: wanted[2], Cmd: CommandPokeAll})
+	require.EqualValues(t, 2, p.NumWanted())
+	require.EqualValues(t, 2, p.NumWaiting())
+	require.EqualValues(t, 2, len(p.MostWanted(10)))
+
+	glb.Stop()
+	glb.WaitAllWorkProcessesStop()
+}
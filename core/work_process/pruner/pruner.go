@@ -1,11 +1,13 @@
 package pruner
 
 import (
+	"sync"
 	"time"
 
 	"github.com/lunfardo314/proxima/core/vertex"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/util/set"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -19,13 +21,29 @@ type (
 		NumVertices() int
 		NumStateReaders() int
 	}
+	// PruneStats is the result of the pruning analysis without pruning
+	PruneStats struct {
+		NumVertices int
+		// transactions which would be deleted from the memDAG
+		MarkedForDeletion []ledger.TransactionID
+		// number of vertices past cones of which would be un-referenced
+		NumUnreferencedPastCone int
+		// number of vertices by reference counter: 0, 1, 2, 3, 4 and 5 or more
+		RefStats [6]uint32
+	}
+
 	Pruner struct {
 		environment
+		// pinned transactions are never pruned
+		pinnedMutex sync.RWMutex
+		pinned      set.Set[ledger.TransactionID]
 
 		// metrics
 		metricsEnabled       bool
 		numVerticesGauge     prometheus.Gauge
 		numStateReadersGauge prometheus.Gauge
+		refCountGauge        *prometheus.GaugeVec
+		oldestSlotAgeGauge   prometheus.Gauge
 	}
 )
 
@@ -34,39 +52,54 @@ const (
 	TraceTag = Name
 )
 
-func New(env environment) *Pruner {
-	ret := &Pruner{environment: env}
+// New starts pruner loop with the period. If period is not positive, slot duration is used
+func New(env environment, period time.Duration) *Pruner {
+	ret := &Pruner{
+		environment: env,
+		pinned:      set.New[ledger.TransactionID](),
+	}
 	ret.registerMetrics()
 
-	ret.RepeatInBackground(Name, ledger.SlotDuration(), func() bool {
-		ret.doPrune()
-		ret.updateMetrics()
+	if period <= 0 {
+		period = ledger.SlotDuration()
+	}
+	ret.RepeatInBackground(Name, period, func() bool {
+		refStats, oldestSlot := ret.doPrune()
+		ret.updateMetrics(refStats, oldestSlot)
 		return true
 	}, true)
 
 	return ret
 }
 
-// pruneVertices returns how many marked for deletion and how many past cones unreferenced
-func (p *Pruner) pruneVertices() (markedForDeletionCount, unreferencedPastConeCount int, refStats [6]uint32) {
+// pruneVertices returns how many marked for deletion and how many past cones unreferenced.
+// It also returns the slot of the oldest vertex which remains in the memDAG, or current slot if none remains
+func (p *Pruner) pruneVertices() (markedForDeletionCount, unreferencedPastConeCount int, refStats [6]uint32, oldestSlot ledger.Slot) {
 	toDelete := make([]*vertex.WrappedTx, 0)
 	nowis := time.Now()
+	oldestSlot = ledger.TimeNow().Slot()
 	for _, vid := range p.Vertices() {
+		if p.IsPinned(vid.ID) {
+			// pinned vertex is left untouched
+			countReferences(&refStats, uint32(vid.NumReferences()))
+			if vid.Slot() < oldestSlot {
+				oldestSlot = vid.Slot()
+			}
+			continue
+		}
 		markedForDeletion, unreferencedPastCone, nReferences := vid.DoPruningIfRelevant(nowis)
 		if markedForDeletion {
 			toDelete = append(toDelete, vid)
 			markedForDeletionCount++
 			p.Tracef(TraceTag, "marked for deletion %s", vid.IDShortString)
+		} else if vid.Slot() < oldestSlot {
+			oldestSlot = vid.Slot()
 		}
 		if unreferencedPastCone {
 			unreferencedPastConeCount++
 			p.Tracef(TraceTag, "past cone of %s has been unreferenced", vid.IDShortString)
 		}
-		if int(nReferences) < len(refStats)-1 {
-			refStats[nReferences]++
-		} else {
-			refStats[len(refStats)-1]++
-		}
+		countReferences(&refStats, nReferences)
 	}
 	p.PurgeDeletedVertices(toDelete)
 	for _, deleted := range toDelete {
@@ -75,14 +108,68 @@ func (p *Pruner) pruneVertices() (markedForDeletionCount, unreferencedPastConeCo
 	return
 }
 
-func (p *Pruner) doPrune() {
+// PruneReport analyses what the next pruning would do, without changing anything in the memDAG
+func (p *Pruner) PruneReport() (ret PruneStats) {
+	ret.MarkedForDeletion = make([]ledger.TransactionID, 0)
+	nowis := time.Now()
+	vertices := p.Vertices()
+	ret.NumVertices = len(vertices)
+	for _, vid := range vertices {
+		markedForDeletion, unreferencedPastCone, nReferences := vid.PruningCandidate(nowis)
+		if p.IsPinned(vid.ID) {
+			markedForDeletion, unreferencedPastCone = false, false
+		}
+		if markedForDeletion {
+			ret.MarkedForDeletion = append(ret.MarkedForDeletion, vid.ID)
+		}
+		if unreferencedPastCone {
+			ret.NumUnreferencedPastCone++
+		}
+		countReferences(&ret.RefStats, nReferences)
+	}
+	return
+}
+
+// Pin prevents the transaction from being pruned until unpinned. The transaction does not need to be in the memDAG
+func (p *Pruner) Pin(txid ledger.TransactionID) {
+	p.pinnedMutex.Lock()
+	defer p.pinnedMutex.Unlock()
+
+	p.pinned.Insert(txid)
+}
+
+// Unpin makes the transaction subject to pruning again
+func (p *Pruner) Unpin(txid ledger.TransactionID) {
+	p.pinnedMutex.Lock()
+	defer p.pinnedMutex.Unlock()
+
+	p.pinned.Remove(txid)
+}
+
+func (p *Pruner) IsPinned(txid ledger.TransactionID) bool {
+	p.pinnedMutex.RLock()
+	defer p.pinnedMutex.RUnlock()
+
+	return p.pinned.Contains(txid)
+}
+
+func countReferences(refStats *[6]uint32, nReferences uint32) {
+	if int(nReferences) < len(refStats)-1 {
+		refStats[nReferences]++
+	} else {
+		refStats[len(refStats)-1]++
+	}
+}
+
+func (p *Pruner) doPrune() (refStats [6]uint32, oldestSlot ledger.Slot) {
 	start := time.Now()
 
-	nDeleted, nUnReferenced, refStats := p.pruneVertices()
+	nDeleted, nUnReferenced, refStats, oldestSlot := p.pruneVertices()
 	nReadersPurged, readersLeft := p.PurgeCachedStateReaders()
 
 	p.Log().Infof("[memDAG pruner] vertices: %d, deleted: %d, detached past cones: %d. state readers purged: %d, left: %d. Ref stats: %v (%v)",
 		p.NumVertices(), nDeleted, nUnReferenced, nReadersPurged, readersLeft, refStats, time.Since(start))
+	return
 }
 
 func (p *Pruner) registerMetrics() {
@@ -94,10 +181,29 @@ func (p *Pruner) registerMetrics() {
 		Name: "proxima_memDAG_numStateReadersGauge",
 		Help: "number of cached state readers in the memDAG",
 	})
-	p.MetricsRegistry().MustRegister(p.numStateReadersGauge, p.numVerticesGauge)
+	p.refCountGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxima_vertex_refcount",
+		Help: "number of vertices in the memDAG by reference counter after the last pruning",
+	}, []string{"bucket"})
+	p.oldestSlotAgeGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "proxima_memDAG_oldestVertexSlotAge",
+		Help: "number of slots between current slot and slot of the oldest vertex remaining in the memDAG after the last pruning",
+	})
+	p.MetricsRegistry().MustRegister(p.numStateReadersGauge, p.numVerticesGauge, p.refCountGauge, p.oldestSlotAgeGauge)
 }
 
-func (p *Pruner) updateMetrics() {
+// refCountBuckets are labels of the reference counter buckets, same as in the refStats
+var refCountBuckets = [6]string{"0", "1", "2", "3", "4", "5+"}
+
+func (p *Pruner) updateMetrics(refStats [6]uint32, oldestSlot ledger.Slot) {
 	p.numVerticesGauge.Set(float64(p.NumVertices()))
 	p.numStateReadersGauge.Set(float64(p.NumStateReaders()))
+	for i, n := range refStats {
+		p.refCountGauge.WithLabelValues(refCountBuckets[i]).Set(float64(n))
+	}
+	if nowSlot := ledger.TimeNow().Slot(); nowSlot > oldestSlot {
+		p.oldestSlotAgeGauge.Set(float64(nowSlot - oldestSlot))
+	} else {
+		p.oldestSlotAgeGauge.Set(0)
+	}
 }
@@ -85,8 +85,8 @@ func (t *SequencerTips) consume(inp Input) {
 				old.IDShortString(), inp.VID.IDShortString(), seqID.StringShort())
 		}
 		if t.replaceOldWithNew(old.WrappedTx, inp.VID) {
-			if inp.VID.Reference() {
-				old.UnReference()
+			if inp.VID.Reference(Name) {
+				old.UnReference(Name)
 				old.WrappedTx = inp.VID
 				old.lastActivity = time.Now()
 				t.latestMilestones[*seqID] = old
@@ -97,7 +97,7 @@ func (t *SequencerTips) consume(inp Input) {
 			t.Tracef(TraceTag, "incoming milestone %s didn't replace existing %s", inp.VID.IDShortString, old.IDShortString)
 		}
 	} else {
-		if inp.VID.Reference() {
+		if inp.VID.Reference(Name) {
 			t.latestMilestones[*seqID] = _milestoneData{
 				WrappedTx:    inp.VID,
 				lastActivity: time.Now(),
@@ -223,7 +223,7 @@ func (t *SequencerTips) purgeAndLog() {
 	}
 
 	for _, chainID := range toDelete {
-		t.latestMilestones[chainID].UnReference()
+		t.latestMilestones[chainID].UnReference(Name)
 		delete(t.latestMilestones, chainID)
 		t.Log().Infof("[tippool] chainID %s has been removed from the sequencer tippool", chainID.StringShort())
 	}
@@ -1,33 +1,56 @@
 package txinput_queue
 
 import (
+	"container/list"
 	"sync"
 	"time"
 )
 
-type inGate[T comparable] struct {
-	mutex     sync.Mutex
-	whiteList map[T]time.Time
-	blackList map[T]time.Time
-	ttlWhite  time.Duration
-	ttlBlack  time.Duration
-}
+type (
+	inGate[T comparable] struct {
+		mutex     sync.RWMutex
+		whiteList map[T]wantedEntry
+		// keys of the white list ordered by the time they are wanted since, the oldest first
+		wantedOrder *list.List
+		blackList   map[T]time.Time
+		ttlWhite    time.Duration
+		ttlBlack    time.Duration
+		// maximum size of the white list. 0 means unlimited
+		maxWhite int
+		// called when key leaves the white list: received = true if it passed the gate,
+		// received = false if it was evicted or expired
+		onWantedDone func(wantedFor time.Duration, received bool)
+	}
+
+	wantedEntry struct {
+		since    time.Time
+		deadline time.Time
+		// element of the wantedOrder
+		elem *list.Element
+	}
+)
 
-func newInGate[T comparable](ttlWhite, ttlBlack time.Duration) *inGate[T] {
-	return &inGate[T]{
-		whiteList: make(map[T]time.Time),
-		blackList: make(map[T]time.Time),
-		ttlWhite:  ttlWhite,
-		ttlBlack:  ttlBlack,
+func newInGate[T comparable](ttlWhite, ttlBlack time.Duration, maxWhite ...int) *inGate[T] {
+	ret := &inGate[T]{
+		whiteList:   make(map[T]wantedEntry),
+		wantedOrder: list.New(),
+		blackList:   make(map[T]time.Time),
+		ttlWhite:    ttlWhite,
+		ttlBlack:    ttlBlack,
+	}
+	if len(maxWhite) > 0 && maxWhite[0] > 0 {
+		ret.maxWhite = maxWhite[0]
 	}
+	return ret
 }
 
 func (g *inGate[T]) checkPass(key T) (pass, wanted bool) {
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
 
-	if _, inWhite := g.whiteList[key]; inWhite {
-		delete(g.whiteList, key)
+	if e, inWhite := g.whiteList[key]; inWhite {
+		g._deleteWanted(key, e)
+		g._wantedDone(e, true)
 		g.blackList[key] = time.Now().Add(g.ttlBlack)
 		return true, true
 	}
@@ -41,7 +64,16 @@ func (g *inGate[T]) checkPass(key T) (pass, wanted bool) {
 	return true, false
 }
 
-func (g *inGate[T]) addWanted(key T) {
+// addWanted adds key to the white list. If white list is full, the key wanted for the longest time is evicted.
+// Returns evicted = true if a key was evicted.
+// Returns duplicate = true if the key was added to the white list recently, i.e. less than half of TTL ago.
+// In that case the white list remains unchanged, the deadline is not refreshed
+func (g *inGate[T]) addWanted(key T) (evicted, duplicate bool) {
+	// fast path for repeated calls with the same key: read lock only
+	if g.recentlyWanted(key) {
+		return false, true
+	}
+
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
 
@@ -49,8 +81,66 @@ func (g *inGate[T]) addWanted(key T) {
 	if _, inBlack := g.blackList[key]; inBlack {
 		return
 	}
+	nowis := time.Now()
+	if e, inWhite := g.whiteList[key]; inWhite {
+		// refresh the deadline, keep the time it is wanted since
+		e.deadline = nowis.Add(g.ttlWhite)
+		g.whiteList[key] = e
+		return
+	}
+	if g.maxWhite > 0 && len(g.whiteList) >= g.maxWhite {
+		oldest := g.wantedOrder.Front().Value.(T)
+		oldestEntry := g.whiteList[oldest]
+		g._deleteWanted(oldest, oldestEntry)
+		g._wantedDone(oldestEntry, false)
+		evicted = true
+	}
+	g.whiteList[key] = wantedEntry{
+		since:    nowis,
+		deadline: nowis.Add(g.ttlWhite),
+		elem:     g.wantedOrder.PushBack(key),
+	}
+	return
+}
+
+func (g *inGate[T]) _deleteWanted(key T, e wantedEntry) {
+	delete(g.whiteList, key)
+	g.wantedOrder.Remove(e.elem)
+}
+
+func (g *inGate[T]) recentlyWanted(key T) bool {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	e, inWhite := g.whiteList[key]
+	return inWhite && e.deadline.After(time.Now().Add(g.ttlWhite/2))
+}
+
+func (g *inGate[T]) _wantedDone(e wantedEntry, received bool) {
+	if g.onWantedDone != nil {
+		g.onWantedDone(time.Since(e.since), received)
+	}
+}
+
+func (g *inGate[T]) numWanted() int {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
 
-	g.whiteList[key] = time.Now().Add(g.ttlWhite)
+	return len(g.whiteList)
+}
+
+// numWantedLongerThan returns number of keys which are in the white list longer than d
+func (g *inGate[T]) numWantedLongerThan(d time.Duration) int {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	ret := 0
+	for _, e := range g.whiteList {
+		if time.Since(e.since) > d {
+			ret++
+		}
+	}
+	return ret
 }
 
 func (g *inGate[T]) purge() int {
@@ -61,13 +151,15 @@ func (g *inGate[T]) purge() int {
 	toDelete := make([]T, 0)
 	nowis := time.Now()
 
-	for key, deadline := range g.whiteList {
-		if deadline.Before(nowis) {
+	for key, e := range g.whiteList {
+		if e.deadline.Before(nowis) {
 			toDelete = append(toDelete, key)
 		}
 	}
 	for _, key := range toDelete {
-		delete(g.whiteList, key)
+		e := g.whiteList[key]
+		g._deleteWanted(key, e)
+		g._wantedDone(e, false)
 	}
 	ret += len(toDelete)
 
@@ -30,4 +30,112 @@ func TestInputGate(t *testing.T) {
 		require.False(t, pass)
 		require.False(t, wanted)
 	})
+	t.Run("max wanted", func(t *testing.T) {
+		g := newInGate[int](5*time.Second, 10*time.Second, 3)
+		for i := 0; i < 3; i++ {
+			evicted, _ := g.addWanted(i)
+			require.False(t, evicted)
+			time.Sleep(time.Millisecond)
+		}
+		require.EqualValues(t, 3, g.numWanted())
+		// adding already wanted does not evict
+		evicted, duplicate := g.addWanted(2)
+		require.False(t, evicted)
+		require.True(t, duplicate)
+
+		// the oldest is evicted
+		evicted, _ = g.addWanted(3)
+		require.True(t, evicted)
+		require.EqualValues(t, 3, g.numWanted())
+		_, wanted := g.checkPass(0)
+		require.False(t, wanted)
+		for i := 1; i <= 3; i++ {
+			_, wanted = g.checkPass(i)
+			require.True(t, wanted)
+		}
+		require.EqualValues(t, 0, g.numWanted())
+	})
+	t.Run("evict wanted longest", func(t *testing.T) {
+		g := newInGate[int](100*time.Millisecond, 10*time.Second, 2)
+		g.addWanted(0)
+		time.Sleep(time.Millisecond)
+		g.addWanted(1)
+		// refreshed deadline of the key 0 is later than the deadline of the key 1, but it is wanted longer
+		time.Sleep(60 * time.Millisecond)
+		g.addWanted(0)
+		require.True(t, g.whiteList[0].deadline.After(g.whiteList[1].deadline))
+
+		evicted, _ := g.addWanted(2)
+		require.True(t, evicted)
+		_, wanted := g.checkPass(0)
+		require.False(t, wanted)
+		_, wanted = g.checkPass(1)
+		require.True(t, wanted)
+		require.EqualValues(t, 1, g.numWanted())
+		require.EqualValues(t, 1, g.wantedOrder.Len())
+	})
+	t.Run("stuck", func(t *testing.T) {
+		g := newInGate[int](5*time.Second, 10*time.Second)
+		g.addWanted(0)
+		g.addWanted(1)
+		require.EqualValues(t, 2, g.numWantedLongerThan(0))
+		require.EqualValues(t, 0, g.numWantedLongerThan(time.Second))
+
+		time.Sleep(100 * time.Millisecond)
+		g.addWanted(2)
+		require.EqualValues(t, 2, g.numWantedLongerThan(50*time.Millisecond))
+		require.EqualValues(t, 0, g.numWantedLongerThan(time.Second))
+	})
+	t.Run("duplicate", func(t *testing.T) {
+		g := newInGate[int](200*time.Millisecond, 10*time.Second)
+		_, duplicate := g.addWanted(1)
+		require.False(t, duplicate)
+		entry := g.whiteList[1]
+
+		// repeated call does not refresh the deadline
+		_, duplicate = g.addWanted(1)
+		require.True(t, duplicate)
+		require.EqualValues(t, entry, g.whiteList[1])
+
+		// after half of the TTL the deadline is refreshed
+		time.Sleep(110 * time.Millisecond)
+		_, duplicate = g.addWanted(1)
+		require.False(t, duplicate)
+		require.True(t, g.whiteList[1].deadline.After(entry.deadline))
+		require.EqualValues(t, entry.since, g.whiteList[1].since)
+		require.EqualValues(t, 1, g.numWanted())
+	})
+	t.Run("wanted done", func(t *testing.T) {
+		g := newInGate[int](100*time.Millisecond, 10*time.Second, 2)
+		var received, cancelled []time.Duration
+		g.onWantedDone = func(wantedFor time.Duration, ok bool) {
+			if ok {
+				received = append(received, wantedFor)
+			} else {
+				cancelled = append(cancelled, wantedFor)
+			}
+		}
+		g.addWanted(1)
+		g.addWanted(2)
+		time.Sleep(10 * time.Millisecond)
+		g.checkPass(1)
+		require.EqualValues(t, 1, len(received))
+		require.True(t, received[0] >= 10*time.Millisecond)
+
+		// not wanted does not count
+		g.checkPass(5)
+		require.EqualValues(t, 1, len(received))
+
+		// eviction
+		g.addWanted(3)
+		g.addWanted(4)
+		require.EqualValues(t, 1, len(cancelled))
+
+		// expiration
+		time.Sleep(110 * time.Millisecond)
+		g.purge()
+		require.EqualValues(t, 3, len(cancelled))
+		require.EqualValues(t, 1, len(received))
+		require.EqualValues(t, 0, g.numWanted())
+	})
 }
@@ -9,7 +9,9 @@ import (
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/ledger/transaction"
+	"github.com/lunfardo314/proxima/util"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
 )
 
 // transaction input queue to buffer incoming transactions from peers and from API
@@ -19,7 +21,7 @@ type (
 	environment interface {
 		global.NodeGlobal
 		TxInFromPeer(tx *transaction.Transaction, metaData *txmetadata.TransactionMetadata, from peer.ID) error
-		TxInFromAPI(tx *transaction.Transaction, trace bool) error
+		TxInFromAPI(tx *transaction.Transaction, trace, doNotGossip bool) error
 		GossipTxBytesToPeers(txBytes []byte, metadata *txmetadata.TransactionMetadata, except ...peer.ID)
 	}
 
@@ -29,6 +31,8 @@ type (
 		TxMetaData *txmetadata.TransactionMetadata
 		FromPeer   peer.ID
 		TraceFlag  bool
+		// DoNotGossip transaction from API is not gossiped to peers
+		DoNotGossip bool
 	}
 
 	TxInputQueue struct {
@@ -36,6 +40,8 @@ type (
 		*work_process.WorkProcess[Input]
 		// bloom filter
 		inGate *inGate[ledger.TransactionIDVeryShort4]
+		// maximum queue length for TryPush
+		capacity int
 		// metrics
 		inputTxCounter        prometheus.Counter
 		pulledTxCounter       prometheus.Counter
@@ -44,6 +50,10 @@ type (
 		gossipedCounter       prometheus.Counter
 		queueSize             prometheus.Gauge
 		nonSequencerTxCounter prometheus.Counter
+		wantedSize            prometheus.Gauge
+		wantedStuck           prometheus.Gauge
+		wantedDuplicates      prometheus.Counter
+		pullLatency           *prometheus.HistogramVec
 	}
 )
 
@@ -53,35 +63,58 @@ const (
 )
 
 const (
-	Name = "txInputQueue"
+	Name     = "txInputQueue"
+	TraceTag = Name
 
 	inGateBlackListTTLSlots = 60 // 10 min
 	inGateWhiteListTTLSlots = 6  // 1 min
 	inGateCleanupPeriod     = 10 * time.Second
+	// wanted transaction is considered stuck if it is not received after being pulled that long
+	inGateStuckAfterSlots = 3
+	// default maximum number of wanted transactions. Can be changed with config key 'transaction_pull.max_wanted'
+	defaultMaxWanted = 10_000
+	// default capacity of the queue for TryPush. Can be changed with config key 'workflow.tx_input_queue_capacity'
+	defaultCapacity = 100_000
 )
 
 func New(env environment) *TxInputQueue {
+	maxWanted := viper.GetInt("transaction_pull.max_wanted")
+	if maxWanted <= 0 {
+		maxWanted = defaultMaxWanted
+	}
+	capacity := viper.GetInt("workflow.tx_input_queue_capacity")
+	if capacity <= 0 {
+		capacity = defaultCapacity
+	}
 	ret := &TxInputQueue{
 		environment: env,
+		capacity:    capacity,
 		inGate: newInGate[ledger.TransactionIDVeryShort4](
 			inGateWhiteListTTLSlots*ledger.L().ID.SlotDuration(),
 			inGateBlackListTTLSlots*ledger.L().ID.SlotDuration(),
+			maxWanted,
 		),
 	}
+	ret.registerMetrics()
+	ret.inGate.onWantedDone = func(wantedFor time.Duration, received bool) {
+		ret.pullLatency.WithLabelValues(util.Cond(received, "received", "cancelled")).Observe(wantedFor.Seconds())
+	}
+
 	ret.WorkProcess = work_process.New[Input](env, Name, ret.consume)
 	ret.WorkProcess.Start()
 
 	ret.RepeatInBackground(Name+"_inFilterCleanup", inGateCleanupPeriod, func() bool {
 		ret.inGate.purge()
+		ret.wantedSize.Set(float64(ret.inGate.numWanted()))
+		ret.wantedStuck.Set(float64(ret.inGate.numWantedLongerThan(inGateStuckAfterSlots * ledger.L().ID.SlotDuration())))
 		return true
 	})
-
-	ret.registerMetrics()
 	return ret
 }
 
 func (q *TxInputQueue) consume(inp Input) {
 	q.inputTxCounter.Inc()
+	q.queueSize.Set(float64(q.Len()))
 
 	switch inp.Cmd {
 	case CmdFromPeer:
@@ -141,11 +174,14 @@ func (q *TxInputQueue) fromAPI(inp *Input) {
 		q.filterHitCounter.Inc()
 		return
 	}
-	if err = q.TxInFromAPI(tx, inp.TraceFlag); err != nil {
+	if err = q.TxInFromAPI(tx, inp.TraceFlag, inp.DoNotGossip); err != nil {
 		q.badTxCounter.Inc()
 		q.Log().Warn("TxInputQueue from API: %v", err)
 		return
 	}
+	if inp.DoNotGossip {
+		return
+	}
 	// gossiping all pre-validated transactions from API
 	q.GossipTxBytesToPeers(inp.TxBytes, inp.TxMetaData)
 	q.gossipedCounter.Inc()
@@ -181,13 +217,54 @@ func (q *TxInputQueue) registerMetrics() {
 		Help: "number of non-sequencer transactions",
 	})
 
-	q.MetricsRegistry().MustRegister(q.inputTxCounter, q.pulledTxCounter, q.badTxCounter, q.filterHitCounter, q.gossipedCounter, q.queueSize, q.nonSequencerTxCounter)
+	q.wantedSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "proxima_pull_list_size",
+		Help: "number of wanted (pulled) transactions",
+	})
+
+	q.wantedStuck = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "proxima_pull_stuck_count",
+		Help: "number of wanted (pulled) transactions which were not received for more than 3 slots",
+	})
+
+	q.wantedDuplicates = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "proxima_txInputQueue_wantedDuplicates",
+		Help: "number of repeated requests to add recently wanted transaction, skipped",
+	})
+
+	q.pullLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "proxima_pull_latency_seconds",
+		Help:    "time in seconds from adding transaction to the wanted list until it is received or cancelled (evicted or expired)",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 16, 32, 64},
+	}, []string{"result"})
+
+	q.MetricsRegistry().MustRegister(q.inputTxCounter, q.pulledTxCounter, q.badTxCounter, q.filterHitCounter, q.gossipedCounter, q.queueSize,
+		q.nonSequencerTxCounter, q.wantedSize, q.wantedStuck, q.wantedDuplicates, q.pullLatency)
+}
+
+// TryPush pushes input to the queue unless queue length reached the capacity.
+// Returns false if input was not pushed. Push is unbounded
+func (q *TxInputQueue) TryPush(inp Input) bool {
+	return q.Queue.TryPush(inp, q.capacity)
 }
 
 // AddWantedTransaction adds transaction short id to the wanted filter.
-// It makes the transaction go directly for attachment without checking other filters and without gossiping
+// It makes the transaction go directly for attachment without checking other filters and without gossiping.
+// Size of the wanted list is limited. When the limit is reached, the oldest wanted transaction is evicted
 func (q *TxInputQueue) AddWantedTransaction(txid *ledger.TransactionID) {
-	q.inGate.addWanted(txid.VeryShortID4())
+	evicted, duplicate := q.inGate.addWanted(txid.VeryShortID4())
+	if duplicate {
+		q.wantedDuplicates.Inc()
+		return
+	}
+	if evicted {
+		q.Tracef(TraceTag, "wanted list is full: the oldest wanted transaction was evicted while adding %s", txid.StringShort)
+	}
+}
+
+// NumWanted returns current size of the wanted list
+func (q *TxInputQueue) NumWanted() int {
+	return q.inGate.numWanted()
 }
 
 func (q *TxInputQueue) EvidenceNonSequencerTx() {
@@ -0,0 +1,77 @@
+package txinput_queue
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/lunfardo314/proxima/core/txmetadata"
+	"github.com/lunfardo314/proxima/global"
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/ledger/transaction"
+	"github.com/lunfardo314/proxima/ledger/txbuilder"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func init() {
+	ledger.InitWithTestingLedgerIDData()
+}
+
+type dummyEnvironment struct {
+	*global.Global
+	doNotGossip atomic.Bool
+	numGossiped atomic.Int32
+}
+
+func (d *dummyEnvironment) TxInFromPeer(_ *transaction.Transaction, _ *txmetadata.TransactionMetadata, _ peer.ID) error {
+	return nil
+}
+
+func (d *dummyEnvironment) TxInFromAPI(_ *transaction.Transaction, _, doNotGossip bool) error {
+	d.doNotGossip.Store(doNotGossip)
+	return nil
+}
+
+func (d *dummyEnvironment) GossipTxBytesToPeers(_ []byte, _ *txmetadata.TransactionMetadata, _ ...peer.ID) {
+	d.numGossiped.Inc()
+}
+
+func makeTxBytes(t *testing.T) []byte {
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	addr := ledger.AddressED25519FromPrivateKey(privKey)
+
+	inTxID := ledger.RandomTransactionID(false)
+	in := &ledger.OutputWithID{
+		ID: ledger.NewOutputID(&inTxID, 0),
+		Output: ledger.NewOutput(func(o *ledger.Output) {
+			o.WithAmount(1_000_000).WithLock(addr)
+		}),
+	}
+	td := txbuilder.NewTransferData(privKey, addr, ledger.TimeNow()).
+		WithAmount(in.Output.Amount()).
+		WithTargetLock(addr).
+		MustWithInputs(in)
+	txBytes, err := txbuilder.MakeSimpleTransferTransaction(td)
+	require.NoError(t, err)
+	return txBytes
+}
+
+func TestFromAPIDoNotGossip(t *testing.T) {
+	env := &dummyEnvironment{Global: global.NewDefault()}
+	q := New(env)
+
+	q.consume(Input{Cmd: CmdFromAPI, TxBytes: makeTxBytes(t)})
+	require.False(t, env.doNotGossip.Load())
+	require.EqualValues(t, 1, env.numGossiped.Load())
+
+	q.consume(Input{Cmd: CmdFromAPI, TxBytes: makeTxBytes(t), DoNotGossip: true})
+	require.True(t, env.doNotGossip.Load())
+	require.EqualValues(t, 1, env.numGossiped.Load())
+
+	env.Stop()
+	env.WaitAllWorkProcessesStop(time.Second)
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/lunfardo314/proxima/core/txmetadata"
 	"github.com/lunfardo314/proxima/core/vertex"
+	"github.com/lunfardo314/proxima/core/work_process/poker"
+	"github.com/lunfardo314/proxima/core/work_process/pruner"
 	"github.com/lunfardo314/proxima/core/work_process/tippool"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
@@ -15,10 +17,9 @@ import (
 	"github.com/lunfardo314/proxima/util"
 )
 
-// TODO revisit MaxDurationInTheFuture
-
+// MaxDurationInTheFuture is how far ahead of the local clock the transaction timestamp is tolerated
 func (w *Workflow) MaxDurationInTheFuture() time.Duration {
-	return 10 * ledger.SlotDuration()
+	return w.cfg.futureTolerance
 }
 
 func (w *Workflow) PokeMe(me, with *vertex.WrappedTx) {
@@ -29,6 +30,17 @@ func (w *Workflow) PokeAllWith(wanted *vertex.WrappedTx) {
 	w.poker.PokeAllWith(wanted)
 }
 
+// NumWaitingToBePoked returns number of transactions waiting in the poker for their dependencies
+func (w *Workflow) NumWaitingToBePoked() int {
+	return w.poker.NumWaiting()
+}
+
+// MostWantedByWaiting returns up to n transactions with the most transactions waiting for them in the poker.
+// Useful to see where attachment is bottlenecked
+func (w *Workflow) MostWantedByWaiting(n int) []poker.WantedInfo {
+	return w.poker.MostWanted(n)
+}
+
 func (w *Workflow) SendTxBytesWithMetadataToPeer(id peer.ID, txBytes []byte, metadata *txmetadata.TransactionMetadata) bool {
 	return w.peers.SendTxBytesWithMetadataToPeer(id, txBytes, metadata)
 }
@@ -60,6 +72,46 @@ func (w *Workflow) IsSynced() bool {
 	return slotNow == 0 || multistate.FirstHealthySlotIsNotBefore(w.StateStore(), slotNow-1, global.FractionHealthyBranch)
 }
 
+// PruneReport returns what the next memDAG pruning would do, without pruning
+func (w *Workflow) PruneReport() pruner.PruneStats {
+	return w.pruner.PruneReport()
+}
+
+// PinTransaction prevents the vertex of the transaction from being pruned from the memDAG until unpinned
+func (w *Workflow) PinTransaction(txid ledger.TransactionID) {
+	w.pruner.Pin(txid)
+}
+
+func (w *Workflow) UnpinTransaction(txid ledger.TransactionID) {
+	w.pruner.Unpin(txid)
+}
+
+// SyncStatusInfo is a snapshot of the sync status of the node
+type SyncStatusInfo struct {
+	Synced            bool
+	LatestSlot        ledger.Slot
+	LatestHealthySlot ledger.Slot
+	NowSlot           ledger.Slot
+	// SlotsBehind number of slots the latest committed slot is behind the current slot
+	SlotsBehind int
+}
+
+// SyncStatus returns sync status of the node. Node is synced if latest committed branches are not too far behind
+// and the latest healthy slot is not before the previous slot
+func (w *Workflow) SyncStatus() SyncStatusInfo {
+	latestSlot, latestHealthySlot, synced := w.LatestBranchSlots()
+	ret := SyncStatusInfo{
+		Synced:            synced && w.IsSynced(),
+		LatestSlot:        latestSlot,
+		LatestHealthySlot: latestHealthySlot,
+		NowSlot:           ledger.TimeNow().Slot(),
+	}
+	if ret.NowSlot > latestSlot {
+		ret.SlotsBehind = int(ret.NowSlot - latestSlot)
+	}
+	return ret
+}
+
 // LatestMilestonesDescending returns optionally filtered sorted transactions from the sequencer tippool
 func (w *Workflow) LatestMilestonesDescending(filter ...func(seqID ledger.ChainID, vid *vertex.WrappedTx) bool) []*vertex.WrappedTx {
 	return w.tippool.LatestMilestonesDescending(filter...)
@@ -78,6 +130,21 @@ func (w *Workflow) NumSequencerTips() int {
 	return w.tippool.NumSequencerTips()
 }
 
+// PauseServing stops gossiping and serving pull requests to peers while transaction processing continues.
+// Used for temporary maintenance
+func (w *Workflow) PauseServing() {
+	w.peers.PauseServing()
+}
+
+// ResumeServing resumes gossiping and serving pull requests
+func (w *Workflow) ResumeServing() {
+	w.peers.ResumeServing()
+}
+
+func (w *Workflow) IsServingPaused() bool {
+	return w.peers.IsServingPaused()
+}
+
 func (w *Workflow) PeerName(id peer.ID) string {
 	return w.peers.PeerName(id)
 }
@@ -115,6 +182,47 @@ func (w *Workflow) AddWantedTransaction(txid *ledger.TransactionID) {
 	w.txInputQueue.AddWantedTransaction(txid)
 }
 
+// StateRetentionHorizon returns the earliest slot, states of which are retained by the node.
+// Baseline branches older than the horizon cannot be solidified
+func (w *Workflow) StateRetentionHorizon() ledger.Slot {
+	ret := multistate.FetchEarliestSlot(w.StateStore())
+	if w.cfg.stateRetentionSlots > 0 {
+		latest := multistate.FetchLatestCommittedSlot(w.StateStore())
+		if int(latest) > w.cfg.stateRetentionSlots && latest-ledger.Slot(w.cfg.stateRetentionSlots) > ret {
+			ret = latest - ledger.Slot(w.cfg.stateRetentionSlots)
+		}
+	}
+	return ret
+}
+
+// MinBaselineCoverage returns the fraction of the best coverage in the slot required for the branch to be used
+// as baseline by the sequencer. Zero fraction means policy is disabled
+func (w *Workflow) MinBaselineCoverage() global.Fraction {
+	return w.cfg.minBaselineCoverage
+}
+
+// BestBranchCoverage returns the best ledger coverage among branches committed in the slot. The value is cached
+func (w *Workflow) BestBranchCoverage(slot ledger.Slot) uint64 {
+	return w.bestCoverage.get(w.StateStore(), slot)
+}
+
+// NumDeduplicatedInFlightTx returns number of repeated submissions of in-flight transactions which
+// did not start another attachment
+func (w *Workflow) NumDeduplicatedInFlightTx() int {
+	if w.inFlight == nil {
+		return 0
+	}
+	return int(w.inFlight.numDeduplicated.Load())
+}
+
+// NumInFlightTx returns number of transactions submitted to the workflow which are still being attached
+func (w *Workflow) NumInFlightTx() int {
+	if w.inFlight == nil {
+		return 0
+	}
+	return w.inFlight.numInFlight()
+}
+
 func (w *Workflow) EvidenceNonSequencerTx() {
 	w.txInputQueue.EvidenceNonSequencerTx()
 }
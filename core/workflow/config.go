@@ -1,18 +1,46 @@
 package workflow
 
-import "go.uber.org/zap"
+import (
+	"time"
+
+	"github.com/lunfardo314/proxima/global"
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/util"
+	"go.uber.org/zap"
+)
 
 type (
 	ConfigParams struct {
 		doNotStartPruner  bool
 		enableSyncManager bool
+		futureTolerance   time.Duration
+		// if true, decoded transaction is logged at debug level when rejected
+		logRejectedTxDetail bool
+		// number of latest committed slots the state of which is retained by the node. 0 means all stored states
+		stateRetentionSlots int
+		// maximum number of goroutines validating inputs of one transaction. 0 means sequential validation
+		parallelInputValidationWorkers int
+		// branches with coverage below the fraction of the best coverage in the slot are not accepted as baseline.
+		// Zero fraction means any valid branch is accepted
+		minBaselineCoverage global.Fraction
+		// if true, repeated submissions of the transaction being attached start another attachment
+		doNotDedupInFlightTx bool
+		// period of the memDAG pruner loop. 0 means slot duration
+		prunerInterval time.Duration
+		// if true, vertices count references by named holders (for debugging)
+		trackReferenceHolders bool
 	}
 
 	ConfigOption func(c *ConfigParams)
 )
 
+// defaultFutureToleranceSlots default tolerance of the timestamp ahead of the local clock, in slots
+const defaultFutureToleranceSlots = 10
+
 func defaultConfigParams() ConfigParams {
-	return ConfigParams{}
+	return ConfigParams{
+		futureTolerance: defaultFutureToleranceSlots * ledger.SlotDuration(),
+	}
 }
 
 // OptionDoNotStartPruner used for testing, to disable pruner
@@ -27,6 +55,87 @@ func OptionEnableSyncManager(c *ConfigParams) {
 	c.enableSyncManager = true
 }
 
+// OptionFutureTimestampTolerance transactions from API and peers with timestamp ahead of the local clock
+// more than the tolerance are rejected. Default is 10 slots
+// Config key: 'workflow.future_tolerance_sec'
+func OptionFutureTimestampTolerance(d time.Duration) ConfigOption {
+	return func(c *ConfigParams) {
+		if d > 0 {
+			c.futureTolerance = d
+		}
+	}
+}
+
+// OptionLogRejectedTxDetail enables logging of the decoded transaction (inputs, unlock data, outputs with constraints)
+// at debug level when transaction is rejected on input or sequencer milestone is marked BAD by the attacher.
+// Disabled by default to avoid log spam
+// Config key: 'workflow.log_rejected_tx_detail: true'
+func OptionLogRejectedTxDetail(c *ConfigParams) {
+	c.logRejectedTxDetail = true
+}
+
+// OptionStateRetentionSlots sets state retention horizon as number of slots back from the latest committed slot.
+// Transactions with baseline older than the horizon are rejected as referencing pruned state.
+// By default, the horizon is the earliest slot in the multi-state DB
+// Config key: 'workflow.state_retention_slots'
+func OptionStateRetentionSlots(slots int) ConfigOption {
+	return func(c *ConfigParams) {
+		if slots > 0 {
+			c.stateRetentionSlots = slots
+		}
+	}
+}
+
+// OptionParallelInputValidation enables validation of inputs (including signature unlocks) of wide transactions
+// by up to 'workers' goroutines. Transactions with less than transaction.ParallelInputValidationThreshold inputs are
+// validated sequentially. By default, validation is sequential
+// Config key: 'workflow.parallel_input_validation_workers'
+func OptionParallelInputValidation(workers int) ConfigOption {
+	return func(c *ConfigParams) {
+		if workers > 1 {
+			c.parallelInputValidationWorkers = workers
+		}
+	}
+}
+
+// OptionMinBaselineCoverage sequencer does not build on the branch if its ledger coverage is below
+// numerator/denominator of the coverage of the best branch in the same slot. Protects against building on low-coverage
+// spam branches. Milestones of other sequencers with such baseline are still attached.
+// By default, any valid branch is accepted
+// Config keys: 'workflow.min_baseline_coverage.numerator', 'workflow.min_baseline_coverage.denominator'
+func OptionMinBaselineCoverage(numerator, denominator int) ConfigOption {
+	return func(c *ConfigParams) {
+		if numerator > 0 && denominator > 0 && numerator <= denominator {
+			c.minBaselineCoverage = global.Fraction{Numerator: numerator, Denominator: denominator}
+		}
+	}
+}
+
+// OptionDoNotDedupInFlightTx disables deduplication of the transactions submitted while the same transaction is
+// still being attached. By default, repeated submission does not start another attachment and its callback
+// receives result of the first one
+// Config key: 'workflow.do_not_dedup_in_flight_tx: true'
+func OptionDoNotDedupInFlightTx(c *ConfigParams) {
+	c.doNotDedupInFlightTx = true
+}
+
+// OptionPrunerInterval sets period of the memDAG pruner loop. By default, it is slot duration.
+// The interval must be positive
+// Config key: 'workflow.pruner.interval', duration, for example '500ms'
+func OptionPrunerInterval(d time.Duration) ConfigOption {
+	util.Assertf(d > 0, "OptionPrunerInterval: pruner interval must be positive, got %v", d)
+	return func(c *ConfigParams) {
+		c.prunerInterval = d
+	}
+}
+
+// OptionTrackReferenceHolders enables counting of vertex references by named holders, such as attacher or tippool.
+// Holders are shown in the verbose memDAG info. Meant for debugging of the memDAG pruning, disabled by default
+// Config key: 'workflow.track_reference_holders: true'
+func OptionTrackReferenceHolders(c *ConfigParams) {
+	c.trackReferenceHolders = true
+}
+
 func (cfg *ConfigParams) log(log *zap.SugaredLogger) {
 	if cfg.doNotStartPruner {
 		log.Info("[workflow config] do not start pruner")
@@ -34,4 +143,26 @@ func (cfg *ConfigParams) log(log *zap.SugaredLogger) {
 	if cfg.enableSyncManager {
 		log.Info("[workflow config] start sync manager")
 	}
+	log.Infof("[workflow config] future timestamp tolerance: %v", cfg.futureTolerance)
+	if cfg.logRejectedTxDetail {
+		log.Info("[workflow config] log details of rejected txs")
+	}
+	if cfg.stateRetentionSlots > 0 {
+		log.Infof("[workflow config] state retention slots: %d", cfg.stateRetentionSlots)
+	}
+	if cfg.parallelInputValidationWorkers > 0 {
+		log.Infof("[workflow config] parallel input validation workers: %d", cfg.parallelInputValidationWorkers)
+	}
+	if cfg.doNotDedupInFlightTx {
+		log.Info("[workflow config] do not deduplicate in-flight transactions")
+	}
+	if cfg.prunerInterval > 0 {
+		log.Infof("[workflow config] pruner interval: %v", cfg.prunerInterval)
+	}
+	if cfg.minBaselineCoverage.Numerator > 0 {
+		log.Infof("[workflow config] minimum baseline coverage: %s", cfg.minBaselineCoverage.String())
+	}
+	if cfg.trackReferenceHolders {
+		log.Info("[workflow config] track vertex reference holders")
+	}
 }
@@ -0,0 +1,61 @@
+package workflow
+
+import (
+	"sync"
+
+	"github.com/lunfardo314/proxima/global"
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/multistate"
+)
+
+// bestCoverageCacheSlots maximum number of slots kept in the cache. The oldest slot is evicted first
+const bestCoverageCacheSlots = 16
+
+// bestCoverageCache keeps the best ledger coverage of committed branches per slot. The slot is read from
+// the state store on the first query, later it is updated with each new branch
+type bestCoverageCache struct {
+	mutex sync.Mutex
+	best  map[ledger.Slot]uint64
+}
+
+func newBestCoverageCache() *bestCoverageCache {
+	return &bestCoverageCache{
+		best: make(map[ledger.Slot]uint64),
+	}
+}
+
+func (c *bestCoverageCache) get(store global.StateStoreReader, slot ledger.Slot) uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if ret, found := c.best[slot]; found {
+		return ret
+	}
+	var ret uint64
+	for _, rr := range multistate.FetchRootRecords(store, slot) {
+		ret = max(ret, rr.LedgerCoverage)
+	}
+	c._put(slot, ret)
+	return ret
+}
+
+// update is called with each new branch. Slots which were not queried yet are not cached
+func (c *bestCoverageCache) update(slot ledger.Slot, coverage uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if prev, found := c.best[slot]; found && coverage > prev {
+		c.best[slot] = coverage
+	}
+}
+
+func (c *bestCoverageCache) _put(slot ledger.Slot, coverage uint64) {
+	if len(c.best) >= bestCoverageCacheSlots {
+		oldest := slot
+		for s := range c.best {
+			oldest = min(oldest, s)
+		}
+		delete(c.best, oldest)
+	}
+	c.best[slot] = coverage
+}
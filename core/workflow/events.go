@@ -5,6 +5,19 @@ import "github.com/lunfardo314/proxima/core/vertex"
 func (w *Workflow) PostEventNewGood(vid *vertex.WrappedTx) {
 	w.Tracef("events", "PostEventNewGood: %s", vid.IDShortString)
 	w.events.PostEvent(EventNewGoodTx, vid)
+	if vid.IsBranchTransaction() {
+		w.bestCoverage.update(vid.Slot(), vid.GetLedgerCoverage())
+		w.postEventNewBranch(vid)
+	}
+}
+
+func (w *Workflow) postEventNewBranch(vid *vertex.WrappedTx) {
+	w.Tracef("events", "postEventNewBranch: %s", vid.IDShortString)
+	w.events.PostEvent(EventNewBranch, BranchEventData{
+		VID:            vid,
+		LedgerCoverage: vid.GetLedgerCoverage(),
+		Slot:           vid.Slot(),
+	})
 }
 
 func (w *Workflow) PostEventNewTransaction(vid *vertex.WrappedTx) {
@@ -0,0 +1,80 @@
+package workflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lunfardo314/proxima/core/vertex"
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/peering"
+	"github.com/lunfardo314/proxima/util/set"
+)
+
+// maxTxSequenceFrameSize limit of the transaction size in the stream read by ReadTxSequence.
+// A transaction can't be bigger than the biggest message of the peering protocol
+const maxTxSequenceFrameSize = peering.MaxMessageBytesLimit
+
+// ExportTxSequence writes transactions of the past cones of tips to the writer in the topological order:
+// each transaction is written only after all transactions it depends on (inputs and endorsements).
+// Each transaction is framed with 4 bytes of big-endian length prefix.
+// Only full vertices are written, virtual transactions (e.g. already committed to the state) are skipped.
+// The order is deterministic for the same set of tips and the same MemDAG, so the stream can be used
+// for the reproducible replay of the transactions into another node. Returns number of transactions written
+func (w *Workflow) ExportTxSequence(tips []ledger.TransactionID, wr io.Writer) (int, error) {
+	visited := set.New[*vertex.WrappedTx]()
+	count := 0
+	var err error
+	for i := range tips {
+		vid := w.GetVertex(&tips[i])
+		if vid == nil {
+			return count, fmt.Errorf("ExportTxSequence: transaction %s is not in the memDAG", tips[i].StringShort())
+		}
+		vid.TraversePastConeDepthFirst(vertex.UnwrapOptionsForTraverse{
+			Vertex: func(_ *vertex.WrappedTx, v *vertex.Vertex) bool {
+				if err = writeTxFrame(wr, v.Tx.Bytes()); err != nil {
+					return false
+				}
+				count++
+				return true
+			},
+		}, visited)
+		if err != nil {
+			return count, fmt.Errorf("ExportTxSequence: %w", err)
+		}
+	}
+	return count, nil
+}
+
+// ReadTxSequence reads length-framed transaction bytes produced by ExportTxSequence and calls fun for each.
+// Stops and returns error if fun returns error or if frame size exceeds the maximum transaction size
+func ReadTxSequence(r io.Reader, fun func(txBytes []byte) error) error {
+	var size uint32
+	for {
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("ReadTxSequence: failed to read frame size prefix: %w", err)
+		}
+		if size > maxTxSequenceFrameSize {
+			return fmt.Errorf("ReadTxSequence: frame size %d exceeds maximum %d", size, maxTxSequenceFrameSize)
+		}
+		txBytes := make([]byte, size)
+		if _, err := io.ReadFull(r, txBytes); err != nil {
+			return fmt.Errorf("ReadTxSequence: failed to read frame body: %w", err)
+		}
+		if err := fun(txBytes); err != nil {
+			return err
+		}
+	}
+}
+
+func writeTxFrame(wr io.Writer, txBytes []byte) error {
+	if err := binary.Write(wr, binary.BigEndian, uint32(len(txBytes))); err != nil {
+		return err
+	}
+	_, err := wr.Write(txBytes)
+	return err
+}
@@ -0,0 +1,86 @@
+package workflow
+
+import (
+	"sync"
+
+	"github.com/lunfardo314/proxima/core/vertex"
+	"github.com/lunfardo314/proxima/ledger"
+	"go.uber.org/atomic"
+)
+
+// inFlightTxs keeps track of transactions submitted to the workflow which are still being attached.
+// Repeated submissions of the in-flight transaction do not start another attachment, instead
+// they return the result of the first submission and their callbacks are called with the result of the first attachment
+type inFlightTxs struct {
+	mutex           sync.Mutex
+	txs             map[ledger.TransactionID]*inFlightTx
+	numDeduplicated atomic.Int64
+}
+
+type inFlightTx struct {
+	// closed when the first submission returns. err is the result of it
+	submitted chan struct{}
+	err       error
+	waiting   []func(vid *vertex.WrappedTx, err error)
+}
+
+func newInFlightTxs() *inFlightTxs {
+	return &inFlightTxs{
+		txs: make(map[ledger.TransactionID]*inFlightTx),
+	}
+}
+
+// register returns true if transaction is not in flight and makes it in flight.
+// Otherwise, callback (if not nil) is registered to be called when the in-flight transaction is finished.
+// The returned in-flight entry is used to pass result of the first submission to the repeated ones
+func (f *inFlightTxs) register(txid ledger.TransactionID, callback func(vid *vertex.WrappedTx, err error)) (*inFlightTx, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	ret, inFlight := f.txs[txid]
+	if !inFlight {
+		ret = &inFlightTx{submitted: make(chan struct{})}
+		f.txs[txid] = ret
+		return ret, true
+	}
+	if callback != nil {
+		ret.waiting = append(ret.waiting, callback)
+	}
+	f.numDeduplicated.Inc()
+	return ret, false
+}
+
+// setSubmitted records result of the first submission and releases repeated submissions waiting for it
+func (e *inFlightTx) setSubmitted(err error) {
+	e.err = err
+	close(e.submitted)
+}
+
+// result waits for the first submission to return and returns its result
+func (e *inFlightTx) result() error {
+	<-e.submitted
+	return e.err
+}
+
+// finish removes transaction from in-flight and calls callbacks of the repeated submissions
+func (f *inFlightTxs) finish(txid ledger.TransactionID, vid *vertex.WrappedTx, err error) {
+	f.mutex.Lock()
+	var waiting []func(vid *vertex.WrappedTx, err error)
+	if e := f.txs[txid]; e != nil {
+		waiting = e.waiting
+	}
+	delete(f.txs, txid)
+	f.mutex.Unlock()
+
+	for _, callback := range waiting {
+		callback(vid, err)
+	}
+}
+
+// numInFlight returns number of transactions in flight
+func (f *inFlightTxs) numInFlight() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return len(f.txs)
+}
@@ -27,6 +27,11 @@ func (w *Workflow) ListenToAccount(account ledger.Accountable, fun func(wOut ver
 	})
 }
 
+// ListenToBranches calls fun for each new good branch. The same branch may be reported more than once
+func (w *Workflow) ListenToBranches(fun func(branch BranchEventData)) {
+	w.events.OnEvent(EventNewBranch, fun)
+}
+
 func (w *Workflow) ListenToSequencers(fun func(vid *vertex.WrappedTx)) {
 	w.events.OnEvent(EventNewGoodTx, func(vid *vertex.WrappedTx) {
 		fun(vid)
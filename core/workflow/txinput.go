@@ -23,6 +23,7 @@ type (
 		callback         func(vid *vertex.WrappedTx, err error)
 		txTrace          bool
 		ctx              context.Context
+		doNotGossip      bool
 	}
 
 	TxInOption func(options *txInOptions)
@@ -63,20 +64,56 @@ func (w *Workflow) TxBytesIn(txBytes []byte, opts ...TxInOption) (*ledger.Transa
 	return tx.ID(), w.TxIn(tx, opts...)
 }
 
-func (w *Workflow) TxInFromAPI(tx *transaction.Transaction, trace bool) error {
-	return w.TxIn(tx,
+func (w *Workflow) TxInFromAPI(tx *transaction.Transaction, trace, doNotGossip bool) error {
+	opts := []TxInOption{
 		WithSourceType(txmetadata.SourceTypeAPI),
 		WithTxTraceFlag(trace),
-	)
+	}
+	if doNotGossip {
+		opts = append(opts, WithDoNotGossip())
+	}
+	return w.TxIn(tx, opts...)
 }
 
-func (w *Workflow) TxBytesInFromAPIQueued(txBytes []byte, trace bool) {
-	w.txInputQueue.Push(txinput_queue.Input{
-		Cmd:        txinput_queue.CmdFromAPI,
-		TxBytes:    txBytes,
-		TraceFlag:  trace,
-		TxMetaData: &txmetadata.TransactionMetadata{TxBytesReceived: util.Ref(time.Now())},
-	})
+// TxBytesInFromAPIQueued pushes transaction to the input queue. If doNotGossip is true,
+// transaction is appended locally without gossiping it to peers
+func (w *Workflow) TxBytesInFromAPIQueued(txBytes []byte, trace bool, doNotGossip ...bool) {
+	w.txInputQueue.Push(makeAPIQueueInput(txBytes, trace, doNotGossip...))
+}
+
+// TryTxBytesInFromAPIQueued same as TxBytesInFromAPIQueued, except it returns false and does not
+// push the transaction if the input queue is at capacity
+func (w *Workflow) TryTxBytesInFromAPIQueued(txBytes []byte, trace bool, doNotGossip ...bool) bool {
+	return w.txInputQueue.TryPush(makeAPIQueueInput(txBytes, trace, doNotGossip...))
+}
+
+func makeAPIQueueInput(txBytes []byte, trace bool, doNotGossip ...bool) txinput_queue.Input {
+	return txinput_queue.Input{
+		Cmd:         txinput_queue.CmdFromAPI,
+		TxBytes:     txBytes,
+		TraceFlag:   trace,
+		TxMetaData:  &txmetadata.TransactionMetadata{TxBytesReceived: util.Ref(time.Now())},
+		DoNotGossip: len(doNotGossip) > 0 && doNotGossip[0],
+	}
+}
+
+// TransactionsIn parses each transaction in the list once and submits parsed ones with the options, like TxIn.
+// Returns parsed transactions and errors, both indexed the same way as the input list. The error is either
+// the parse error (then the transaction is nil) or the error returned by TxIn.
+// Attachment of transactions is asynchronous, as with TxIn
+func (w *Workflow) TransactionsIn(txBytesList [][]byte, opts ...TxInOption) ([]*transaction.Transaction, []error) {
+	txs := make([]*transaction.Transaction, len(txBytesList))
+	errs := make([]error, len(txBytesList))
+	for i, txBytes := range txBytesList {
+		tx, err := transaction.FromBytes(txBytes)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		txs[i] = tx
+		errs[i] = w.TxIn(tx, opts...)
+	}
+	return txs, errs
 }
 
 func (w *Workflow) TxBytesInFromPeerQueued(txBytes []byte, metaData *txmetadata.TransactionMetadata, from peer.ID) {
@@ -101,6 +138,38 @@ func (w *Workflow) TxIn(tx *transaction.Transaction, opts ...TxInOption) error {
 	for _, opt := range opts {
 		opt(options)
 	}
+	if w.inFlight == nil {
+		return w.txIn(tx, options)
+	}
+	// deduplication of in-flight transactions
+	txid := *tx.ID()
+	isSeqMilestone := tx.IsSequencerMilestone()
+	var callback func(vid *vertex.WrappedTx, err error)
+	if isSeqMilestone {
+		callback = options.callback
+	}
+	inFlight, first := w.inFlight.register(txid, callback)
+	if !first {
+		w.Tracef(TraceTagTxInput, "%s is in flight. Repeated submission won't be attached", txid.StringShort)
+		return inFlight.result()
+	}
+	if isSeqMilestone {
+		options.callback = func(vid *vertex.WrappedTx, err error) {
+			if callback != nil {
+				callback(vid, err)
+			}
+			w.inFlight.finish(txid, vid, err)
+		}
+	}
+	err := w.txIn(tx, options)
+	inFlight.setSubmitted(err)
+	if err != nil || !isSeqMilestone {
+		w.inFlight.finish(txid, w.GetVertex(&txid), err)
+	}
+	return err
+}
+
+func (w *Workflow) txIn(tx *transaction.Transaction, options *txInOptions) error {
 	// base validation
 	txid := tx.ID()
 
@@ -117,6 +186,19 @@ func (w *Workflow) TxIn(tx *transaction.Transaction, opts ...TxInOption) error {
 	if !tx.IsSequencerMilestone() {
 		// callback is only possible when tx is sequencer milestone
 		options.callback = func(_ *vertex.WrappedTx, _ error) {}
+	} else if w.cfg.logRejectedTxDetail {
+		// milestone can also be rejected by the attacher
+		options.callback = w.logRejectedByAttacher(tx, options.callback)
+	}
+
+	// claimed branch transaction with non-zero tick is dropped before any other checks
+	if err := tx.Validate(transaction.CheckBranchTimestampTick()); err != nil {
+		err = fmt.Errorf("invalid branch transaction %s: '%w'", txid.StringShort(), err)
+		w.Tracef(TraceTagTxInput, "%v", err)
+		w.TraceTx(txid, "TxBytesIn: %v", err)
+		attacher.InvalidateTxID(*txid, w, err)
+		w.logRejectedTx(tx, err)
+		return err
 	}
 
 	// check time bounds
@@ -131,8 +213,9 @@ func (w *Workflow) TxIn(tx *transaction.Transaction, opts ...TxInOption) error {
 	if err != nil {
 		if enforceTimeBounds {
 			w.Tracef(TraceTagTxInput, "invalidate %s: time bounds validation failed", txid.StringShort)
-			err = fmt.Errorf("%w (MaxDurationInTheFuture = %v)", err, w.MaxDurationInTheFuture())
+			err = fmt.Errorf("timestamp too far in future: %w (tolerance = %v)", err, w.MaxDurationInTheFuture())
 			attacher.InvalidateTxID(*txid, w, err)
+			w.logRejectedTx(tx, err)
 
 			return err
 		}
@@ -146,6 +229,7 @@ func (w *Workflow) TxIn(tx *transaction.Transaction, opts ...TxInOption) error {
 		w.Tracef(TraceTagTxInput, "%v", err)
 		w.TraceTx(txid, "TxBytesIn: %v", err)
 		attacher.InvalidateTxID(*txid, w, err)
+		w.logRejectedTx(tx, err)
 		return err
 	}
 
@@ -168,6 +252,12 @@ func (w *Workflow) TxIn(tx *transaction.Transaction, opts ...TxInOption) error {
 	if options.callback != nil {
 		attachOpts = append(attachOpts, attacher.WithAttachmentCallback(options.callback))
 	}
+	if options.doNotGossip {
+		attachOpts = append(attachOpts, attacher.WithDoNotGossip)
+	}
+	if options.receivedFromPeer != nil {
+		attachOpts = append(attachOpts, attacher.WithPullFromPeer(*options.receivedFromPeer))
+	}
 
 	if time.Until(txTime) <= 0 {
 		// timestamp is in the past -> attach immediately
@@ -205,6 +295,27 @@ func (w *Workflow) _attach(tx *transaction.Transaction, opts ...attacher.AttachT
 	}
 }
 
+// logRejectedTx logs decoded transaction at debug level, if enabled by config
+func (w *Workflow) logRejectedTx(tx *transaction.Transaction, reason error) {
+	if !w.cfg.logRejectedTxDetail {
+		return
+	}
+	w.Log().Debugf("rejected transaction %s: '%v'\n%s", tx.IDShortString(), reason, tx.LinesShort("    ").String())
+}
+
+// logRejectedByAttacher wraps attachment callback of the sequencer milestone. Milestone marked BAD by the attacher
+// is logged the same way as the transaction rejected on input
+func (w *Workflow) logRejectedByAttacher(tx *transaction.Transaction, callback func(vid *vertex.WrappedTx, err error)) func(vid *vertex.WrappedTx, err error) {
+	return func(vid *vertex.WrappedTx, err error) {
+		if err != nil && vid != nil && vid.GetTxStatus() == vertex.Bad {
+			w.logRejectedTx(tx, err)
+		}
+		if callback != nil {
+			callback(vid, err)
+		}
+	}
+}
+
 // SequencerMilestoneAttachWait attaches sequencer transaction synchronously.
 // Waits up to timeout until attacher finishes
 func (w *Workflow) SequencerMilestoneAttachWait(txBytes []byte, meta *txmetadata.TransactionMetadata, timeout time.Duration) (*vertex.WrappedTx, error) {
@@ -254,6 +365,46 @@ func (w *Workflow) SequencerMilestoneAttachWait(txBytes []byte, meta *txmetadata
 	return vid, nil
 }
 
+// TxBytesInWaitStatus submits transaction and waits up to timeout until it reaches terminal status: Good or Bad.
+// Returns Undefined with nil error if timeout fires before that.
+// Returns Bad with error if transaction is rejected by pre-validation or by the attacher.
+// Returns Undefined with error if bytes cannot be parsed as transaction.
+// The status is delivered by the status change notification of the vertex. The timeout only limits waiting:
+// attachment of the transaction continues after it fires.
+// If the transaction is already in the memDAG with terminal status, the status is returned immediately
+func (w *Workflow) TxBytesInWaitStatus(txBytes []byte, timeout time.Duration, opts ...TxInOption) (vertex.Status, error) {
+	tx, err := transaction.FromBytes(txBytes)
+	if err != nil {
+		return vertex.Undefined, err
+	}
+	// vertex is put on the memDAG before submitting, so that the notification cannot be missed
+	vid := attacher.AttachTxID(*tx.ID(), w, attacher.WithInvokedBy("TxBytesInWaitStatus"))
+	statusCh := make(chan vertex.Status, 1)
+	vid.OnStatusDefined(func(status vertex.Status) {
+		statusCh <- status
+	})
+	select {
+	case status := <-statusCh:
+		return status, vid.GetError()
+	default:
+	}
+
+	if err = w.TxIn(tx, opts...); err != nil {
+		return vertex.Bad, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case status := <-statusCh:
+		return status, vid.GetError()
+	case <-timer.C:
+		return vertex.Undefined, nil
+	case <-w.Ctx().Done():
+		return vertex.Undefined, nil
+	}
+}
+
 func WithAttachmentCallback(fun func(vid *vertex.WrappedTx, err error)) TxInOption {
 	return func(opts *txInOptions) {
 		opts.callback = fun
@@ -289,6 +440,13 @@ func WithTxTraceFlag(trace bool) TxInOption {
 	}
 }
 
+// WithDoNotGossip transaction is appended locally without gossiping it to peers
+func WithDoNotGossip() TxInOption {
+	return func(opts *txInOptions) {
+		opts.doNotGossip = true
+	}
+}
+
 func WithContext(ctx context.Context) TxInOption {
 	return func(opts *txInOptions) {
 		opts.ctx = ctx
@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/lunfardo314/proxima/core/memdag"
@@ -16,6 +17,8 @@ import (
 	"github.com/lunfardo314/proxima/core/work_process/txinput_queue"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/ledger/transaction"
+	"github.com/lunfardo314/proxima/multistate"
 	"github.com/lunfardo314/proxima/peering"
 	"github.com/lunfardo314/proxima/util/eventtype"
 	"github.com/lunfardo314/proxima/util/set"
@@ -29,6 +32,7 @@ type (
 		StateStore() global.StateStore
 		TxBytesStore() global.TxBytesStore
 		PullFromNPeers(nPeers int, txid *ledger.TransactionID) int
+		PullFromPeer(id peer.ID, txid *ledger.TransactionID, failedPulls, nPeersFallback int) (int, bool)
 		GetOwnSequencerID() *ledger.ChainID
 	}
 	Workflow struct {
@@ -43,16 +47,26 @@ type (
 		txInputQueue *txinput_queue.TxInputQueue
 		tippool      *tippool.SequencerTips
 		pruner       *pruner.Pruner
+		inFlight     *inFlightTxs // nil if deduplication of in-flight transactions is disabled
+		bestCoverage *bestCoverageCache
 		//
 		enableTrace    atomic.Bool
 		traceTagsMutex sync.RWMutex
 		traceTags      set.Set[string]
 	}
+
+	// BranchEventData is the argument of the EventNewBranch
+	BranchEventData struct {
+		VID            *vertex.WrappedTx
+		LedgerCoverage uint64
+		Slot           ledger.Slot
+	}
 )
 
 var (
 	EventNewGoodTx = eventtype.RegisterNew[*vertex.WrappedTx]("new good seq")
-	EventNewTx     = eventtype.RegisterNew[*vertex.WrappedTx]("new tx") // event may be posted more than once for the transaction
+	EventNewTx     = eventtype.RegisterNew[*vertex.WrappedTx]("new tx")   // event may be posted more than once for the transaction
+	EventNewBranch = eventtype.RegisterNew[BranchEventData]("new branch") // posted together with EventNewGoodTx for branches
 )
 
 func Start(env Environment, peers *peering.Peers, opts ...ConfigOption) *Workflow {
@@ -61,26 +75,38 @@ func Start(env Environment, peers *peering.Peers, opts ...ConfigOption) *Workflo
 		opt(&cfg)
 	}
 	cfg.log(env.Log())
+	if cfg.parallelInputValidationWorkers > 0 {
+		transaction.SetParallelInputValidation(cfg.parallelInputValidationWorkers)
+	}
+	vertex.TrackReferenceHolders(cfg.trackReferenceHolders)
 
 	ret := &Workflow{
-		Environment: env,
-		MemDAG:      memdag.New(env),
-		cfg:         &cfg,
-		peers:       peers,
-		traceTags:   set.New[string](),
+		Environment:  env,
+		MemDAG:       memdag.New(env),
+		cfg:          &cfg,
+		peers:        peers,
+		traceTags:    set.New[string](),
+		bestCoverage: newBestCoverageCache(),
+	}
+	if !cfg.doNotDedupInFlightTx {
+		ret.inFlight = newInFlightTxs()
 	}
 	ret.poker = poker.New(ret)
 	ret.events = events.New(ret)
 	ret.pullTxServer = pull_tx_server.New(ret)
 	ret.tippool = tippool.New(ret)
 	ret.txInputQueue = txinput_queue.New(ret)
-	ret.pruner = pruner.New(ret)
+	ret.pruner = pruner.New(ret, cfg.prunerInterval)
 	snapshot.Start(ret)
 
 	ret.peers.OnReceiveTxBytes(func(from peer.ID, txBytes []byte, metadata *txmetadata.TransactionMetadata) {
 		ret.TxBytesInFromPeerQueued(txBytes, metadata, from)
 	})
 
+	ret.peers.SetSyncStatusSource(func() (ledger.Slot, bool) {
+		return multistate.FetchLatestCommittedSlot(ret.StateStore()), ret.IsSynced()
+	})
+
 	ret.peers.OnReceivePullTxRequest(func(from peer.ID, txid ledger.TransactionID) {
 		ret.pullTxServer.Push(&pull_tx_server.Input{
 			TxID:   txid,
@@ -99,5 +125,33 @@ func StartFromConfig(env Environment, peers *peering.Peers) *Workflow {
 	if viper.GetBool("workflow.sync_manager.enable") {
 		opts = append(opts, OptionEnableSyncManager)
 	}
+	if sec := viper.GetInt("workflow.future_tolerance_sec"); sec > 0 {
+		opts = append(opts, OptionFutureTimestampTolerance(time.Duration(sec)*time.Second))
+	}
+	if viper.GetBool("workflow.do_not_dedup_in_flight_tx") {
+		opts = append(opts, OptionDoNotDedupInFlightTx)
+	}
+	if viper.GetBool("workflow.log_rejected_tx_detail") {
+		opts = append(opts, OptionLogRejectedTxDetail)
+	}
+	if viper.GetBool("workflow.track_reference_holders") {
+		opts = append(opts, OptionTrackReferenceHolders)
+	}
+	if slots := viper.GetInt("workflow.state_retention_slots"); slots > 0 {
+		opts = append(opts, OptionStateRetentionSlots(slots))
+	}
+	if workers := viper.GetInt("workflow.parallel_input_validation_workers"); workers > 1 {
+		opts = append(opts, OptionParallelInputValidation(workers))
+	}
+	if numerator := viper.GetInt("workflow.min_baseline_coverage.numerator"); numerator > 0 {
+		opts = append(opts, OptionMinBaselineCoverage(numerator, viper.GetInt("workflow.min_baseline_coverage.denominator")))
+	}
+	if viper.IsSet("workflow.pruner.interval") {
+		d := viper.GetDuration("workflow.pruner.interval")
+		if d <= 0 {
+			env.Log().Fatalf("[workflow config] wrong 'workflow.pruner.interval' = %v, must be positive duration", d)
+		}
+		opts = append(opts, OptionPrunerInterval(d))
+	}
 	return Start(env, peers, opts...)
 }
@@ -1,14 +1,30 @@
 package workflow
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/lunfardo314/proxima/core/txmetadata"
+	"github.com/lunfardo314/proxima/core/vertex"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/ledger/transaction"
+	"github.com/lunfardo314/proxima/ledger/txbuilder"
+	"github.com/lunfardo314/proxima/multistate"
 	"github.com/lunfardo314/proxima/peering"
 	"github.com/lunfardo314/proxima/txstore"
+	"github.com/lunfardo314/proxima/util"
 	"github.com/lunfardo314/unitrie/common"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func init() {
@@ -37,6 +53,10 @@ func (d *workflowDummyEnvironment) PullFromNPeers(nPeers int, txid *ledger.Trans
 	panic("not implemented")
 }
 
+func (d *workflowDummyEnvironment) PullFromPeer(_ peer.ID, _ *ledger.TransactionID, _, _ int) (int, bool) {
+	panic("not implemented")
+}
+
 func (d *workflowDummyEnvironment) GetOwnSequencerID() *ledger.ChainID {
 	panic("not implemented")
 }
@@ -64,3 +84,285 @@ func TestBasic(t *testing.T) {
 	env.Stop()
 	env.WaitAllWorkProcessesStop()
 }
+
+// makeFarFutureTx makes transaction which is rejected on input because its timestamp is too far in the future.
+// Input does not need to exist
+func makeFarFutureTx(t *testing.T) *transaction.Transaction {
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	addr := ledger.AddressED25519FromPrivateKey(privKey)
+
+	inTxID := ledger.RandomTransactionID(false)
+	in := &ledger.OutputWithID{
+		ID: ledger.NewOutputID(&inTxID, 0),
+		Output: ledger.NewOutput(func(o *ledger.Output) {
+			o.WithAmount(1_000_000).WithLock(addr)
+		}),
+	}
+	ts := ledger.TimeFromClockTime(time.Now().Add(time.Hour))
+	td := txbuilder.NewTransferData(privKey, addr, ts).
+		WithAmount(in.Output.Amount()).
+		WithTargetLock(addr).
+		MustWithInputs(in)
+	txBytes, err := txbuilder.MakeSimpleTransferTransaction(td)
+	require.NoError(t, err)
+	tx, err := transaction.FromBytes(txBytes)
+	require.NoError(t, err)
+	return tx
+}
+
+func TestLogRejectedTxDetail(t *testing.T) {
+	run := func(opts ...ConfigOption) *observer.ObservedLogs {
+		env := newWorkflowDummyEnvironment()
+		core, logs := observer.New(zapcore.DebugLevel)
+		env.SugaredLogger = zap.New(core).Sugar()
+
+		w := Start(env, peering.NewPeersDummy(), append(opts, OptionDoNotStartPruner)...)
+		err := w.TxIn(makeFarFutureTx(t), WithSourceType(txmetadata.SourceTypeAPI))
+		util.RequireErrorWith(t, err, "timestamp too far in future")
+
+		env.Stop()
+		env.WaitAllWorkProcessesStop()
+		return logs
+	}
+	const msg = "rejected transaction"
+
+	logs := run()
+	require.EqualValues(t, 0, logs.FilterMessageSnippet(msg).Len())
+
+	logs = run(OptionLogRejectedTxDetail)
+	detailed := logs.FilterMessageSnippet(msg).All()
+	require.EqualValues(t, 1, len(detailed))
+	require.EqualValues(t, zapcore.DebugLevel, detailed[0].Level)
+	require.Contains(t, detailed[0].Message, "Inputs (1):")
+	require.Contains(t, detailed[0].Message, "Outputs (1):")
+}
+
+func TestLogRejectedByAttacher(t *testing.T) {
+	env := newWorkflowDummyEnvironment()
+	core, logs := observer.New(zapcore.DebugLevel)
+	env.SugaredLogger = zap.New(core).Sugar()
+	w := Start(env, peering.NewPeersDummy(), OptionDoNotStartPruner, OptionLogRejectedTxDetail)
+
+	tx := makeFarFutureTx(t)
+	numCalled := 0
+	callback := w.logRejectedByAttacher(tx, func(_ *vertex.WrappedTx, _ error) {
+		numCalled++
+	})
+	const msg = "rejected transaction"
+
+	vid := vertex.WrapTxID(*tx.ID())
+	callback(vid, nil)
+	require.EqualValues(t, 1, numCalled)
+	require.EqualValues(t, 0, logs.FilterMessageSnippet(msg).Len())
+
+	errBad := fmt.Errorf("bad milestone")
+	vid.SetTxStatusBad(errBad)
+	callback(vid, errBad)
+	require.EqualValues(t, 2, numCalled)
+	detailed := logs.FilterMessageSnippet(msg).All()
+	require.EqualValues(t, 1, len(detailed))
+	require.Contains(t, detailed[0].Message, "bad milestone")
+	require.Contains(t, detailed[0].Message, "Outputs (1):")
+
+	env.Stop()
+	env.WaitAllWorkProcessesStop()
+}
+
+func TestTxBytesInWaitStatus(t *testing.T) {
+	env := newWorkflowDummyEnvironment()
+	w := Start(env, peering.NewPeersDummy(), OptionDoNotStartPruner)
+
+	status, err := w.TxBytesInWaitStatus([]byte("dummy data"), time.Second)
+	require.Error(t, err)
+	require.EqualValues(t, vertex.Undefined, status)
+
+	status, err = w.TxBytesInWaitStatus(makeFarFutureTx(t).Bytes(), time.Second, WithSourceType(txmetadata.SourceTypeAPI))
+	util.RequireErrorWith(t, err, "timestamp too far in future")
+	require.EqualValues(t, vertex.Bad, status)
+
+	env.Stop()
+	env.WaitAllWorkProcessesStop()
+}
+
+func TestTransactionsIn(t *testing.T) {
+	env := newWorkflowDummyEnvironment()
+	w := Start(env, peering.NewPeersDummy(), OptionDoNotStartPruner)
+
+	tx := makeFarFutureTx(t)
+	txs, errs := w.TransactionsIn([][]byte{nil, tx.Bytes(), []byte("dummy data")}, WithSourceType(txmetadata.SourceTypeAPI))
+	require.EqualValues(t, 3, len(txs))
+	require.EqualValues(t, 3, len(errs))
+
+	require.Nil(t, txs[0])
+	require.Error(t, errs[0])
+	// parsed transaction is returned together with the error from TxIn with options
+	require.EqualValues(t, *tx.ID(), *txs[1].ID())
+	util.RequireErrorWith(t, errs[1], "timestamp too far in future")
+	require.Nil(t, txs[2])
+	require.Error(t, errs[2])
+
+	env.Stop()
+	env.WaitAllWorkProcessesStop()
+}
+
+func TestListenToBranches(t *testing.T) {
+	env := newWorkflowDummyEnvironment()
+	w := Start(env, peering.NewPeersDummy(), OptionDoNotStartPruner)
+
+	branchCh := make(chan BranchEventData, 2)
+	w.ListenToBranches(func(branch BranchEventData) {
+		branchCh <- branch
+	})
+	seqTxID := ledger.NewTransactionID(ledger.NewLedgerTime(5, 10), ledger.TransactionIDShort{}, true)
+	branchTxID := ledger.NewTransactionID(ledger.NewLedgerTime(6, 0), ledger.TransactionIDShort{}, true)
+	require.False(t, seqTxID.IsBranchTransaction())
+	require.True(t, branchTxID.IsBranchTransaction())
+
+	branchVID := vertex.WrapTxID(branchTxID)
+	branchVID.SetLedgerCoverage(1337)
+	w.PostEventNewGood(vertex.WrapTxID(seqTxID))
+	w.PostEventNewGood(branchVID)
+
+	select {
+	case branch := <-branchCh:
+		require.True(t, branch.VID == branchVID)
+		require.EqualValues(t, 1337, branch.LedgerCoverage)
+		require.EqualValues(t, 6, branch.Slot)
+	case <-time.After(time.Second):
+		t.Fatalf("branch event not received")
+	}
+	require.EqualValues(t, 0, len(branchCh))
+
+	env.Stop()
+	env.WaitAllWorkProcessesStop()
+}
+
+func TestWantedTransactions(t *testing.T) {
+	env := newWorkflowDummyEnvironment()
+	w := Start(env, peering.NewPeersDummy(), OptionDoNotStartPruner)
+
+	vids := make([]*vertex.WrappedTx, 3)
+	w.WithGlobalWriteLock(func() {
+		for i := range vids {
+			vids[i] = vertex.WrapTxID(ledger.RandomTransactionID(false))
+			w.AddVertexNoLock(vids[i])
+		}
+	})
+	require.EqualValues(t, 0, len(w.WantedTransactions()))
+
+	vids[0].UnwrapVirtualTx(func(v *vertex.VirtualTransaction) {
+		v.SetPullNeeded()
+	})
+	vids[1].UnwrapVirtualTx(func(v *vertex.VirtualTransaction) {
+		v.SetPullNeeded()
+		v.SetPullHappened(1, time.Minute)
+	})
+	vids[2].UnwrapVirtualTx(func(v *vertex.VirtualTransaction) {
+		v.SetPullNotNeeded()
+	})
+	wanted := w.WantedTransactions()
+	require.EqualValues(t, 2, len(wanted))
+	require.Contains(t, wanted, vids[0].ID)
+	require.Contains(t, wanted, vids[1].ID)
+	require.True(t, ledger.LessTxID(wanted[0], wanted[1]))
+
+	env.Stop()
+	env.WaitAllWorkProcessesStop()
+}
+
+func TestOptionPrunerInterval(t *testing.T) {
+	cfg := defaultConfigParams()
+	require.EqualValues(t, 0, cfg.prunerInterval)
+
+	require.Panics(t, func() {
+		OptionPrunerInterval(-time.Second)
+	})
+	require.Panics(t, func() {
+		OptionPrunerInterval(0)
+	})
+	require.EqualValues(t, 0, cfg.prunerInterval)
+
+	// intervals below one second are allowed
+	OptionPrunerInterval(200 * time.Millisecond)(&cfg)
+	require.EqualValues(t, 200*time.Millisecond, cfg.prunerInterval)
+}
+
+func TestReadTxSequenceFrameSize(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeTxFrame(&buf, []byte{1, 2, 3}))
+	require.NoError(t, binary.Write(&buf, binary.BigEndian, uint32(maxTxSequenceFrameSize+1)))
+
+	frames := 0
+	err := ReadTxSequence(&buf, func(txBytes []byte) error {
+		require.EqualValues(t, []byte{1, 2, 3}, txBytes)
+		frames++
+		return nil
+	})
+	util.RequireErrorWith(t, err, "exceeds maximum")
+	require.EqualValues(t, 1, frames)
+}
+
+func TestPinTransaction(t *testing.T) {
+	env := newWorkflowDummyEnvironment()
+	w := Start(env, peering.NewPeersDummy(), OptionDoNotStartPruner)
+
+	txid := ledger.RandomTransactionID(false)
+	vid := vertex.WrapTxID(txid)
+	vid.SetTxStatusBad(fmt.Errorf("bad"))
+	w.WithGlobalWriteLock(func() {
+		w.AddVertexNoLock(vid)
+	})
+	isCandidate := func() bool {
+		for _, id := range w.PruneReport().MarkedForDeletion {
+			if id == txid {
+				return true
+			}
+		}
+		return false
+	}
+	require.True(t, isCandidate())
+
+	w.PinTransaction(txid)
+	require.False(t, isCandidate())
+
+	w.UnpinTransaction(txid)
+	require.True(t, isCandidate())
+
+	env.Stop()
+	env.WaitAllWorkProcessesStop()
+}
+
+func TestBestBranchCoverage(t *testing.T) {
+	store := common.NewInMemoryKVStore()
+	_, genesisRoot := multistate.InitStateStore(*ledger.L().ID, store)
+	const slot = ledger.Slot(10)
+	writeBranch := func(coverage uint64) {
+		branchID := ledger.RandomTransactionID(true)
+		branchID = ledger.NewTransactionID(ledger.NewLedgerTime(slot, 0), branchID.ShortID(), true)
+		batch := store.BatchedWriter()
+		multistate.WriteRootRecord(batch, branchID, multistate.RootRecord{Root: genesisRoot, LedgerCoverage: coverage})
+		require.NoError(t, batch.Commit())
+	}
+	writeBranch(1_000)
+	writeBranch(5_000)
+
+	c := newBestCoverageCache()
+	require.EqualValues(t, 5_000, c.get(store, slot))
+	require.EqualValues(t, 0, c.get(store, slot+1))
+
+	// cached value is not read from the store again, it is updated with new branches
+	writeBranch(7_000)
+	require.EqualValues(t, 5_000, c.get(store, slot))
+	c.update(slot, 7_000)
+	c.update(slot, 2_000)
+	require.EqualValues(t, 7_000, c.get(store, slot))
+
+	// oldest slots are evicted
+	for s := slot + 2; s < slot+2+bestCoverageCacheSlots; s++ {
+		c.get(store, s)
+	}
+	require.EqualValues(t, bestCoverageCacheSlots, len(c.best))
+	_, found := c.best[slot]
+	require.False(t, found)
+}
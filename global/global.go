@@ -61,6 +61,8 @@ const (
 	PullRepeatPeriodDefault = 2 * time.Second
 	PullMaxAttemptsDefault  = 60
 	PullFromNumPeersDefault = 2
+	// PullFromPeerMaxFailures number of failed pulls from the specific peer before pull is escalated to other peers
+	PullFromPeerMaxFailures = 3
 )
 
 const TraceTag = "global"
@@ -2,6 +2,7 @@ package global
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/lunfardo314/proxima/ledger"
@@ -9,13 +10,40 @@ import (
 	"github.com/lunfardo314/proxima/util/lines"
 )
 
-type NodeInfo struct {
-	ID              peer.ID         `json:"id"`
-	Version         string          `json:"version"`
-	NumStaticAlive  uint16          `json:"num_static_peers"`
-	NumDynamicAlive uint16          `json:"num_dynamic_alive"`
-	Sequencer       *ledger.ChainID `json:"sequencers,omitempty"`
-}
+type (
+	NodeInfo struct {
+		ID              peer.ID         `json:"id"`
+		Version         string          `json:"version"`
+		NumStaticAlive  uint16          `json:"num_static_peers"`
+		NumDynamicAlive uint16          `json:"num_dynamic_alive"`
+		Isolated        bool            `json:"isolated,omitempty"`
+		ServingPaused   bool            `json:"serving_paused,omitempty"`
+		Addrs           []string        `json:"addrs,omitempty"`
+		NATStatus       string          `json:"nat_status,omitempty"`
+		Sequencer       *ledger.ChainID `json:"sequencers,omitempty"`
+		// LatestBranchTime timestamp of the latest committed branch and its age in milliseconds
+		LatestBranchTime  string `json:"latest_branch_time,omitempty"`
+		LatestBranchAgeMs int64  `json:"latest_branch_age_ms,omitempty"`
+		// Sequencers latest milestones of sequencers known to the node, sorted by coverage descending
+		Sequencers []SequencerInfo `json:"latest_milestones,omitempty"`
+		// Branches branches of the latest committed slot, sorted by coverage descending
+		Branches []BranchInfo `json:"latest_branches,omitempty"`
+	}
+
+	SequencerInfo struct {
+		ID              ledger.ChainID       `json:"id"`
+		Name            string               `json:"name,omitempty"`
+		LatestMilestone ledger.TransactionID `json:"latest_milestone"`
+		LedgerCoverage  uint64               `json:"ledger_coverage"`
+	}
+
+	BranchInfo struct {
+		ID             ledger.TransactionID `json:"id"`
+		Slot           uint32               `json:"slot"`
+		SequencerID    ledger.ChainID       `json:"sequencer_id"`
+		LedgerCoverage uint64               `json:"ledger_coverage"`
+	}
+)
 
 func (ni *NodeInfo) Bytes() []byte {
 	ret, err := json.Marshal(ni)
@@ -41,6 +69,19 @@ func (ni *NodeInfo) Lines(prefix ...string) *lines.Lines {
 	ret.Add("lpp host ID: %s", ni.ID.String()).
 		Add("static peers alive: %d", ni.NumStaticAlive).
 		Add("dynamic peers alive: %d", ni.NumDynamicAlive).
-		Add("sequencer: %s", seqStr)
+		Add("isolated: %v", ni.Isolated).
+		Add("serving paused: %v", ni.ServingPaused).
+		Add("NAT status: %s", ni.NATStatus).
+		Add("addresses: %v", ni.Addrs).
+		Add("sequencer: %s", seqStr).
+		Add("latest branch time: %s, age: %v", ni.LatestBranchTime, time.Duration(ni.LatestBranchAgeMs)*time.Millisecond)
+	ret.Add("latest milestones: %d", len(ni.Sequencers))
+	for _, si := range ni.Sequencers {
+		ret.Add("    %s (%s): %s, coverage: %s", si.Name, si.ID.StringShort(), si.LatestMilestone.StringShort(), util.Th(si.LedgerCoverage))
+	}
+	ret.Add("latest branches: %d", len(ni.Branches))
+	for _, bi := range ni.Branches {
+		ret.Add("    %s, slot: %d, sequencer: %s, coverage: %s", bi.ID.StringShort(), bi.Slot, bi.SequencerID.StringShort(), util.Th(bi.LedgerCoverage))
+	}
 	return ret
 }
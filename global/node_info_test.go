@@ -77,4 +77,35 @@ func TestPeerInfo(t *testing.T) {
 
 		require.True(t, pi.Sequencer.String() == piBack.Sequencer.String())
 	})
+	t.Run("3-sequencers and branches", func(t *testing.T) {
+		sequencer := ledger.RandomChainID()
+		pi := &NodeInfo{
+			ID:              randomPeerID(),
+			NumStaticAlive:  5,
+			NumDynamicAlive: 3,
+			Sequencer:       &sequencer,
+			Sequencers: []SequencerInfo{{
+				ID:              sequencer,
+				Name:            "seq0",
+				LatestMilestone: ledger.RandomTransactionID(true),
+				LedgerCoverage:  1_000_000,
+			}},
+			Branches: []BranchInfo{{
+				ID:             ledger.RandomTransactionID(true),
+				Slot:           1337,
+				SequencerID:    sequencer,
+				LedgerCoverage: 2_000_000,
+			}},
+		}
+		jsonData, err := json.MarshalIndent(pi, "", "  ")
+		require.NoError(t, err)
+		t.Logf("json string:\n%s", string(jsonData))
+
+		piBack, err := NodeInfoFromBytes(jsonData)
+		require.NoError(t, err)
+		require.EqualValues(t, pi.Sequencers, piBack.Sequencers)
+		require.EqualValues(t, pi.Branches, piBack.Branches)
+		require.True(t, pi.Sequencer.String() == piBack.Sequencer.String())
+		t.Logf("\n%s", piBack.Lines("   ").String())
+	})
 }
@@ -27,6 +27,8 @@ type (
 		// GetUTXOsLockedInAccount TODO limit maximum number of output
 		GetUTXOsLockedInAccount(accountID ledger.AccountID) ([]*ledger.OutputDataWithID, error)
 		GetUTXOForChainID(id *ledger.ChainID) (*ledger.OutputDataWithID, error)
+		// GetChainControlledOutputs returns outputs locked with the ChainLock of the chain
+		GetChainControlledOutputs(chainID ledger.ChainID) ([]*ledger.OutputDataWithID, error)
 		Root() common.VCommitment
 		MustLedgerIdentityBytes() []byte // either state identity consistent or panic
 	}
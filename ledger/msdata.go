@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/lunfardo314/easyfl"
 	"github.com/lunfardo314/proxima/util"
@@ -13,14 +14,20 @@ import (
 // MilestoneData data which is on sequencer as 'or(..)' constraint. It is not enforced by the ledger, yet maintained
 // by the sequencer
 type MilestoneData struct {
-	Name         string // < 256
+	Name         string // <= MaxMilestoneDataNameLength bytes
 	MinimumFee   uint64
 	ChainHeight  uint32
 	BranchHeight uint32
 }
 
+// MilestoneDataFixedIndex index of the milestone data constraint in the sequencer chain output.
+// Inflation and additional constraints of the chain output are placed after it
 const MilestoneDataFixedIndex = 4
 
+// MaxMilestoneDataNameLength is maximum length of the name in bytes. Name is encoded as EasyFL data constant,
+// which can't be longer than 127 bytes. Longer names are truncated
+const MaxMilestoneDataNameLength = 127
+
 // ParseMilestoneData expected at index 4, otherwise nil
 func ParseMilestoneData(o *Output) *MilestoneData {
 	if o.NumConstraints() <= MilestoneDataFixedIndex {
@@ -34,10 +41,7 @@ func ParseMilestoneData(o *Output) *MilestoneData {
 }
 
 func (od *MilestoneData) AsConstraint() Constraint {
-	dscrBin := []byte(od.Name)
-	if len(dscrBin) > 255 {
-		dscrBin = dscrBin[:256]
-	}
+	dscrBin := truncateUTF8([]byte(od.Name), MaxMilestoneDataNameLength)
 	dscrBinStr := fmt.Sprintf("0x%s", hex.EncodeToString(dscrBin))
 	chainIndexStr := fmt.Sprintf("u32/%d", od.ChainHeight)
 	branchIndexStr := fmt.Sprintf("u32/%d", od.BranchHeight)
@@ -53,6 +57,24 @@ func (od *MilestoneData) AsConstraint() Constraint {
 	return constr
 }
 
+// truncateUTF8 truncates data to maxLen bytes without splitting the last UTF-8 encoded rune
+func truncateUTF8(data []byte, maxLen int) []byte {
+	if len(data) <= maxLen {
+		return data
+	}
+	ret := data[:maxLen]
+	// cut the incomplete rune at the end, if any
+	for i := len(ret) - 1; i >= 0 && i >= len(ret)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(ret[i]) {
+			if !utf8.FullRune(ret[i:]) {
+				ret = ret[:i]
+			}
+			break
+		}
+	}
+	return ret
+}
+
 func MilestoneDataFromConstraint(constr []byte) (*MilestoneData, error) {
 	sym, _, args, err := L().ParseBytecodeOneLevel(constr)
 	if err != nil {
@@ -2,12 +2,18 @@ package tests
 
 import (
 	"crypto/ed25519"
+	"crypto/rand"
 	"testing"
 
 	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/ledger/txbuilder"
 	"github.com/lunfardo314/proxima/multistate"
+	"github.com/lunfardo314/proxima/util"
+	"github.com/lunfardo314/proxima/util/lazybytes"
 	"github.com/lunfardo314/proxima/util/testutil"
+	"github.com/lunfardo314/proxima/util/testutil/inittest"
 	"github.com/lunfardo314/unitrie/common"
+	"github.com/lunfardo314/unitrie/immutable"
 	"github.com/stretchr/testify/require"
 )
 
@@ -58,6 +64,21 @@ func TestInitOrigin(t *testing.T) {
 
 	require.EqualValues(t, 0, multistate.FetchLatestCommittedSlot(store))
 	require.EqualValues(t, 0, multistate.FetchEarliestSlot(store))
+
+	supply, slotInflation, numTx, err := multistate.MustNewReadable(store, genesisRoot).SupplyAndInflation()
+	require.NoError(t, err)
+	require.EqualValues(t, id.InitialSupply, supply)
+	require.EqualValues(t, branchData.SlotInflation, slotInflation)
+	require.EqualValues(t, branchData.NumTransactions, numTx)
+
+	// readable view of the updatable state reaches the root record too
+	supply, _, _, err = multistate.MustNewUpdatable(store, genesisRoot).Readable().SupplyAndInflation()
+	require.NoError(t, err)
+	require.EqualValues(t, id.InitialSupply, supply)
+
+	slot, stemBytes := multistate.MustNewReadable(store, genesisRoot).GetStem()
+	require.EqualValues(t, stemBack.ID.Slot(), slot)
+	require.EqualValues(t, stemBack.Output.Bytes(), stemBytes)
 }
 
 func TestBoostrapSequencerID(t *testing.T) {
@@ -74,3 +95,217 @@ func TestLedgerIDSerDe(t *testing.T) {
 	//t.Logf(hex.EncodeToString(idBack.Bytes()))
 	require.EqualValues(t, idBytes, idBack.Bytes())
 }
+
+func makeSyntheticState(t testing.TB, n int) (*common.InMemoryKVStore, common.VCommitment) {
+	id := ledger.DefaultIdentityData(testutil.GetTestingPrivateKey())
+	store := common.NewInMemoryKVStore()
+	root, err := multistate.CommitEmptyRootWithLedgerIdentity(*id, store)
+	require.NoError(t, err)
+
+	trie, err := immutable.NewTrieUpdatable(ledger.CommitmentModel, store, root)
+	require.NoError(t, err)
+	for i := 0; i < n; i++ {
+		key := make([]byte, 33)
+		key[0] = byte(i % 4)
+		_, _ = rand.Read(key[1:])
+		value := make([]byte, 100)
+		_, _ = rand.Read(value)
+		trie.Update(key, value)
+	}
+	batch := store.BatchedWriter()
+	root = trie.Commit(batch)
+	require.NoError(t, batch.Commit())
+	return store, root
+}
+
+func TestVerifyTrieIntegrity(t *testing.T) {
+	store, root := makeSyntheticState(t, 1000)
+	require.NoError(t, multistate.VerifyTrieIntegrity(store, root, false))
+	require.NoError(t, multistate.VerifyTrieIntegrity(store, root, true))
+
+	// corrupt one value in the value store
+	var corruptKey []byte
+	common.MakeTraversableReaderPartition(store, immutable.PartitionValues).Iterator(nil).IterateKeys(func(k []byte) bool {
+		corruptKey = k
+		return false
+	})
+	require.True(t, len(corruptKey) > 0)
+	store.Set(corruptKey, []byte("corrupted"))
+	require.Error(t, multistate.VerifyTrieIntegrity(store, root, false))
+	require.Error(t, multistate.VerifyTrieIntegrity(store, root, true))
+}
+
+func BenchmarkVerifyTrieIntegrity(b *testing.B) {
+	store, root := makeSyntheticState(b, 100_000)
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, multistate.VerifyTrieIntegrity(store, root, false))
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, multistate.VerifyTrieIntegrity(store, root, true))
+		}
+	})
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	id := ledger.DefaultIdentityData(testutil.GetTestingPrivateKey())
+	store := common.NewInMemoryKVStore()
+	bootstrapSeqID, genesisRoot := multistate.InitStateStore(*id, store)
+	require.NoError(t, multistate.MustNewReadable(store, genesisRoot).VerifyIntegrity())
+
+	// makes new root from the genesis state with one key deleted
+	rootWithKeyDeleted := func(key []byte) common.VCommitment {
+		trie, err := immutable.NewTrieUpdatable(ledger.CommitmentModel, store, genesisRoot)
+		require.NoError(t, err)
+		require.True(t, trie.Delete(key))
+		batch := store.BatchedWriter()
+		root := trie.Commit(batch)
+		require.NoError(t, batch.Commit())
+		return root
+	}
+	chainKey := common.Concat(multistate.TriePartitionChainID, bootstrapSeqID[:])
+	root := rootWithKeyDeleted(chainKey)
+	err := multistate.MustNewReadable(store, root).VerifyIntegrity()
+	require.Error(t, err)
+	t.Logf("expected error: %v", err)
+
+	stemID := ledger.GenesisStemOutputID()
+	stemKey := common.Concat(multistate.TriePartitionLedgerState, stemID[:])
+	root = rootWithKeyDeleted(stemKey)
+	err = multistate.MustNewReadable(store, root).VerifyIntegrity()
+	require.Error(t, err)
+	t.Logf("expected error: %v", err)
+}
+
+func TestUpdateChecksSupply(t *testing.T) {
+	id := ledger.DefaultIdentityData(testutil.GetTestingPrivateKey())
+	store := common.NewInMemoryKVStore()
+	_, genesisRoot := multistate.InitStateStore(*id, store)
+
+	// predecessor of the update is the genesis branch
+	par := &multistate.RootRecordParams{
+		StemOutputID:  ledger.GenesisStemOutputID(),
+		Coverage:      id.InitialSupply,
+		SlotInflation: 1000,
+		Supply:        id.InitialSupply + 1000 + 1,
+	}
+	err := multistate.MustNewUpdatable(store, genesisRoot).Update(multistate.NewMutations(), par)
+	require.Error(t, err)
+	t.Logf("expected error: %v", err)
+
+	par.Supply = id.InitialSupply + 1000
+	err = multistate.MustNewUpdatable(store, genesisRoot).Update(multistate.NewMutations(), par)
+	require.NoError(t, err)
+}
+
+func TestRootRecordTotals(t *testing.T) {
+	id := ledger.DefaultIdentityData(testutil.GetTestingPrivateKey())
+	store := common.NewInMemoryKVStore()
+	bootstrapSeqID, genesisRoot := multistate.InitStateStore(*id, store)
+
+	checkTotals := func(branchTxID ledger.TransactionID) multistate.RootRecord {
+		rr, found := multistate.FetchRootRecord(store, branchTxID)
+		require.True(t, found)
+		rdr := multistate.MustNewReadable(store, rr.Root)
+		numOutputs := 0
+		rdr.Iterator([]byte{multistate.TriePartitionLedgerState}).IterateKeys(func(_ []byte) bool {
+			numOutputs++
+			return true
+		})
+		require.EqualValues(t, numOutputs, rr.TotalOutputs)
+		require.EqualValues(t, len(rdr.ChainInfo()), rr.TotalChains)
+		return rr
+	}
+	genesisRR := checkTotals(*ledger.GenesisTransactionID())
+	// genesis output and stem, genesis chain
+	require.EqualValues(t, 2, genesisRR.TotalOutputs)
+	require.EqualValues(t, 1, genesisRR.TotalChains)
+	require.EqualValues(t, bootstrapSeqID, genesisRR.SequencerID)
+	require.True(t, ledger.CommitmentModel.EqualCommitments(genesisRoot, genesisRR.Root))
+
+	distrib, _, _ := inittest.GenesisParamsWithPreDistribution(1_000_000, 2_000_000)
+	_, distribTxID, err := txbuilder.DistributeInitialSupplyExt(store, testutil.GetTestingPrivateKey(), distrib)
+	require.NoError(t, err)
+	rr := checkTotals(distribTxID)
+	require.EqualValues(t, genesisRR.TotalOutputs+uint64(len(distrib)), rr.TotalOutputs)
+	require.EqualValues(t, 1, rr.TotalChains)
+
+	rrBack, err := multistate.RootRecordFromBytes(rr.Bytes())
+	require.NoError(t, err)
+	require.EqualValues(t, rr, rrBack)
+
+	rrJSONBack, err := rr.JSONAble().Parse()
+	require.NoError(t, err)
+	require.EqualValues(t, rr.TotalOutputs, rrJSONBack.TotalOutputs)
+	require.EqualValues(t, rr.TotalChains, rrJSONBack.TotalChains)
+	require.True(t, rrJSONBack.HasTotals())
+}
+
+func TestRootRecordFormats(t *testing.T) {
+	id := ledger.DefaultIdentityData(testutil.GetTestingPrivateKey())
+	store := common.NewInMemoryKVStore()
+	bootstrapSeqID, _ := multistate.InitStateStore(*id, store)
+	rr, found := multistate.FetchRootRecord(store, *ledger.GenesisTransactionID())
+	require.True(t, found)
+	require.True(t, rr.HasTotals())
+	require.EqualValues(t, 1, rr.Version())
+
+	data := rr.Bytes()
+	arr := lazybytes.ArrayFromBytesReadOnly(data)
+	// legacy elements, version, total outputs, total chains
+	require.EqualValues(t, 9, arr.NumElements())
+	require.EqualValues(t, []byte{1}, arr.At(6))
+
+	withElements := func(n int, extra ...[]byte) []byte {
+		ret := lazybytes.EmptyArray(256)
+		for i := 0; i < n; i++ {
+			ret.Push(arr.At(i))
+		}
+		for _, e := range extra {
+			ret.Push(e)
+		}
+		return ret.Bytes()
+	}
+	t.Run("current", func(t *testing.T) {
+		rrBack, err := multistate.RootRecordFromBytes(data)
+		require.NoError(t, err)
+		require.EqualValues(t, rr, rrBack)
+		require.EqualValues(t, data, rrBack.Bytes())
+	})
+	t.Run("legacy", func(t *testing.T) {
+		// root record as written before totals were introduced
+		legacyData := withElements(6)
+		rrLegacy, err := multistate.RootRecordFromBytes(legacyData)
+		require.NoError(t, err)
+		require.False(t, rrLegacy.HasTotals())
+		require.EqualValues(t, 0, rrLegacy.Version())
+		require.EqualValues(t, rr.Supply, rrLegacy.Supply)
+		require.EqualValues(t, rr.NumTransactions, rrLegacy.NumTransactions)
+		require.EqualValues(t, bootstrapSeqID, rrLegacy.SequencerID)
+		require.EqualValues(t, 0, rrLegacy.TotalOutputs)
+		// legacy record keeps its format
+		require.EqualValues(t, legacyData, rrLegacy.Bytes())
+
+		rrJSONBack, err := rrLegacy.JSONAble().Parse()
+		require.NoError(t, err)
+		require.False(t, rrJSONBack.HasTotals())
+	})
+	t.Run("newer version", func(t *testing.T) {
+		// known elements of the newer version are decoded
+		rrNewer, err := multistate.RootRecordFromBytes(withElements(6, []byte{2}, arr.At(7), arr.At(8), []byte("future")))
+		require.NoError(t, err)
+		require.True(t, rrNewer.HasTotals())
+		require.EqualValues(t, rr.TotalOutputs, rrNewer.TotalOutputs)
+		require.EqualValues(t, rr.TotalChains, rrNewer.TotalChains)
+	})
+	t.Run("wrong", func(t *testing.T) {
+		_, err := multistate.RootRecordFromBytes(withElements(5))
+		util.RequireErrorWith(t, err, "wrong number of elements")
+		_, err = multistate.RootRecordFromBytes(withElements(6, []byte{0}, arr.At(7), arr.At(8)))
+		util.RequireErrorWith(t, err, "wrong version")
+		_, err = multistate.RootRecordFromBytes(withElements(8))
+		util.RequireErrorWith(t, err, "wrong number of elements")
+	})
+}
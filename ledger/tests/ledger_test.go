@@ -806,6 +806,43 @@ func TestChainLock(t *testing.T) {
 		require.EqualValues(t, 2_500, int(onChainOut))
 		require.EqualValues(t, 11_000, int(u.Balance(addr0))) // also includes 500 on chain
 	})
+	t.Run("chain controlled outputs", func(t *testing.T) {
+		initTest2()
+		ts := ledger.TimeNow().AddTicks(5)
+		sendFun(1000, ts)
+		sendFun(2000, ts.AddTicks(1))
+
+		// output locked in another chain is not controlled by the chain
+		otherChainID := ledger.RandomChainID()
+		par, err := u.MakeTransferInputData(privKey1, nil, ts.AddTicks(2))
+		require.NoError(t, err)
+		err = u.DoTransfer(par.
+			WithAmount(3000).
+			WithTargetLock(ledger.ChainLockFromChainID(otherChainID)),
+		)
+		require.NoError(t, err)
+
+		outs, err := u.StateReader().GetChainControlledOutputs(chainID)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, len(outs))
+		sum := uint64(0)
+		for _, o := range outs {
+			out, err := ledger.OutputFromBytesReadOnly(o.OutputData)
+			require.NoError(t, err)
+			require.True(t, ledger.EqualConstraints(chainAddr, out.Lock()))
+			sum += out.Amount()
+		}
+		require.EqualValues(t, 3000, sum)
+
+		outs, err = u.StateReader().GetChainControlledOutputs(otherChainID)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, len(outs))
+
+		// unknown chain does not control any outputs
+		outs, err = u.StateReader().GetChainControlledOutputs(ledger.RandomChainID())
+		require.NoError(t, err)
+		require.EqualValues(t, 0, len(outs))
+	})
 }
 
 func TestLocalLibrary(t *testing.T) {
@@ -1172,3 +1209,70 @@ func TestDeadlineLock(t *testing.T) {
 	//require.EqualValues(t, 2000, int(u.Balance(addr1, ts.AddSlots(9))))
 	//require.EqualValues(t, 0, int(u.Balance(addr1, ts.AddSlots(11))))
 }
+
+func makeManyInputsTransfer(t testing.TB, numInputs int, wrongKey bool) (*utxodb.UTXODB, []byte) {
+	u := utxodb.NewUTXODB(genesisPrivateKey, true)
+	privKey0, _, addr0 := u.GenerateAddress(0)
+	privKey1, _, _ := u.GenerateAddress(1)
+	for i := 0; i < numInputs; i++ {
+		err := u.TokensFromFaucet(addr0, 1000)
+		require.NoError(t, err)
+	}
+	require.EqualValues(t, numInputs, u.NumUTXOs(addr0))
+
+	par, err := u.MakeTransferInputData(util.Cond(wrongKey, privKey1, privKey0), addr0, ledger.NilLedgerTime)
+	require.NoError(t, err)
+	txBytes, err := txbuilder.MakeTransferTransaction(par.WithAmount(uint64(numInputs * 1000)).WithTargetLock(addr0))
+	require.NoError(t, err)
+	return u, txBytes
+}
+
+func TestParallelInputValidation(t *testing.T) {
+	const numInputs = 3 * transaction2.ParallelInputValidationThreshold
+	defer transaction2.SetParallelInputValidation(0)
+
+	t.Run("ok", func(t *testing.T) {
+		u, txBytes := makeManyInputsTransfer(t, numInputs, false)
+		for _, workers := range []int{0, 4} {
+			transaction2.SetParallelInputValidation(workers)
+			ctx, err := u.ValidationContextFromTransaction(txBytes)
+			require.NoError(t, err)
+			require.EqualValues(t, numInputs, ctx.NumInputs())
+			err = ctx.Validate()
+			require.NoError(t, err)
+		}
+	})
+	t.Run("wrong signature", func(t *testing.T) {
+		u, txBytes := makeManyInputsTransfer(t, numInputs, true)
+		for _, workers := range []int{0, 4} {
+			transaction2.SetParallelInputValidation(workers)
+			ctx, err := u.ValidationContextFromTransaction(txBytes)
+			require.NoError(t, err)
+			err = ctx.Validate()
+			util.RequireErrorWith(t, err, "addressED25519 unlock failed")
+
+			failed, err := ctx.ValidateWithReportOnConsumedOutputs()
+			require.Error(t, err)
+			// the first input is unlocked with the signature, the rest are unlocked by reference
+			require.EqualValues(t, []byte{0}, failed)
+		}
+	})
+}
+
+func BenchmarkInputValidation(b *testing.B) {
+	const numInputs = 254
+	defer transaction2.SetParallelInputValidation(0)
+
+	u, txBytes := makeManyInputsTransfer(b, numInputs, false)
+	for _, workers := range []int{0, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			transaction2.SetParallelInputValidation(workers)
+			for i := 0; i < b.N; i++ {
+				ctx, err := u.ValidationContextFromTransaction(txBytes)
+				require.NoError(b, err)
+				err = ctx.Validate()
+				require.NoError(b, err)
+			}
+		})
+	}
+}
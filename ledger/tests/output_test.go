@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/util/lazybytes"
@@ -32,3 +34,33 @@ func TestRawOutputBytes(t *testing.T) {
 	require.EqualValues(t, o.Bytes(), rawBytesBack)
 
 }
+
+func TestMilestoneDataLongName(t *testing.T) {
+	roundTrip := func(name string) *ledger.MilestoneData {
+		md := &ledger.MilestoneData{
+			Name:         name,
+			MinimumFee:   500,
+			ChainHeight:  10,
+			BranchHeight: 5,
+		}
+		ret, err := ledger.MilestoneDataFromConstraint(md.AsConstraint().Bytes())
+		require.NoError(t, err)
+		require.EqualValues(t, md.MinimumFee, ret.MinimumFee)
+		require.EqualValues(t, md.ChainHeight, ret.ChainHeight)
+		require.EqualValues(t, md.BranchHeight, ret.BranchHeight)
+		return ret
+	}
+	t.Run("ascii", func(t *testing.T) {
+		name := strings.Repeat("a", 300)
+		require.EqualValues(t, name[:ledger.MaxMilestoneDataNameLength], roundTrip(name).Name)
+		name = strings.Repeat("b", ledger.MaxMilestoneDataNameLength)
+		require.EqualValues(t, name, roundTrip(name).Name)
+	})
+	t.Run("utf8", func(t *testing.T) {
+		// 3-byte rune crosses the boundary
+		prefix := strings.Repeat("a", ledger.MaxMilestoneDataNameLength-1)
+		ret := roundTrip(prefix + "€" + strings.Repeat("c", 40))
+		require.EqualValues(t, prefix, ret.Name)
+		require.True(t, utf8.ValidString(ret.Name))
+	})
+}
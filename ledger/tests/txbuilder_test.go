@@ -1,15 +1,21 @@
 package tests
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/lunfardo314/easyfl"
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/ledger/transaction"
 	"github.com/lunfardo314/proxima/ledger/txbuilder"
+	"github.com/lunfardo314/proxima/multistate"
 	"github.com/lunfardo314/proxima/util"
 	"github.com/lunfardo314/proxima/util/utxodb"
+	"github.com/lunfardo314/unitrie/common"
 	"github.com/stretchr/testify/require"
 )
 
@@ -186,6 +192,40 @@ func TestBasics(t *testing.T) {
 			require.EqualValues(t, 10000, u.Balance(addrs[i]))
 		}
 	})
+	t.Run("utxos of many accounts", func(t *testing.T) {
+		u := utxodb.NewUTXODB(genesisPrivateKey, true)
+		_, _, addrs := u.GenerateAddressesWithFaucetAmount(1, 5, 10_000)
+		privKey0, _, addr0 := u.GenerateAddress(0)
+		err := u.TokensFromFaucet(addr0, 1000)
+		require.NoError(t, err)
+		const howMany = 3
+		for i := 0; i < howMany; i++ {
+			err = u.TransferTokens(privKey0, addrs[1], 100)
+			require.NoError(t, err)
+		}
+		_, _, addrEmpty := u.GenerateAddress(100)
+
+		accounts := []ledger.AccountID{addr0.AccountID(), addrEmpty.AccountID()}
+		for _, addr := range addrs {
+			accounts = append(accounts, addr.AccountID())
+		}
+		outs, err := u.StateReader().GetUTXOsLockedInAccounts(accounts)
+		require.NoError(t, err)
+		require.EqualValues(t, len(accounts), len(outs))
+		for _, acc := range accounts {
+			expected, err := u.StateReader().GetUTXOsLockedInAccount(acc)
+			require.NoError(t, err)
+			require.EqualValues(t, len(expected), len(outs[string(acc)]))
+			for i := range expected {
+				require.EqualValues(t, expected[i].ID, outs[string(acc)][i].ID)
+			}
+		}
+		require.EqualValues(t, 0, len(outs[string(addrEmpty.AccountID())]))
+		require.EqualValues(t, 1+howMany, len(outs[string(addrs[1].AccountID())]))
+
+		_, err = u.StateReader().GetUTXOsLockedInAccounts([]ledger.AccountID{make([]byte, 256)})
+		require.Error(t, err)
+	})
 }
 
 func TestManyInputs(t *testing.T) {
@@ -381,3 +421,666 @@ func TestChainSuccessorTransaction(t *testing.T) {
 		require.EqualValues(t, initAmount, u.Balance(addrs[1]))
 	})
 }
+
+// seqTxTestData UTXODB with the chain origin of the sequencer, controlled by the first private key,
+// and the timestamp with the fake endorsement for the sequencer transaction
+type seqTxTestData struct {
+	u          *utxodb.UTXODB
+	privKeys   []ed25519.PrivateKey
+	addrs      []ledger.AddressED25519
+	chainInput *ledger.OutputWithChainID
+	ts         ledger.Time
+	endorse    ledger.TransactionID
+}
+
+const seqTxTestInitAmount = 100_000_000_000_000
+
+// initSeqTxTestData creates nAddrs addresses with tokens from the faucet and the chain origin controlled by the first one.
+// Sequencer transaction timestamp is one sequencer pace after the chain origin
+func initSeqTxTestData(t *testing.T, nAddrs int) *seqTxTestData {
+	ret := &seqTxTestData{u: utxodb.NewUTXODB(genesisPrivateKey, true)}
+	ret.privKeys, _, ret.addrs = ret.u.GenerateAddressesWithFaucetAmount(1, nAddrs, seqTxTestInitAmount)
+
+	var err error
+	ret.chainInput, err = ret.u.CreateChainOrigin(ret.privKeys[0], ledger.TimeNow())
+	require.NoError(t, err)
+	ret.setTimestampAfter(ret.chainInput.Timestamp())
+	return ret
+}
+
+// setTimestampAfter sets sequencer transaction timestamp one sequencer pace after ts and the endorsement on the same slot
+func (td *seqTxTestData) setTimestampAfter(ts ledger.Time) {
+	td.ts = ledger.L().ID.EnsurePostBranchConsolidationConstraintTimestamp(ts.AddTicks(ledger.TransactionPaceSequencer()))
+	// endorsement must be on the same slot
+	td.endorse = ledger.NewTransactionID(ledger.NewLedgerTime(td.ts.Slot(), 1), ledger.TransactionIDShort{}, true)
+}
+
+func TestSequencerTxOutputCache(t *testing.T) {
+	td := initSeqTxTestData(t, 1)
+	chainInput, endorse := td.chainInput, td.endorse
+	par := txbuilder.MakeSequencerTransactionParams{
+		SeqName:           "test",
+		ChainInput:        chainInput,
+		Timestamp:         td.ts,
+		Endorsements:      []*ledger.TransactionID{&endorse},
+		PrivateKey:        td.privKeys[0],
+		ReturnInputLoader: true,
+	}
+	t.Run("default slice loader", func(t *testing.T) {
+		_, inputLoader, err := txbuilder.MakeSequencerTransactionWithInputLoader(par)
+		require.NoError(t, err)
+		o, err := inputLoader(0)
+		require.NoError(t, err)
+		require.True(t, o == chainInput.Output)
+	})
+	t.Run("with cache", func(t *testing.T) {
+		cache := make(txbuilder.OutputCache)
+		par.OutputCache = cache
+		_, inputLoader, err := txbuilder.MakeSequencerTransactionWithInputLoader(par)
+		require.NoError(t, err)
+		// consumed output was put into the cache
+		require.True(t, cache[chainInput.ID] == chainInput.Output)
+
+		// the loader is served from the cache
+		cached := chainInput.Output.Clone()
+		cache[chainInput.ID] = cached
+		o, err := inputLoader(0)
+		require.NoError(t, err)
+		require.True(t, o == cached)
+
+		_, err = inputLoader(1)
+		util.RequireErrorWith(t, err, "wrong input index")
+	})
+	t.Run("cache is consulted before loading", func(t *testing.T) {
+		cache := make(txbuilder.OutputCache)
+		par1 := par
+		par1.ReturnInputLoader = false
+		par1.OutputCache = cache
+		_, err := txbuilder.MakeSequencerTransaction(par1)
+		require.NoError(t, err)
+		// consumed output is put into the cache without the input loader
+		require.True(t, cache[chainInput.ID] == chainInput.Output)
+
+		numLoads := 0
+		load := func() (*ledger.Output, error) {
+			numLoads++
+			return chainInput.Output.Clone(), nil
+		}
+		o, err := cache.Load(chainInput.ID, load)
+		require.NoError(t, err)
+		require.True(t, o == chainInput.Output)
+		require.EqualValues(t, 0, numLoads)
+
+		otherID := ledger.NewOutputID(&endorse, 0)
+		o, err = cache.Load(otherID, load)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, numLoads)
+		o1, err := cache.Load(otherID, load)
+		require.NoError(t, err)
+		require.True(t, o == o1)
+		require.EqualValues(t, 1, numLoads)
+
+		_, err = cache.Load(ledger.NewOutputID(&endorse, 1), func() (*ledger.Output, error) {
+			return nil, errors.New("not found")
+		})
+		util.RequireErrorWith(t, err, "not found")
+		require.EqualValues(t, 2, len(cache))
+	})
+}
+
+func TestSequencerTxEndorsements(t *testing.T) {
+	td := initSeqTxTestData(t, 1)
+	ts := td.ts
+	seqTxIDAt := func(ts ledger.Time, seqTx bool) *ledger.TransactionID {
+		ret := ledger.NewTransactionID(ts, ledger.TransactionIDShort{}, seqTx)
+		return &ret
+	}
+	makeTx := func(endorse ...*ledger.TransactionID) error {
+		_, err := txbuilder.MakeSequencerTransaction(txbuilder.MakeSequencerTransactionParams{
+			SeqName:      "test",
+			ChainInput:   td.chainInput,
+			Timestamp:    ts,
+			Endorsements: endorse,
+			PrivateKey:   td.privKeys[0],
+		})
+		return err
+	}
+	t.Run("ok", func(t *testing.T) {
+		err := makeTx(seqTxIDAt(ledger.NewLedgerTime(ts.Slot(), 1), true), seqTxIDAt(ledger.NewLedgerTime(ts.Slot(), 2), true))
+		require.NoError(t, err)
+	})
+	t.Run("cross slot", func(t *testing.T) {
+		err := makeTx(seqTxIDAt(ledger.NewLedgerTime(ts.Slot()-1, 1), true))
+		util.RequireErrorWith(t, err, "is not on the slot of the target timestamp")
+		require.True(t, errors.Is(err, txbuilder.ErrInvalidEndorsement))
+
+		err = makeTx(seqTxIDAt(ledger.NewLedgerTime(ts.Slot(), 1), true), seqTxIDAt(ledger.NewLedgerTime(ts.Slot()+1, 1), true))
+		util.RequireErrorWith(t, err, "is not on the slot of the target timestamp")
+	})
+	t.Run("stale", func(t *testing.T) {
+		// endorsed transaction is too close to the target
+		err := makeTx(seqTxIDAt(ts.AddTicks(-ledger.TransactionPaceSequencer()+1), true))
+		util.RequireErrorWith(t, err, "violates sequencer time pace")
+
+		// endorsed transaction is in the future
+		if future := ts.AddTicks(1); future.Slot() == ts.Slot() {
+			err = makeTx(seqTxIDAt(future, true))
+			util.RequireErrorWith(t, err, "violates sequencer time pace")
+		}
+	})
+	t.Run("not sequencer", func(t *testing.T) {
+		err := makeTx(seqTxIDAt(ledger.NewLedgerTime(ts.Slot(), 1), false))
+		util.RequireErrorWith(t, err, "is not a sequencer transaction")
+	})
+	t.Run("repeating", func(t *testing.T) {
+		e := seqTxIDAt(ledger.NewLedgerTime(ts.Slot(), 1), true)
+		err := makeTx(e, e)
+		util.RequireErrorWith(t, err, "repeating endorsement")
+	})
+	t.Run("too many", func(t *testing.T) {
+		endorse := make([]*ledger.TransactionID, ledger.L().ID.MaxNumberOfEndorsements+1)
+		for i := range endorse {
+			endorse[i] = seqTxIDAt(ledger.NewLedgerTime(ts.Slot(), 1), true)
+		}
+		err := makeTx(endorse...)
+		util.RequireErrorWith(t, err, "exceeds limit")
+	})
+}
+
+func TestPreviewSequencerTransaction(t *testing.T) {
+	td := initSeqTxTestData(t, 1)
+	chainInput := td.chainInput
+	const additionalAmount = 1_000_000
+	par := txbuilder.MakeSequencerTransactionParams{
+		SeqName:           "test",
+		ChainInput:        chainInput,
+		Timestamp:         td.ts,
+		Endorsements:      []*ledger.TransactionID{&td.endorse},
+		AdditionalOutputs: []*ledger.Output{ledger.NewOutput(func(o *ledger.Output) { o.WithAmount(additionalAmount).WithLock(td.addrs[0]) })},
+		PutInflation:      true,
+	}
+	// private key is not needed
+	preview, err := txbuilder.PreviewSequencerTransaction(par)
+	require.NoError(t, err)
+	require.True(t, preview.PutInflationConstraint)
+	require.EqualValues(t, preview.ChainOutAmount+additionalAmount, preview.TotalProducedAmount)
+
+	// dry run through the params is only supported by the preview
+	dryPar := par
+	dryPar.DryRun = true
+	previewDry, err := txbuilder.PreviewSequencerTransaction(dryPar)
+	require.NoError(t, err)
+	require.EqualValues(t, *preview, *previewDry)
+
+	_, err = txbuilder.MakeSequencerTransaction(dryPar)
+	util.RequireErrorWith(t, err, "dry run is not supported")
+	require.True(t, errors.Is(err, txbuilder.ErrInvalidParams))
+	_, _, err = txbuilder.MakeSequencerTransactionWithInputLoader(dryPar)
+	require.True(t, errors.Is(err, txbuilder.ErrInvalidParams))
+
+	par.PrivateKey = td.privKeys[0]
+	txBytes, err := txbuilder.MakeSequencerTransaction(par)
+	require.NoError(t, err)
+	tx, err := transaction.FromBytes(txBytes, transaction.MainTxValidationOptions...)
+	require.NoError(t, err)
+	require.EqualValues(t, preview.ChainOutAmount, tx.SequencerOutput().Output.Amount())
+	require.EqualValues(t, preview.TotalProducedAmount, tx.TotalAmount())
+	require.EqualValues(t, chainInput.Output.Amount()+preview.InflateBy, tx.TotalAmount())
+
+	par.PutInflation = false
+	preview, err = txbuilder.PreviewSequencerTransaction(par)
+	require.NoError(t, err)
+	require.False(t, preview.PutInflationConstraint)
+	require.EqualValues(t, 0, preview.InflateBy)
+	require.EqualValues(t, chainInput.Output.Amount(), preview.TotalProducedAmount)
+}
+
+func TestSequencerTxAdditionalChainOutputConstraints(t *testing.T) {
+	td := initSeqTxTestData(t, 1)
+	marker, err := ledger.NewGeneralScriptFromSource("concat(0x01020304030201)")
+	require.NoError(t, err)
+
+	par := txbuilder.MakeSequencerTransactionParams{
+		SeqName:                          "test",
+		ChainInput:                       td.chainInput,
+		Timestamp:                        td.ts,
+		Endorsements:                     []*ledger.TransactionID{&td.endorse},
+		PrivateKey:                       td.privKeys[0],
+		PutInflation:                     true,
+		AdditionalChainOutputConstraints: []ledger.Constraint{marker},
+	}
+	t.Run("ok", func(t *testing.T) {
+		txBytes, err := txbuilder.MakeSequencerTransaction(par)
+		require.NoError(t, err)
+		tx, err := transaction.FromBytes(txBytes, transaction.MainTxValidationOptions...)
+		require.NoError(t, err)
+
+		o := tx.SequencerOutput().Output
+		msData := ledger.ParseMilestoneData(o)
+		require.True(t, msData != nil)
+		require.EqualValues(t, "test", msData.Name)
+		_, idxInflation := o.InflationConstraint()
+		require.EqualValues(t, ledger.MilestoneDataFixedIndex+1, idxInflation)
+		require.EqualValues(t, marker.Bytes(), o.ConstraintAt(ledger.MilestoneDataFixedIndex+2))
+	})
+	t.Run("too many", func(t *testing.T) {
+		par1 := par
+		par1.AdditionalChainOutputConstraints = make([]ledger.Constraint, 256-ledger.MilestoneDataFixedIndex-1)
+		for i := range par1.AdditionalChainOutputConstraints {
+			par1.AdditionalChainOutputConstraints[i] = marker
+		}
+		_, err := txbuilder.MakeSequencerTransaction(par1)
+		util.RequireErrorWith(t, err, "too many additional chain output constraints")
+	})
+	t.Run("not allowed", func(t *testing.T) {
+		par1 := par
+		par1.AdditionalChainOutputConstraints = []ledger.Constraint{&ledger.InflationConstraint{}}
+		_, err := txbuilder.MakeSequencerTransaction(par1)
+		util.RequireErrorWith(t, err, "is not allowed")
+	})
+}
+
+func TestSequencerTxTagAlongOutputs(t *testing.T) {
+	td := initSeqTxTestData(t, 3)
+	u, chainInput := td.u, td.chainInput
+	fees := make(map[ledger.ChainID]uint64)
+	for i := 1; i < 3; i++ {
+		seq, err := u.CreateChainOrigin(td.privKeys[i], ledger.TimeNow())
+		require.NoError(t, err)
+		fees[seq.ChainID] = uint64(i * 1_000)
+	}
+
+	par := txbuilder.MakeSequencerTransactionParams{
+		SeqName:      "test",
+		ChainInput:   chainInput,
+		Timestamp:    td.ts,
+		Endorsements: []*ledger.TransactionID{&td.endorse},
+		PrivateKey:   td.privKeys[0],
+	}
+	err := txbuilder.AddTagAlongOutputs(&par, fees)
+	require.NoError(t, err)
+	require.EqualValues(t, len(fees), len(par.AdditionalOutputs))
+
+	txBytes, err := txbuilder.MakeSequencerTransaction(par)
+	require.NoError(t, err)
+	err = u.AddTransaction(txBytes, func(ctx *transaction.TxContext, err error) error {
+		if err != nil {
+			return fmt.Errorf("Error: %v\n%s", err, ctx.String())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	for seqID, fee := range fees {
+		locked, _, err := u.BalanceOnChain(seqID)
+		require.NoError(t, err)
+		require.EqualValues(t, fee, locked)
+	}
+	_, onChain, err := u.BalanceOnChain(chainInput.ChainID)
+	require.NoError(t, err)
+	require.EqualValues(t, chainInput.Output.Amount()-3_000, onChain)
+
+	t.Run("wrong fees", func(t *testing.T) {
+		par1 := par
+		par1.AdditionalOutputs = nil
+		err := txbuilder.AddTagAlongOutputs(&par1, map[ledger.ChainID]uint64{chainInput.ChainID: 0})
+		util.RequireErrorWith(t, err, "zero tag-along fee")
+
+		var id1, id2 ledger.ChainID
+		id2[0] = 1
+		err = txbuilder.AddTagAlongOutputs(&par1, map[ledger.ChainID]uint64{id1: math.MaxUint64, id2: 1})
+		util.RequireErrorWith(t, err, "arithmetic overflow")
+		require.True(t, errors.Is(err, txbuilder.ErrArithmeticOverflow))
+		require.EqualValues(t, 0, len(par1.AdditionalOutputs))
+
+		// overflow of the sum of additional outputs is detected by the builder
+		for _, amount := range []uint64{math.MaxUint64, 1} {
+			par1.AdditionalOutputs = append(par1.AdditionalOutputs, ledger.NewOutput(func(o *ledger.Output) {
+				o.WithAmount(amount).WithLock(chainInput.ChainID.AsChainLock())
+			}))
+		}
+		_, err = txbuilder.MakeSequencerTransaction(par1)
+		util.RequireErrorWith(t, err, "arithmetic overflow")
+		require.True(t, errors.Is(err, txbuilder.ErrArithmeticOverflow))
+		require.False(t, errors.Is(err, txbuilder.ErrInvalidTimestamp))
+	})
+}
+
+func TestBranchInflationOverride(t *testing.T) {
+	td := initSeqTxTestData(t, 1)
+	chainInput := td.chainInput
+	par := txbuilder.MakeSequencerTransactionParams{
+		SeqName:      "test",
+		ChainInput:   chainInput,
+		StemInput:    ledger.GenesisStemOutput(),
+		Timestamp:    chainInput.Timestamp().AddTicks(ledger.TransactionPaceSequencer()).NextSlotBoundary(),
+		PrivateKey:   td.privKeys[0],
+		PutInflation: true,
+	}
+	preview, err := txbuilder.PreviewSequencerTransaction(par)
+	require.NoError(t, err)
+	require.True(t, preview.InflateBy <= ledger.L().ID.BranchInflationBonusBase)
+
+	override := ledger.L().ID.BranchInflationBonusBase / 2
+	par.BranchInflationOverride = &override
+	preview, err = txbuilder.PreviewSequencerTransaction(par)
+	require.NoError(t, err)
+	require.EqualValues(t, override, preview.InflateBy)
+	require.EqualValues(t, chainInput.Output.Amount()+override, preview.ChainOutAmount)
+
+	override = ledger.L().ID.BranchInflationBonusBase + 1
+	_, err = txbuilder.PreviewSequencerTransaction(par)
+	util.RequireErrorWith(t, err, "exceeds maximum")
+
+	// override is ignored for non-branch transactions
+	par.StemInput = nil
+	par.Timestamp = td.ts
+	par.Endorsements = []*ledger.TransactionID{&td.endorse}
+	previewOverride, err := txbuilder.PreviewSequencerTransaction(par)
+	require.NoError(t, err)
+	par.BranchInflationOverride = nil
+	preview, err = txbuilder.PreviewSequencerTransaction(par)
+	require.NoError(t, err)
+	require.EqualValues(t, *preview, *previewOverride)
+}
+
+func TestSequencerTxChainLockedInput(t *testing.T) {
+	const chainLockedAmt = 1_000_000
+	td := initSeqTxTestData(t, 2)
+	u, chainInput := td.u, td.chainInput
+
+	// send tokens to the chain lock of the sequencer
+	tx, err := u.TransferTokensReturnTx(td.privKeys[1], chainInput.ChainID.AsChainLock(), chainLockedAmt)
+	require.NoError(t, err)
+
+	var chainLocked *ledger.OutputWithID
+	for _, o := range tx.ProducedOutputs() {
+		if o.Output.Lock().Name() == ledger.ChainLockName {
+			chainLocked = o
+		}
+	}
+	require.True(t, chainLocked != nil)
+
+	td.setTimestampAfter(ledger.MaximumTime(chainInput.Timestamp(), chainLocked.Timestamp()))
+	txBytes, err := txbuilder.MakeSequencerTransaction(txbuilder.MakeSequencerTransactionParams{
+		SeqName:          "test",
+		ChainInput:       chainInput,
+		Timestamp:        td.ts,
+		AdditionalInputs: []*ledger.OutputWithID{chainLocked},
+		Endorsements:     []*ledger.TransactionID{&td.endorse},
+		PrivateKey:       td.privKeys[0],
+	})
+	require.NoError(t, err)
+
+	err = u.AddTransaction(txBytes, func(ctx *transaction.TxContext, err error) error {
+		if err != nil {
+			return fmt.Errorf("Error: %v\n%s", err, ctx.String())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// chain-locked output was consumed into the chain output
+	lockedOnChain, onChainOutput, err := u.BalanceOnChain(chainInput.ChainID)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, lockedOnChain)
+	require.EqualValues(t, chainInput.Output.Amount()+chainLockedAmt, onChainOutput)
+}
+
+func TestNextSequencerTimestamp(t *testing.T) {
+	pace := int(ledger.TransactionPaceSequencer())
+	chainInputAt := func(ts ledger.Time, seqTx bool) *ledger.OutputWithChainID {
+		txid := ledger.NewTransactionID(ts, ledger.TransactionIDShort{}, seqTx)
+		return &ledger.OutputWithChainID{OutputWithID: ledger.OutputWithID{ID: ledger.NewOutputID(&txid, 0)}}
+	}
+	seqTxIDAt := func(ts ledger.Time) *ledger.TransactionID {
+		ret := ledger.NewTransactionID(ts, ledger.TransactionIDShort{}, true)
+		return &ret
+	}
+	t.Run("same slot", func(t *testing.T) {
+		chainIn := chainInputAt(ledger.NewLedgerTime(10, 50), true)
+		ts, err := txbuilder.NextSequencerTimestamp(chainIn, nil, nil, ledger.NewLedgerTime(10, 1))
+		require.NoError(t, err)
+		require.EqualValues(t, ledger.NewLedgerTime(10, 50).AddTicks(pace), ts)
+
+		// now is later than the pace
+		now := ledger.NewLedgerTime(10, 100)
+		ts, err = txbuilder.NextSequencerTimestamp(chainIn, nil, nil, now)
+		require.NoError(t, err)
+		require.EqualValues(t, now, ts)
+	})
+	t.Run("after branch", func(t *testing.T) {
+		chainIn := chainInputAt(ledger.NewLedgerTime(10, 0), true)
+		ts, err := txbuilder.NextSequencerTimestamp(chainIn, nil, nil, ledger.NewLedgerTime(10, 0))
+		require.NoError(t, err)
+		require.True(t, ledger.L().ID.IsPostBranchConsolidationTimestamp(ts))
+		require.True(t, ledger.ValidSequencerPace(chainIn.Timestamp(), ts))
+		require.EqualValues(t, 10, ts.Slot())
+	})
+	t.Run("cross slot", func(t *testing.T) {
+		chainIn := chainInputAt(ledger.NewLedgerTime(10, 50), true)
+		now := ledger.NewLedgerTime(12, 50)
+		_, err := txbuilder.NextSequencerTimestamp(chainIn, nil, nil, now)
+		util.RequireErrorWith(t, err, "cross-slot sequencer tx must endorse")
+
+		endorse := seqTxIDAt(ledger.NewLedgerTime(12, 60))
+		ts, err := txbuilder.NextSequencerTimestamp(chainIn, nil, []*ledger.TransactionID{endorse}, now)
+		require.NoError(t, err)
+		require.EqualValues(t, endorse.Timestamp().AddTicks(pace), ts)
+
+		// endorsement from another slot
+		endorse = seqTxIDAt(ledger.NewLedgerTime(11, 60))
+		_, err = txbuilder.NextSequencerTimestamp(chainIn, nil, []*ledger.TransactionID{endorse}, now)
+		util.RequireErrorWith(t, err, "is not on the slot of the target timestamp")
+	})
+	t.Run("not sequencer predecessor", func(t *testing.T) {
+		chainIn := chainInputAt(ledger.NewLedgerTime(10, 50), false)
+		_, err := txbuilder.NextSequencerTimestamp(chainIn, nil, nil, ledger.NewLedgerTime(10, 1))
+		util.RequireErrorWith(t, err, "endorsement of sequencer transaction is mandatory")
+	})
+	t.Run("branch", func(t *testing.T) {
+		chainIn := chainInputAt(ledger.NewLedgerTime(10, 100), true)
+		stemTxID := ledger.NewTransactionID(ledger.NewLedgerTime(10, 0), ledger.TransactionIDShort{}, true)
+		stemIn := &ledger.OutputWithID{ID: ledger.NewOutputID(&stemTxID, 0)}
+
+		ts, err := txbuilder.NextSequencerTimestamp(chainIn, stemIn, nil, ledger.NewLedgerTime(10, 120))
+		require.NoError(t, err)
+		require.EqualValues(t, ledger.NewLedgerTime(11, 0), ts)
+
+		_, err = txbuilder.NextSequencerTimestamp(chainIn, stemIn, []*ledger.TransactionID{seqTxIDAt(ledger.NewLedgerTime(10, 110))}, ledger.NewLedgerTime(10, 120))
+		util.RequireErrorWith(t, err, "branch transaction can't endorse")
+	})
+}
+
+func TestInflationState(t *testing.T) {
+	const amount = 1_000_000_000
+	chainOutputAt := func(ts ledger.Time, branch bool, inflation *ledger.InflationConstraint) *ledger.OutputWithChainID {
+		txid := ledger.NewTransactionID(ts, ledger.TransactionIDShort{}, true)
+		out := ledger.NewOutput(func(o *ledger.Output) {
+			o.PutAmount(amount)
+			o.PutLock(ledger.AddressED25519Null())
+			if inflation != nil {
+				_, _ = o.PushConstraint(inflation.Bytes())
+			}
+		})
+		require.EqualValues(t, branch, txid.IsBranchTransaction())
+		return &ledger.OutputWithChainID{OutputWithID: ledger.OutputWithID{ID: ledger.NewOutputID(&txid, 0), Output: out}}
+	}
+	opportunitySlots := ledger.Slot(ledger.L().ID.ChainInflationOpportunitySlots)
+
+	t.Run("no inflation constraint", func(t *testing.T) {
+		o := chainOutputAt(ledger.NewLedgerTime(10, 50), false, nil)
+		st := txbuilder.InflationState(o, ledger.NewLedgerTime(10, 60))
+		require.False(t, st.HasInflationConstraint)
+		require.EqualValues(t, 0, st.ChainInflation)
+		require.EqualValues(t, 0, st.Delayed)
+		require.True(t, st.Continues)
+		require.True(t, st.SuccessorInflation > 0)
+	})
+	t.Run("continuation", func(t *testing.T) {
+		o := chainOutputAt(ledger.NewLedgerTime(10, 50), false, &ledger.InflationConstraint{ChainInflation: 1000, DelayedInflationIndex: 0xff})
+		st := txbuilder.InflationState(o, ledger.NewLedgerTime(10+opportunitySlots, 50))
+		require.True(t, st.HasInflationConstraint)
+		require.EqualValues(t, 1000, st.ChainInflation)
+		// non-branch outputs do not delay inflation
+		require.EqualValues(t, 0, st.Delayed)
+		require.True(t, st.Continues)
+		require.EqualValues(t, ledger.L().CalcChainInflationAmount(o.Timestamp(), ledger.NewLedgerTime(10+opportunitySlots, 50), amount, 0), st.SuccessorInflation)
+	})
+	t.Run("reset", func(t *testing.T) {
+		o := chainOutputAt(ledger.NewLedgerTime(10, 50), false, &ledger.InflationConstraint{ChainInflation: 1000, DelayedInflationIndex: 0xff})
+		st := txbuilder.InflationState(o, ledger.NewLedgerTime(11+opportunitySlots, 50))
+		require.False(t, st.Continues)
+		require.EqualValues(t, 0, st.SuccessorInflation)
+	})
+	t.Run("reset with delayed inflation on branch", func(t *testing.T) {
+		o := chainOutputAt(ledger.NewLedgerTime(10, 0), true, &ledger.InflationConstraint{ChainInflation: 1000, DelayedInflationIndex: 0xff})
+		st := txbuilder.InflationState(o, ledger.NewLedgerTime(11+opportunitySlots, 50))
+		require.EqualValues(t, 1000, st.Delayed)
+		require.False(t, st.Continues)
+		// only delayed inflation is left
+		require.EqualValues(t, 1000, st.SuccessorInflation)
+	})
+}
+
+func TestIterateChains(t *testing.T) {
+	u := utxodb.NewUTXODB(genesisPrivateKey, true)
+	const numChains = 4
+	privKeys, _, _ := u.GenerateAddressesWithFaucetAmount(1, numChains, 100_000_000_000)
+	for i := range privKeys {
+		_, err := u.CreateChainOrigin(privKeys[i], ledger.TimeNow())
+		require.NoError(t, err)
+	}
+	rdr := u.StateReader()
+	chainInfo := rdr.ChainInfo()
+	// created chains plus genesis chain
+	require.EqualValues(t, numChains+1, len(chainInfo))
+
+	count := 0
+	rdr.IterateChains(func(chainID ledger.ChainID, o *ledger.OutputDataWithID) bool {
+		info, found := chainInfo[chainID]
+		require.True(t, found)
+		require.EqualValues(t, info.Output.ID, o.ID)
+		count++
+		return true
+	})
+	require.EqualValues(t, len(chainInfo), count)
+
+	count = 0
+	rdr.IterateChains(func(_ ledger.ChainID, _ *ledger.OutputDataWithID) bool {
+		count++
+		return count < 2
+	})
+	require.EqualValues(t, 2, count)
+}
+
+func TestParsedOutputCache(t *testing.T) {
+	u := utxodb.NewUTXODB(genesisPrivateKey, true)
+	_, _, addrs := u.GenerateAddressesWithFaucetAmount(1, 10, 10_000)
+
+	const trieCacheSize = 10_000
+	rdrNoCache := multistate.MustNewReadable(u.StateStore(), u.Root(), trieCacheSize)
+	rdrCache := multistate.MustNewReadable(u.StateStore(), u.Root(), trieCacheSize, 100)
+	for i := 0; i < 2; i++ {
+		require.EqualValues(t, rdrNoCache.AccountsByLocks(), rdrCache.AccountsByLocks())
+		require.EqualValues(t, len(rdrNoCache.ChainInfo()), len(rdrCache.ChainInfo()))
+	}
+	for _, addr := range addrs {
+		oDatas, err := rdrCache.GetUTXOsLockedInAccount(addr.AccountID())
+		require.NoError(t, err)
+		require.EqualValues(t, 1, len(oDatas))
+		var prev *ledger.Output
+		for i := 0; i < 2; i++ {
+			o, found := rdrCache.GetParsedOutput(&oDatas[0].ID)
+			require.True(t, found)
+			require.EqualValues(t, oDatas[0].OutputData, o.Bytes())
+			// cached output is not shared with the caller
+			require.True(t, o != prev)
+			prev = o
+		}
+	}
+}
+
+// BenchmarkParsedOutputCache repeated queries to the same root with and without cache of parsed outputs
+func BenchmarkParsedOutputCache(b *testing.B) {
+	u := utxodb.NewUTXODB(genesisPrivateKey, true)
+	const (
+		numAddresses  = 200
+		trieCacheSize = 10_000
+	)
+	u.GenerateAddressesWithFaucetAmount(1, numAddresses, 10_000)
+
+	b.Run("no cache", func(b *testing.B) {
+		rdr := multistate.MustNewReadable(u.StateStore(), u.Root(), trieCacheSize)
+		for i := 0; i < b.N; i++ {
+			rdr.AccountsByLocks()
+		}
+	})
+	b.Run("cache", func(b *testing.B) {
+		rdr := multistate.MustNewReadable(u.StateStore(), u.Root(), trieCacheSize, 2*numAddresses)
+		for i := 0; i < b.N; i++ {
+			rdr.AccountsByLocks()
+		}
+	})
+}
+
+func TestExportImportUTXOSet(t *testing.T) {
+	u := utxodb.NewUTXODB(genesisPrivateKey, true)
+	privKeys, _, addrs := u.GenerateAddressesWithFaucetAmount(1, 10, 100_000_000_000)
+	_, err := u.CreateChainOrigin(privKeys[0], ledger.TimeNow())
+	require.NoError(t, err)
+
+	rdr := u.StateReader()
+	var buf1, buf2 bytes.Buffer
+	err = rdr.ExportUTXOSet(&buf1)
+	require.NoError(t, err)
+	err = rdr.ExportUTXOSet(&buf2)
+	require.NoError(t, err)
+	// export is deterministic
+	require.EqualValues(t, buf1.Bytes(), buf2.Bytes())
+
+	store := common.NewInMemoryKVStore()
+	upd, err := multistate.ImportUTXOSet(store, bytes.NewReader(buf1.Bytes()), rdr.Root())
+	require.NoError(t, err)
+	// imported state reproduces the source state
+	require.True(t, ledger.CommitmentModel.EqualCommitments(rdr.Root(), upd.Root()))
+
+	imported := upd.Readable()
+	require.EqualValues(t, rdr.MustLedgerIdentityBytes(), imported.MustLedgerIdentityBytes())
+	require.EqualValues(t, rdr.AccountsByLocks(), imported.AccountsByLocks())
+	require.EqualValues(t, len(rdr.ChainInfo()), len(imported.ChainInfo()))
+	for _, addr := range addrs {
+		ids, err := rdr.GetIDsLockedInAccount(addr.AccountID())
+		require.NoError(t, err)
+		idsImported, err := imported.GetIDsLockedInAccount(addr.AccountID())
+		require.NoError(t, err)
+		require.EqualValues(t, ids, idsImported)
+	}
+	require.NoError(t, imported.VerifyIntegrity())
+
+	// corrupted output data must be detected
+	corrupted := bytes.Clone(buf1.Bytes())
+	corrupted[len(corrupted)-1] ^= 0xff
+	_, err = multistate.ImportUTXOSet(common.NewInMemoryKVStore(), bytes.NewReader(corrupted))
+	require.Error(t, err)
+
+	// truncated export must be detected
+	_, err = multistate.ImportUTXOSet(common.NewInMemoryKVStore(), bytes.NewReader(buf1.Bytes()[:buf1.Len()-10]))
+	require.Error(t, err)
+
+	// export of another state is consistent with itself, but it is rejected by the trusted root
+	trustedRoot := rdr.Root()
+	require.NoError(t, u.TokensFromFaucet(addrs[0], 1000))
+	var buf3 bytes.Buffer
+	require.NoError(t, u.StateReader().ExportUTXOSet(&buf3))
+	_, err = multistate.ImportUTXOSet(common.NewInMemoryKVStore(), bytes.NewReader(buf3.Bytes()))
+	require.NoError(t, err)
+	_, err = multistate.ImportUTXOSet(common.NewInMemoryKVStore(), bytes.NewReader(buf3.Bytes()), trustedRoot)
+	util.RequireErrorWith(t, err, "trusted root")
+
+	// huge length in the input is rejected without allocation
+	huge := bytes.Clone(buf1.Bytes()[:1])
+	huge = append(huge, 0xff, 0xff, 0xff, 0xff)
+	_, err = multistate.ImportUTXOSet(common.NewInMemoryKVStore(), bytes.NewReader(huge))
+	util.RequireErrorWith(t, err, "exceeds maximum")
+}
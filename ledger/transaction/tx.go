@@ -163,6 +163,22 @@ func CheckTimestampUpperBound(upperBound time.Time) TxValidationOption {
 	}
 }
 
+// CheckBranchTimestampTick rejects sequencer transactions which claim to be a branch (contain stem output index)
+// while their timestamp is not on the slot boundary
+func CheckBranchTimestampTick() TxValidationOption {
+	return func(tx *Transaction) error {
+		if !tx.sequencerMilestoneFlag {
+			return nil
+		}
+		outputIndexData := tx.tree.BytesAtPath(Path(ledger.TxSequencerAndStemOutputIndices))
+		util.Assertf(len(outputIndexData) == 2, "len(outputIndexData) == 2")
+		if outputIndexData[1] != 0xff && tx.timestamp.Tick() != 0 {
+			return fmt.Errorf("branch transaction must have tick 0 in the timestamp: %s", tx.timestamp.String())
+		}
+		return nil
+	}
+}
+
 func ScanSequencerData() TxValidationOption {
 	return func(tx *Transaction) error {
 		if !tx.sequencerMilestoneFlag {
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"sync"
 
 	"github.com/lunfardo314/easyfl"
 	"github.com/lunfardo314/proxima/ledger"
@@ -149,14 +150,21 @@ func (ctx *TxContext) _validateOutputs(consumedBranch bool, failFast bool) (uint
 	}
 	var lastErr error
 	var sum uint64
-	var extraDepositWeight uint32
 	var failedOutputs bytes.Buffer
 
+	if consumedBranch && ctx.traceOption == TraceOptionNone {
+		if workers := int(__parallelInputWorkers.Load()); workers > 1 && ctx.NumInputs() >= ParallelInputValidationThreshold {
+			return ctx._validateConsumedOutputsParallel(workers, failFast)
+		}
+	}
+
 	path := common.Concat(branch, 0)
 	ctx.tree.ForEach(func(i byte, data []byte) bool {
-		var err error
 		path[len(path)-1] = i
-		o, err := ledger.OutputFromBytesReadOnly(data)
+		amount, err := ctx.validateOutput(consumedBranch, data, path)
+		if err == nil && amount > math.MaxUint64-sum {
+			err = fmt.Errorf("validateOutputsFailFast @ path %s: uint64 arithmetic overflow", PathToString(path))
+		}
 		if err != nil {
 			if !failFast {
 				failedOutputs.WriteByte(i)
@@ -164,41 +172,97 @@ func (ctx *TxContext) _validateOutputs(consumedBranch bool, failFast bool) (uint
 			lastErr = err
 			return !failFast
 		}
+		sum += amount
+		return true
+	}, branch)
+	if lastErr != nil {
+		util.Assertf(failFast || failedOutputs.Len() > 0, "failedOutputs.Len()>0")
+		return 0, failedOutputs.Bytes(), lastErr
+	}
+	return sum, nil, nil
+}
 
-		if extraDepositWeight, err = ctx.runOutput(consumedBranch, o, path); err != nil {
-			if !failFast {
-				failedOutputs.WriteByte(i)
-			}
-			lastErr = fmt.Errorf("%w :\n%s", err, o.ToString("   "))
-			return !failFast
-		}
-		minDeposit := ledger.MinimumStorageDeposit(o, extraDepositWeight)
-		amount := o.Amount()
-		if amount < minDeposit {
-			lastErr = fmt.Errorf("not enough storage deposit in output %s. Minimum %d, got %d",
-				PathToString(path), minDeposit, amount)
-			if !failFast {
-				failedOutputs.WriteByte(i)
+// _validateConsumedOutputsParallel validates consumed outputs, including signature unlocks, by up to 'workers' goroutines.
+// Each worker evaluates constraints in its own data context. Results are combined in the order of inputs,
+// so the outcome is the same as of the sequential validation
+func (ctx *TxContext) _validateConsumedOutputsParallel(workers int, failFast bool) (uint64, []byte, error) {
+	branch := Path(ledger.ConsumedBranch, ledger.ConsumedOutputsBranch)
+	numInputs := ctx.NumInputs()
+	amounts := make([]uint64, numInputs)
+	errs := make([]error, numInputs)
+	var next atomic.Int32
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+
+	for w := 0; w < min(workers, numInputs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			workerCtx := *ctx
+			workerCtx.dataContext = ledger.NewDataContext(ctx.tree)
+			for {
+				i := int(next.Inc()) - 1
+				if i >= numInputs || (failFast && failed.Load()) {
+					return
+				}
+				path := common.Concat(branch, byte(i))
+				errs[i] = util.CatchPanicOrError(func() error {
+					var err error
+					amounts[i], err = workerCtx.validateOutput(true, ctx.ConsumedOutputData(byte(i)), path)
+					return err
+				})
+				if errs[i] != nil {
+					failed.Store(true)
+				}
 			}
-			return !failFast
+		}()
+	}
+	wg.Wait()
+
+	var lastErr error
+	var sum uint64
+	var failedOutputs bytes.Buffer
+	for i := 0; i < numInputs; i++ {
+		err := errs[i]
+		if err == nil && amounts[i] > math.MaxUint64-sum {
+			err = fmt.Errorf("validateOutputsFailFast @ path %s: uint64 arithmetic overflow", PathToString(common.Concat(branch, byte(i))))
 		}
-		if amount > math.MaxUint64-sum {
-			lastErr = fmt.Errorf("validateOutputsFailFast @ path %s: uint64 arithmetic overflow", PathToString(path))
-			if !failFast {
-				failedOutputs.WriteByte(i)
+		if err != nil {
+			lastErr = err
+			if failFast {
+				break
 			}
-			return !failFast
+			failedOutputs.WriteByte(byte(i))
+			continue
 		}
-		sum += amount
-		return true
-	}, branch)
+		sum += amounts[i]
+	}
 	if lastErr != nil {
-		util.Assertf(failFast || failedOutputs.Len() > 0, "failedOutputs.Len()>0")
 		return 0, failedOutputs.Bytes(), lastErr
 	}
 	return sum, nil, nil
 }
 
+// validateOutput parses output, runs its constraints and checks storage deposit. Returns amount of the output
+func (ctx *TxContext) validateOutput(consumedBranch bool, data []byte, path lazybytes.TreePath) (uint64, error) {
+	o, err := ledger.OutputFromBytesReadOnly(data)
+	if err != nil {
+		return 0, err
+	}
+	extraDepositWeight, err := ctx.runOutput(consumedBranch, o, path)
+	if err != nil {
+		return 0, fmt.Errorf("%w :\n%s", err, o.ToString("   "))
+	}
+	minDeposit := ledger.MinimumStorageDeposit(o, extraDepositWeight)
+	amount := o.Amount()
+	if amount < minDeposit {
+		return 0, fmt.Errorf("not enough storage deposit in output %s. Minimum %d, got %d",
+			PathToString(path), minDeposit, amount)
+	}
+	return amount, nil
+}
+
 func (ctx *TxContext) UnlockParams(consumedOutputIdx, constraintIdx byte) []byte {
 	return ctx.tree.BytesAtPath(Path(ledger.TransactionBranch, ledger.TxUnlockData, consumedOutputIdx, constraintIdx))
 }
@@ -387,3 +451,16 @@ func printTraceIfEnabled(evalCtx easyfl.GlobalData) {
 func SetPrintEasyFLTraceOnFail(v bool) {
 	__printLogOnFail.Store(v)
 }
+
+// ParallelInputValidationThreshold transactions with fewer inputs are always validated sequentially
+const ParallelInputValidationThreshold = 16
+
+// __parallelInputWorkers is global var for the maximum number of goroutines validating inputs of one transaction
+var __parallelInputWorkers atomic.Int32
+
+// SetParallelInputValidation enables validation of consumed outputs of the transaction (including signature unlocks)
+// by up to 'workers' goroutines. Only transactions with at least ParallelInputValidationThreshold inputs are
+// validated in parallel. workers <= 1 means sequential validation (default)
+func SetParallelInputValidation(workers int) {
+	__parallelInputWorkers.Store(int32(workers))
+}
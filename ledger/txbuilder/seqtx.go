@@ -1,7 +1,11 @@
 package txbuilder
 
 import (
+	"bytes"
 	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math"
 
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/util"
@@ -31,8 +35,70 @@ type MakeSequencerTransactionParams struct {
 	PrivateKey ed25519.PrivateKey
 	// PutInflation if true, calculates maximum inflation possible
 	// if false, does not add inflation constraint at all
-	PutInflation      bool
-	ReturnInputLoader bool
+	PutInflation bool
+	// BranchInflationOverride if not nil, replaces the branch inflation bonus calculated from the VRF proof.
+	// Used only for branch transactions with inflation. Must not exceed ledger's BranchInflationBonusBase.
+	// Note that the ledger enforces branch inflation bonus derived from the VRF proof, so the transaction
+	// with a different value will be rejected. Intended for testing
+	BranchInflationOverride *uint64
+	ReturnInputLoader       bool
+	// OutputCache optional cache of outputs, shared among transaction builders with overlapping inputs.
+	// If provided, consumed outputs are always put into the cache, so that callers can skip loading them
+	// for the next transaction (see OutputCache.Load). The returned input loader, if any, serves outputs from it.
+	// By default, input loader is backed by the slice of consumed outputs
+	OutputCache OutputCache
+	// AdditionalChainOutputConstraints optional constraints pushed onto the chain output after the milestone data
+	// and inflation constraint. Milestone data remains at the fixed index ledger.MilestoneDataFixedIndex
+	AdditionalChainOutputConstraints []ledger.Constraint
+	// DryRun if true, the builder does not sign and produce the transaction, it only returns its economics.
+	// Private key is not needed, except for branch transactions with inflation. Only PreviewSequencerTransaction
+	// supports dry run, MakeSequencerTransaction rejects it
+	DryRun bool
+}
+
+// SequencerTxPreview is the economics of the sequencer transaction calculated by the builder in dry run mode
+type SequencerTxPreview struct {
+	// InflateBy total inflation amount of the transaction, including branch inflation bonus
+	InflateBy uint64
+	// ChainOutAmount amount on the sequencer chain output
+	ChainOutAmount uint64
+	// TotalProducedAmount sum of amounts of all produced outputs
+	TotalProducedAmount uint64
+	// PutInflationConstraint is true if the inflation constraint is put on the chain output
+	PutInflationConstraint bool
+}
+
+var (
+	ErrInvalidParams      = errors.New("invalid parameters")
+	ErrInvalidTimestamp   = errors.New("invalid timestamp")
+	ErrTooManyInputs      = errors.New("too many inputs")
+	ErrNotChainOutput     = errors.New("not a chain output")
+	ErrMissingEndorsement = errors.New("missing endorsement")
+	ErrInvalidEndorsement = errors.New("invalid endorsement")
+	ErrNotEnoughTokens    = errors.New("not enough tokens")
+	ErrArithmeticOverflow = errors.New("arithmetic overflow")
+)
+
+// maxNumberOfConstraints is maximum number of constraints in the output
+const maxNumberOfConstraints = 256
+
+// OutputCache is a cache of outputs by output ID. Not thread safe
+type OutputCache map[ledger.OutputID]*ledger.Output
+
+// Load returns output from the cache. If output is not in the cache, it is loaded with the provided function
+// and put into the cache. Nil cache always loads
+func (c OutputCache) Load(oid ledger.OutputID, load func() (*ledger.Output, error)) (*ledger.Output, error) {
+	if o, ok := c[oid]; ok {
+		return o, nil
+	}
+	o, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if c != nil {
+		c[oid] = o
+	}
+	return o, nil
 }
 
 func MakeSequencerTransaction(par MakeSequencerTransactionParams) ([]byte, error) {
@@ -40,15 +106,35 @@ func MakeSequencerTransaction(par MakeSequencerTransactionParams) ([]byte, error
 	return ret, err
 }
 
+// MakeSequencerTransactionWithInputLoader builds and signs the sequencer transaction and returns its bytes and input loader.
+// Dry run is not supported, use PreviewSequencerTransaction instead
 func MakeSequencerTransactionWithInputLoader(par MakeSequencerTransactionParams) ([]byte, func(i byte) (*ledger.Output, error), error) {
+	if par.DryRun {
+		return nil, nil, seqTxErr(ErrInvalidParams, "dry run is not supported. Use PreviewSequencerTransaction instead")
+	}
+	_, txBytes, inputLoader, err := makeSequencerTransaction(par)
+	return txBytes, inputLoader, err
+}
+
+// PreviewSequencerTransaction runs the sequencer transaction builder in dry run mode. It returns economics of the
+// sequencer transaction without producing and signing it. Private key is not needed, except for branch transactions
+// with inflation, because branch inflation bonus depends on the VRF proof
+func PreviewSequencerTransaction(par MakeSequencerTransactionParams) (*SequencerTxPreview, error) {
+	par.DryRun = true
+	ret, _, _, err := makeSequencerTransaction(par)
+	return ret, err
+}
+
+func makeSequencerTransaction(par MakeSequencerTransactionParams) (*SequencerTxPreview, []byte, func(i byte) (*ledger.Output, error), error) {
 	var consumedOutputs []*ledger.Output
-	if par.ReturnInputLoader {
+	var consumedIDs []ledger.OutputID
+	collectConsumed := par.ReturnInputLoader || par.OutputCache != nil
+	if collectConsumed {
 		consumedOutputs = make([]*ledger.Output, 0)
+		consumedIDs = make([]ledger.OutputID, 0)
 	}
-	errP := util.MakeErrFuncForPrefix("MakeSequencerTransaction")
-
 	if !par.Timestamp.IsSlotBoundary() && !ledger.L().ID.IsPostBranchConsolidationTimestamp(par.Timestamp) {
-		return nil, nil, errP("timestamp violates post-branch timestamp constraint: %s", par.Timestamp.String())
+		return nil, nil, nil, seqTxErr(ErrInvalidTimestamp, "timestamp violates post-branch timestamp constraint: %s", par.Timestamp.String())
 	}
 	nIn := len(par.AdditionalInputs) + 1
 	if par.StemInput != nil {
@@ -56,35 +142,50 @@ func MakeSequencerTransactionWithInputLoader(par MakeSequencerTransactionParams)
 	}
 	switch {
 	case nIn > 256:
-		return nil, nil, errP("too many inputs")
+		return nil, nil, nil, seqTxErr(ErrTooManyInputs, "number of inputs %d exceeds 256", nIn)
 	case par.StemInput != nil && par.Timestamp.Tick() != 0:
-		return nil, nil, errP("wrong timestamp for branch transaction: %s", par.Timestamp.String())
+		return nil, nil, nil, seqTxErr(ErrInvalidTimestamp, "wrong timestamp for branch transaction: %s", par.Timestamp.String())
 	case par.Timestamp.Slot() > par.ChainInput.ID.Slot() && par.Timestamp.Tick() != 0 && len(par.Endorsements) == 0:
-		return nil, nil, errP("cross-slot sequencer tx must endorse another sequencer tx: chain input ts: %s, target: %s",
+		return nil, nil, nil, seqTxErr(ErrMissingEndorsement, "cross-slot sequencer tx must endorse another sequencer tx: chain input ts: %s, target: %s",
 			par.ChainInput.ID.Timestamp(), par.Timestamp)
 	case !par.ChainInput.ID.IsSequencerTransaction() && par.StemInput == nil && len(par.Endorsements) == 0:
-		return nil, nil, errP("chain predecessor is not a sequencer transaction -> endorsement of sequencer transaction is mandatory (unless making a branch)")
+		return nil, nil, nil, seqTxErr(ErrMissingEndorsement, "chain predecessor is not a sequencer transaction -> endorsement of sequencer transaction is mandatory (unless making a branch)")
+	}
+	if err := checkEndorsements(par.Endorsements, par.Timestamp); err != nil {
+		return nil, nil, nil, seqTxErr(ErrInvalidEndorsement, "%v", err)
+	}
+	if err := checkAdditionalChainOutputConstraints(par.AdditionalChainOutputConstraints, par.PutInflation); err != nil {
+		return nil, nil, nil, seqTxErr(ErrInvalidParams, "%v", err)
 	}
 
 	chainInConstraint, chainInConstraintIdx := par.ChainInput.Output.ChainConstraint()
 	if chainInConstraintIdx == 0xff {
-		return nil, nil, errP("not a chain output: %s", par.ChainInput.ID.StringShort())
+		return nil, nil, nil, seqTxErr(ErrNotChainOutput, "not a chain output: %s", par.ChainInput.ID.StringShort())
 	}
 
 	txb := NewTransactionBuilder()
 	// count sums
 	additionalIn, additionalOut := uint64(0), uint64(0)
 	for _, o := range par.AdditionalInputs {
+		if o.Output.Amount() > math.MaxUint64-additionalIn {
+			return nil, nil, nil, seqTxErr(ErrArithmeticOverflow, "arithmetic overflow when calculating total amount of additional inputs")
+		}
 		additionalIn += o.Output.Amount()
 	}
 	for _, o := range par.AdditionalOutputs {
+		if o.Amount() > math.MaxUint64-additionalOut {
+			return nil, nil, nil, seqTxErr(ErrArithmeticOverflow, "arithmetic overflow when calculating total amount of additional outputs")
+		}
 		additionalOut += o.Amount()
 	}
 	chainInAmount := par.ChainInput.Output.Amount()
 
+	if additionalIn > math.MaxUint64-chainInAmount {
+		return nil, nil, nil, seqTxErr(ErrArithmeticOverflow, "arithmetic overflow when calculating total amount of inputs")
+	}
 	totalInAmount := chainInAmount + additionalIn
 	if totalInAmount < additionalOut {
-		return nil, nil, errP("not enough tokens in the input")
+		return nil, nil, nil, seqTxErr(ErrNotEnoughTokens, "not enough tokens in the input")
 	}
 
 	var inflationAmount uint64
@@ -100,6 +201,9 @@ func MakeSequencerTransactionWithInputLoader(par MakeSequencerTransactionParams)
 			inflationAmount = inflationConstraint.ChainInflation
 		} else {
 			// branch transaction. Generate verifiable randomness. It will be used to deterministically calculate inflation amount
+			if len(par.PrivateKey) != ed25519.PrivateKeySize {
+				return nil, nil, nil, seqTxErr(ErrInvalidParams, "private key is required to calculate branch inflation")
+			}
 			pubKey := par.PrivateKey.Public().(ed25519.PublicKey)
 			var err error
 
@@ -107,7 +211,7 @@ func MakeSequencerTransactionWithInputLoader(par MakeSequencerTransactionParams)
 			// using stem predecessor ID as msg for VRF to randomize branch inflation for the same sequencer even on the same slot
 			inflationConstraint.VRFProof, _, err = vrf.Prove(pubKey, par.PrivateKey, par.StemInput.ID[:])
 			if err != nil {
-				return nil, nil, errP(err, "while generating VRF randomness proof")
+				return nil, nil, nil, seqTxErr(ErrInvalidParams, "while generating VRF randomness proof: %v", err)
 			}
 
 			{
@@ -117,14 +221,25 @@ func MakeSequencerTransactionWithInputLoader(par MakeSequencerTransactionParams)
 				util.AssertNoError(err, "MakeSequencerTransactionWithInputLoader: verify VRF proof")
 				util.Assertf(ok, "MakeSequencerTransactionWithInputLoader: verify VRF proof")
 			}
-			inflationAmount = ledger.L().BranchInflationBonusFromRandomnessProof(inflationConstraint.VRFProof)
+			if par.BranchInflationOverride == nil {
+				inflationAmount = ledger.L().BranchInflationBonusFromRandomnessProof(inflationConstraint.VRFProof)
+			} else {
+				if *par.BranchInflationOverride > ledger.L().ID.BranchInflationBonusBase {
+					return nil, nil, nil, seqTxErr(ErrInvalidParams, "branch inflation override %s exceeds maximum %s",
+						util.Th(*par.BranchInflationOverride), util.Th(ledger.L().ID.BranchInflationBonusBase))
+				}
+				inflationAmount = *par.BranchInflationOverride
+			}
 		}
 	}
 
+	if inflationAmount > math.MaxUint64-totalInAmount {
+		return nil, nil, nil, seqTxErr(ErrArithmeticOverflow, "arithmetic overflow when calculating inflated amount")
+	}
 	chainOutAmount := totalInAmount + inflationAmount - additionalOut // >= 0
 
 	if chainOutAmount < ledger.L().Const().MinimumAmountOnSequencer() {
-		return nil, nil, errP("amount on the chain output is below minimum required for the sequencer: %s",
+		return nil, nil, nil, seqTxErr(ErrNotEnoughTokens, "amount on the chain output is below minimum required for the sequencer: %s",
 			util.Th(ledger.L().Const().MinimumAmountOnSequencer()))
 	}
 
@@ -134,10 +249,11 @@ func MakeSequencerTransactionWithInputLoader(par MakeSequencerTransactionParams)
 	// make chain input/output
 	chainPredIdx, err := txb.ConsumeOutput(par.ChainInput.Output, par.ChainInput.ID)
 	if err != nil {
-		return nil, nil, errP(err)
+		return nil, nil, nil, fmt.Errorf("MakeSequencerTransaction: %w", err)
 	}
-	if par.ReturnInputLoader {
+	if collectConsumed {
 		consumedOutputs = append(consumedOutputs, par.ChainInput.Output)
+		consumedIDs = append(consumedIDs, par.ChainInput.ID)
 	}
 	txb.PutSignatureUnlock(chainPredIdx)
 
@@ -182,11 +298,14 @@ func MakeSequencerTransactionWithInputLoader(par MakeSequencerTransactionParams)
 			_, _ = o.PushConstraint(inflationConstraint.Bytes())
 			//fmt.Printf(">>>>>>>>>>>>>>> push %s\n", inflationConstraint.String())
 		}
+		for _, c := range par.AdditionalChainOutputConstraints {
+			_, _ = o.PushConstraint(c.Bytes())
+		}
 	})
 
 	chainOutIndex, err := txb.ProduceOutput(chainOut)
 	if err != nil {
-		return nil, nil, errP(err)
+		return nil, nil, nil, fmt.Errorf("MakeSequencerTransaction: %w", err)
 	}
 	// unlock chain input (chain constraint unlock + inflation (optionally)
 	txb.PutUnlockParams(chainPredIdx, chainInConstraintIdx, ledger.NewChainUnlockParams(chainOutIndex, chainOutConstraintIdx, 0))
@@ -196,10 +315,11 @@ func MakeSequencerTransactionWithInputLoader(par MakeSequencerTransactionParams)
 	if par.StemInput != nil {
 		_, err = txb.ConsumeOutput(par.StemInput.Output, par.StemInput.ID)
 		if err != nil {
-			return nil, nil, errP(err)
+			return nil, nil, nil, fmt.Errorf("MakeSequencerTransaction: %w", err)
 		}
-		if par.ReturnInputLoader {
+		if collectConsumed {
 			consumedOutputs = append(consumedOutputs, par.StemInput.Output)
+			consumedIDs = append(consumedIDs, par.StemInput.ID)
 		}
 
 		stemOut := ledger.NewOutput(func(o *ledger.Output) {
@@ -210,7 +330,7 @@ func MakeSequencerTransactionWithInputLoader(par MakeSequencerTransactionParams)
 		})
 		stemOutputIndex, err = txb.ProduceOutput(stemOut)
 		if err != nil {
-			return nil, nil, errP(err)
+			return nil, nil, nil, fmt.Errorf("MakeSequencerTransaction: %w", err)
 		}
 	}
 
@@ -220,48 +340,165 @@ func MakeSequencerTransactionWithInputLoader(par MakeSequencerTransactionParams)
 	for _, o := range par.AdditionalInputs {
 		idx, err := txb.ConsumeOutput(o.Output, o.ID)
 		if err != nil {
-			return nil, nil, errP(err)
+			return nil, nil, nil, fmt.Errorf("MakeSequencerTransaction: %w", err)
 		}
-		if par.ReturnInputLoader {
+		if collectConsumed {
 			consumedOutputs = append(consumedOutputs, o.Output)
+			consumedIDs = append(consumedIDs, o.ID)
 		}
 		switch lockName := o.Output.Lock().Name(); lockName {
 		case ledger.AddressED25519Name:
 			if err = txb.PutUnlockReference(idx, ledger.ConstraintIndexLock, 0); err != nil {
-				return nil, nil, err
+				return nil, nil, nil, fmt.Errorf("MakeSequencerTransaction: %w", err)
 			}
 		case ledger.ChainLockName:
-			txb.PutUnlockParams(idx, ledger.ConstraintIndexLock, ledger.NewChainLockUnlockParams(0, chainInConstraintIdx))
+			txb.PutUnlockParams(idx, ledger.ConstraintIndexLock, ledger.NewChainLockUnlockParams(chainPredIdx, chainInConstraintIdx))
 		default:
-			return nil, nil, errP("unsupported type of additional input: %s", lockName)
+			return nil, nil, nil, seqTxErr(ErrInvalidParams, "unsupported type of additional input: %s", lockName)
 		}
 		tsIn = ledger.MaximumTime(tsIn, o.Timestamp())
 	}
 
 	if !ledger.ValidSequencerPace(tsIn, par.Timestamp) {
-		return nil, nil, errP("timestamp %s is inconsistent with latest input timestamp %s", par.Timestamp.String(), tsIn.String())
+		return nil, nil, nil, seqTxErr(ErrInvalidTimestamp, "timestamp %s is inconsistent with latest input timestamp %s", par.Timestamp.String(), tsIn.String())
 	}
 
 	_, err = txb.ProduceOutputs(par.AdditionalOutputs...)
 	if err != nil {
-		return nil, nil, errP(err)
+		return nil, nil, nil, fmt.Errorf("MakeSequencerTransaction: %w", err)
 	}
 	txb.PushEndorsements(par.Endorsements...)
 	txb.TransactionData.Timestamp = par.Timestamp
 	txb.TransactionData.SequencerOutputIndex = chainOutIndex
 	txb.TransactionData.StemOutputIndex = stemOutputIndex
 	txb.TransactionData.InputCommitment = txb.InputCommitment()
+	preview := &SequencerTxPreview{
+		InflateBy:              inflationAmount,
+		ChainOutAmount:         chainOutAmount,
+		TotalProducedAmount:    totalOutAmount,
+		PutInflationConstraint: inflationConstraint != nil,
+	}
+	if par.OutputCache != nil {
+		par.OutputCache.put(consumedIDs, consumedOutputs)
+	}
+	if par.DryRun {
+		return preview, nil, nil, nil
+	}
 	txb.SignED25519(par.PrivateKey)
 
 	inputLoader := func(i byte) (*ledger.Output, error) {
 		panic("MakeSequencerTransactionWithInputLoader: par.ReturnInputLoader parameter must be set to true")
 	}
 	if par.ReturnInputLoader {
-		inputLoader = func(i byte) (*ledger.Output, error) {
-			return consumedOutputs[i], nil
+		if par.OutputCache == nil {
+			inputLoader = func(i byte) (*ledger.Output, error) {
+				return consumedOutputs[i], nil
+			}
+		} else {
+			inputLoader = par.OutputCache.inputLoader(consumedIDs, consumedOutputs)
+		}
+	}
+	return preview, txb.TransactionData.Bytes(), inputLoader, nil
+}
+
+// seqTxErr wraps sentinel error of the sequencer transaction builder with the message
+func seqTxErr(sentinel error, format string, args ...any) error {
+	return fmt.Errorf("MakeSequencerTransaction: %w: %s", sentinel, fmt.Sprintf(format, args...))
+}
+
+// put puts consumed outputs into the cache, if not there yet
+func (c OutputCache) put(ids []ledger.OutputID, outs []*ledger.Output) {
+	for i := range ids {
+		if _, already := c[ids[i]]; !already {
+			c[ids[i]] = outs[i]
 		}
 	}
-	return txb.TransactionData.Bytes(), inputLoader, nil
+}
+
+// inputLoader returns input loader served from the cache.
+// Falls back to consumed outputs if output is missing in the cache
+func (c OutputCache) inputLoader(ids []ledger.OutputID, outs []*ledger.Output) func(i byte) (*ledger.Output, error) {
+	return func(i byte) (*ledger.Output, error) {
+		if int(i) >= len(ids) {
+			return nil, fmt.Errorf("wrong input index %d", i)
+		}
+		if o, ok := c[ids[i]]; ok {
+			return o, nil
+		}
+		return outs[i], nil
+	}
+}
+
+// AddTagAlongOutputs appends tag-along fee outputs, one per sequencer, to the additional outputs of the sequencer
+// transaction. Outputs are chain-locked to the sequencers and are added in the order of chain IDs to make the
+// resulting transaction deterministic. The builder deducts fees from the amount on the chain output
+func AddTagAlongOutputs(par *MakeSequencerTransactionParams, fees map[ledger.ChainID]uint64) error {
+	seqIDs := util.KeysSorted(fees, func(id1, id2 ledger.ChainID) bool {
+		return bytes.Compare(id1[:], id2[:]) < 0
+	})
+	total := uint64(0)
+	for _, seqID := range seqIDs {
+		amount := fees[seqID]
+		if amount == 0 {
+			return fmt.Errorf("AddTagAlongOutputs: %w: zero tag-along fee for %s", ErrInvalidParams, seqID.StringShort())
+		}
+		if amount > math.MaxUint64-total {
+			return fmt.Errorf("AddTagAlongOutputs: %w: arithmetic overflow when calculating total tag-along fee", ErrArithmeticOverflow)
+		}
+		total += amount
+	}
+	for _, seqID := range seqIDs {
+		par.AdditionalOutputs = append(par.AdditionalOutputs, ledger.NewOutput(func(o *ledger.Output) {
+			o.WithAmount(fees[seqID]).WithLock(ledger.ChainLockFromChainID(seqID))
+		}))
+	}
+	return nil
+}
+
+// checkEndorsements checks if endorsements can be put into the sequencer transaction with the timestamp:
+// endorsed transactions must be sequencer transactions on the same slot as the target and must respect
+// sequencer time pace. Otherwise, transaction would be rejected only later, during attachment
+func checkEndorsements(endorsements []*ledger.TransactionID, ts ledger.Time) error {
+	if len(endorsements) > int(ledger.L().ID.MaxNumberOfEndorsements) {
+		return fmt.Errorf("number of endorsements %d exceeds limit of %d", len(endorsements), ledger.L().ID.MaxNumberOfEndorsements)
+	}
+	for i, e := range endorsements {
+		switch {
+		case !e.IsSequencerMilestone():
+			return fmt.Errorf("endorsement %s is not a sequencer transaction", e.StringShort())
+		case e.Slot() != ts.Slot():
+			return fmt.Errorf("endorsement %s is not on the slot of the target timestamp %s", e.StringShort(), ts.String())
+		case !ledger.ValidSequencerPace(e.Timestamp(), ts):
+			return fmt.Errorf("endorsement %s violates sequencer time pace with the target timestamp %s", e.StringShort(), ts.String())
+		}
+		for _, prev := range endorsements[:i] {
+			if *prev == *e {
+				return fmt.Errorf("repeating endorsement %s", e.StringShort())
+			}
+		}
+	}
+	return nil
+}
+
+// checkAdditionalChainOutputConstraints checks if additional constraints fit into the chain output.
+// Constraints which are recognized in the chain output by name are not allowed, because they would be ambiguous
+func checkAdditionalChainOutputConstraints(constraints []ledger.Constraint, putInflation bool) error {
+	// amount, lock, chain, sequencer, milestone data
+	numConstraints := ledger.MilestoneDataFixedIndex + 1
+	if putInflation {
+		numConstraints++
+	}
+	if numConstraints+len(constraints) > maxNumberOfConstraints {
+		return fmt.Errorf("too many additional chain output constraints: %d. Total number of constraints must not exceed %d",
+			len(constraints), maxNumberOfConstraints)
+	}
+	for _, c := range constraints {
+		switch name := c.Name(); name {
+		case ledger.ChainConstraintName, ledger.SequencerConstraintName, ledger.InflationConstraintName:
+			return fmt.Errorf("additional chain output constraint '%s' is not allowed", name)
+		}
+	}
+	return nil
 }
 
 func calcChainInflationAmount(chainInput *ledger.OutputWithChainID, ts ledger.Time) (uint64, byte) {
@@ -290,3 +527,75 @@ func calcChainInflationAmount(chainInput *ledger.OutputWithChainID, ts ledger.Ti
 
 	return ret, idx
 }
+
+// ChainInflationState inflation state of the chain output with respect to its successor with particular timestamp
+type ChainInflationState struct {
+	// HasInflationConstraint output contains inflation constraint
+	HasInflationConstraint bool
+	// ChainInflation inflation amount in the inflation constraint of the output. 0 if constraint is not present
+	ChainInflation uint64
+	// Delayed inflation amount added to the inflation of the successor. Only branch outputs delay their inflation
+	Delayed uint64
+	// Continues is true if the successor is inside the inflation opportunity window and accrues chain inflation.
+	// Otherwise, inflation resets: inflation of the successor consists of the delayed amount only
+	Continues bool
+	// SuccessorInflation chain inflation amount of the successor with the timestamp
+	SuccessorInflation uint64
+}
+
+// InflationState returns inflation state of the chain output with respect to the successor with timestamp ts,
+// the same way MakeSequencerTransaction calculates inflation of the successor
+func InflationState(o *ledger.OutputWithChainID, ts ledger.Time) (ret ChainInflationState) {
+	inflationConstraint, idx := o.Output.InflationConstraint()
+	if idx != 0xff {
+		ret.HasInflationConstraint = true
+		ret.ChainInflation = inflationConstraint.ChainInflation
+		if o.ID.IsBranchTransaction() {
+			ret.Delayed = inflationConstraint.ChainInflation
+		}
+	}
+	if diff := ledger.DiffTicks(ts, o.Timestamp()); diff >= 0 {
+		ret.Continues = uint64(diff)/ledger.TicksPerSlot <= ledger.L().ID.ChainInflationOpportunitySlots
+	}
+	ret.SuccessorInflation, _ = calcChainInflationAmount(o, ts)
+	return
+}
+
+// NextSequencerTimestamp computes the earliest valid timestamp of the sequencer transaction which is not before 'now'.
+// It respects sequencer time pace from the chain input, stem input and endorsements,
+// branch transaction must be on the slot boundary, non-branch transaction must satisfy post-branch consolidation
+// constraint and must endorse another sequencer tx when cross-slot.
+// Endorsements must be on the slot of the resulting timestamp.
+// Pre-branch consolidation constraint is not checked because it depends on the number of inputs
+func NextSequencerTimestamp(chainInput *ledger.OutputWithChainID, stemInput *ledger.OutputWithID, endorsements []*ledger.TransactionID, now ledger.Time) (ledger.Time, error) {
+	errP := util.MakeErrFuncForPrefix("NextSequencerTimestamp")
+	pace := int(ledger.TransactionPaceSequencer())
+
+	ret := ledger.MaximumTime(now, chainInput.Timestamp().AddTicks(pace))
+	if stemInput != nil {
+		// branch transaction
+		if len(endorsements) > 0 {
+			return ledger.NilLedgerTime, errP("branch transaction can't endorse transactions from the same slot")
+		}
+		return ledger.MaximumTime(ret, stemInput.Timestamp().AddTicks(pace)).NextSlotBoundary(), nil
+	}
+	for _, e := range endorsements {
+		ret = ledger.MaximumTime(ret, e.Timestamp().AddTicks(pace))
+	}
+	ret = ledger.L().ID.EnsurePostBranchConsolidationConstraintTimestamp(ret)
+	for _, e := range endorsements {
+		if e.Slot() != ret.Slot() {
+			return ledger.NilLedgerTime, errP("endorsement %s is not on the slot of the target timestamp %s", e.StringShort(), ret.String())
+		}
+	}
+	if len(endorsements) == 0 {
+		switch {
+		case ret.Slot() > chainInput.ID.Slot():
+			return ledger.NilLedgerTime, seqTxErr(ErrMissingEndorsement, "cross-slot sequencer tx must endorse another sequencer tx: chain input ts: %s, target: %s",
+				chainInput.ID.Timestamp().String(), ret.String())
+		case !chainInput.ID.IsSequencerTransaction():
+			return ledger.NilLedgerTime, seqTxErr(ErrMissingEndorsement, "chain predecessor is not a sequencer transaction -> endorsement of sequencer transaction is mandatory")
+		}
+	}
+	return ret, nil
+}
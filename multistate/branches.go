@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
@@ -57,11 +58,23 @@ func FetchEarliestSlot(store common.KVReader) ledger.Slot {
 	return ret
 }
 
-const numberOfElementsInRootRecord = 6
+// Root record is serialized as a lazy array. The legacy format has 6 elements. Starting from
+// rootRecordVersionTotals, the 7th element is the version byte, followed by version-specific elements.
+// Later versions may only append elements, so the known prefix of a newer record is always decodable
+const (
+	rootRecordVersionLegacy = byte(0)
+	rootRecordVersionTotals = byte(1)
+	// rootRecordVersion is the version of the root records written by this node
+	rootRecordVersion = rootRecordVersionTotals
+
+	numberOfElementsInRootRecordLegacy = 6
+	numberOfElementsInRootRecordTotals = 9
+	maxNumberOfElementsInRootRecord    = 256
+)
 
 func (r *RootRecord) Bytes() []byte {
 	util.Assertf(r.LedgerCoverage > 0, "r.Coverage.LatestDelta() > 0")
-	arr := lazybytes.EmptyArray(numberOfElementsInRootRecord)
+	arr := lazybytes.EmptyArray(maxNumberOfElementsInRootRecord)
 	arr.Push(r.SequencerID.Bytes())
 	arr.Push(r.Root.Bytes())
 
@@ -80,10 +93,33 @@ func (r *RootRecord) Bytes() []byte {
 	binary.BigEndian.PutUint32(nTxBin[:], r.NumTransactions)
 
 	arr.Push(nTxBin[:])
-	util.Assertf(arr.NumElements() == numberOfElementsInRootRecord, "arr.NumElements() == 6")
+
+	if r.version == rootRecordVersionLegacy {
+		util.Assertf(arr.NumElements() == numberOfElementsInRootRecordLegacy, "arr.NumElements() == 6")
+		return arr.Bytes()
+	}
+	arr.Push([]byte{r.version})
+
+	var totalOutputsBin, totalChainsBin [8]byte
+	binary.BigEndian.PutUint64(totalOutputsBin[:], r.TotalOutputs)
+	arr.Push(totalOutputsBin[:])
+	binary.BigEndian.PutUint64(totalChainsBin[:], r.TotalChains)
+	arr.Push(totalChainsBin[:])
+
+	util.Assertf(arr.NumElements() == numberOfElementsInRootRecordTotals, "arr.NumElements() == 9")
 	return arr.Bytes()
 }
 
+// Version returns version of the root record format
+func (r *RootRecord) Version() byte {
+	return r.version
+}
+
+// HasTotals returns true if TotalOutputs and TotalChains are present in the record
+func (r *RootRecord) HasTotals() bool {
+	return r.version >= rootRecordVersionTotals
+}
+
 func (r *RootRecord) StringShort() string {
 	return fmt.Sprintf("%s, %s, %s, %d",
 		r.SequencerID.StringShort(), util.Th(r.LedgerCoverage), r.Root.String(), r.NumTransactions)
@@ -118,14 +154,23 @@ func (r *RootRecord) LinesVerbose(prefix ...string) *lines.Lines {
 	ret.Add("root: %s", r.Root.String()).
 		Add("slot inflation: %s", util.Th(r.SlotInflation)).
 		Add("num transactions: %d", r.NumTransactions)
+	if r.HasTotals() {
+		ret.Add("total outputs: %s", util.Th(r.TotalOutputs)).
+			Add("total chains: %s", util.Th(r.TotalChains))
+	}
 	return ret
 }
 
+// RootRecordFromBytes decodes root record of any version. Records of versions newer than rootRecordVersion
+// are decoded partially, only the known elements
 func RootRecordFromBytes(data []byte) (RootRecord, error) {
-	arr, err := lazybytes.ParseArrayFromBytesReadOnly(data, numberOfElementsInRootRecord)
+	arr, err := lazybytes.ParseArrayFromBytesReadOnly(data, maxNumberOfElementsInRootRecord)
 	if err != nil {
 		return RootRecord{}, err
 	}
+	if arr.NumElements() < numberOfElementsInRootRecordLegacy {
+		return RootRecord{}, fmt.Errorf("wrong number of elements in the root record: %d", arr.NumElements())
+	}
 	chainID, err := ledger.ChainIDFromBytes(arr.At(0))
 	if err != nil {
 		return RootRecord{}, err
@@ -137,14 +182,31 @@ func RootRecordFromBytes(data []byte) (RootRecord, error) {
 	if len(arr.At(2)) != 8 || len(arr.At(3)) != 8 || len(arr.At(4)) != 8 || len(arr.At(5)) != 4 {
 		return RootRecord{}, fmt.Errorf("wrong data length")
 	}
-	return RootRecord{
+	ret := RootRecord{
 		Root:            root,
 		SequencerID:     chainID,
 		LedgerCoverage:  binary.BigEndian.Uint64(arr.At(2)),
 		SlotInflation:   binary.BigEndian.Uint64(arr.At(3)),
 		Supply:          binary.BigEndian.Uint64(arr.At(4)),
 		NumTransactions: binary.BigEndian.Uint32(arr.At(5)),
-	}, nil
+	}
+	if arr.NumElements() == numberOfElementsInRootRecordLegacy {
+		return ret, nil
+	}
+	if len(arr.At(6)) != 1 || arr.At(6)[0] == rootRecordVersionLegacy {
+		return RootRecord{}, fmt.Errorf("wrong version of the root record")
+	}
+	if arr.NumElements() < numberOfElementsInRootRecordTotals {
+		return RootRecord{}, fmt.Errorf("wrong number of elements in the root record of version %d: %d", arr.At(6)[0], arr.NumElements())
+	}
+	if len(arr.At(7)) != 8 || len(arr.At(8)) != 8 {
+		return RootRecord{}, fmt.Errorf("wrong data length")
+	}
+	ret.TotalOutputs = binary.BigEndian.Uint64(arr.At(7))
+	ret.TotalChains = binary.BigEndian.Uint64(arr.At(8))
+	// unknown elements of newer versions are not kept
+	ret.version = min(arr.At(6)[0], rootRecordVersion)
+	return ret, nil
 }
 
 func ValidInclusionThresholdFraction(numerator, denominator int) bool {
@@ -229,6 +291,31 @@ func FetchAnyLatestRootRecord(store global.StateStoreReader) RootRecord {
 	return recs[0]
 }
 
+// IterateStems iterates stem outputs of all roots in the last slotsBack slots with root records, until fun returns false.
+// If slotsBack <= 0, stems of all roots in the store are iterated.
+// Stems are ordered by slot descending, the order of stems within the same slot is the order of root records in the DB
+func IterateStems(store global.StateStore, slotsBack int, fun func(slot ledger.Slot, stem *ledger.OutputWithID) bool) {
+	iterateStemsWithRootRecords(store, slotsBack, func(_ RootRecord, stem *ledger.OutputWithID) bool {
+		return fun(stem.ID.Slot(), stem)
+	})
+}
+
+func iterateStemsWithRootRecords(store global.StateStoreReader, slotsBack int, fun func(rr RootRecord, stem *ledger.OutputWithID) bool) {
+	var rootRecords []RootRecord
+	if slotsBack > 0 {
+		rootRecords = FetchRootRecordsNSlotsBack(store, slotsBack)
+	} else {
+		rootRecords = fetchAllRootRecordsSlotDescending(store)
+	}
+	for _, rr := range rootRecords {
+		rdr, err := NewSugaredReadableState(store, rr.Root, 0)
+		util.AssertNoError(err)
+		if !fun(rr, rdr.GetStemOutput()) {
+			return
+		}
+	}
+}
+
 // FetchRootRecordsNSlotsBack load root records from N lates slots, present in the store
 func FetchRootRecordsNSlotsBack(store global.StateStoreReader, nBack int) []RootRecord {
 	if nBack <= 0 {
@@ -258,6 +345,28 @@ func FetchAllRootRecords(store common.Traversable) []RootRecord {
 	return ret
 }
 
+// fetchAllRootRecordsSlotDescending all root records in one pass over the DB, sorted by slot descending.
+// Order of root records within the same slot is the order in the DB
+func fetchAllRootRecordsSlotDescending(store common.Traversable) []RootRecord {
+	type slotRootRecord struct {
+		slot ledger.Slot
+		rr   RootRecord
+	}
+	recs := make([]slotRootRecord, 0)
+	IterateRootRecords(store, func(branchTxID ledger.TransactionID, rootData RootRecord) bool {
+		recs = append(recs, slotRootRecord{slot: branchTxID.Slot(), rr: rootData})
+		return true
+	})
+	sort.SliceStable(recs, func(i, j int) bool {
+		return recs[i].slot > recs[j].slot
+	})
+	ret := make([]RootRecord, len(recs))
+	for i := range recs {
+		ret[i] = recs[i].rr
+	}
+	return ret
+}
+
 // FetchRootRecords returns root records for particular slots in the DB
 func FetchRootRecords(store common.Traversable, slots ...ledger.Slot) []RootRecord {
 	if len(slots) == 0 {
@@ -330,6 +439,22 @@ func FetchLatestBranchTransactionIDs(store global.StateStoreReader) []ledger.Tra
 	return ret
 }
 
+// LatestBranchTime returns timestamp of the latest committed branch and its age relative to ledger.TimeNow().
+// Big age means node is stalled
+func LatestBranchTime(store global.StateStoreReader) (ledger.Time, time.Duration, error) {
+	slot := FetchLatestCommittedSlot(store)
+	var branchID *ledger.TransactionID
+	IterateRootRecords(store, func(txid ledger.TransactionID, _ RootRecord) bool {
+		branchID = &txid
+		return false
+	}, slot)
+	if branchID == nil {
+		return ledger.NilLedgerTime, 0, fmt.Errorf("LatestBranchTime: no root records found in the latest committed slot %d", slot)
+	}
+	ts := branchID.Timestamp()
+	return ts, ledger.TimeNow().Time().Sub(ts.Time()), nil
+}
+
 // FetchHeaviestBranchChainNSlotsBack descending by epoch
 func FetchHeaviestBranchChainNSlotsBack(store global.StateStoreReader, nBack int) []*BranchData {
 	rootData := make(map[ledger.TransactionID]RootRecord)
@@ -606,3 +731,49 @@ func FindLatestReliableBranchWithSequencerID(store global.StateStoreReader, seqI
 	})
 	return
 }
+
+// SetLatestRoot rolls the state back to the existing root, which becomes the only root of the latest committed slot.
+// Root records of newer slots and other root records of the same slot are deleted, so the node restarts on that root.
+// Trie nodes are not deleted.
+// Dangerous! It is meant for disaster recovery only and must not be used on the running node
+func SetLatestRoot(store global.StateStore, root common.VCommitment) error {
+	var branchID *ledger.TransactionID
+	IterateRootRecords(store, func(txid ledger.TransactionID, rootData RootRecord) bool {
+		if ledger.CommitmentModel.EqualCommitments(rootData.Root, root) {
+			branchID = &txid
+			return false
+		}
+		return true
+	})
+	if branchID == nil {
+		return fmt.Errorf("SetLatestRoot: root record for root %s not found", root.String())
+	}
+	if _, err := NewReadable(store, root); err != nil {
+		return fmt.Errorf("SetLatestRoot: state with root %s is not available: %w", root.String(), err)
+	}
+	slot := branchID.Slot()
+	latestSlot := FetchLatestCommittedSlot(store)
+	if slot > latestSlot {
+		return fmt.Errorf("SetLatestRoot: slot %d of the root %s is newer than the latest committed slot %d",
+			slot, root.String(), latestSlot)
+	}
+	if earliestSlot := FetchEarliestSlot(store); slot < earliestSlot {
+		return fmt.Errorf("SetLatestRoot: slot %d of the root %s is older than the earliest slot %d",
+			slot, root.String(), earliestSlot)
+	}
+
+	toDelete := make([]ledger.TransactionID, 0)
+	IterateRootRecords(store, func(txid ledger.TransactionID, _ RootRecord) bool {
+		if txid.Slot() > slot || (txid.Slot() == slot && txid != *branchID) {
+			toDelete = append(toDelete, txid)
+		}
+		return true
+	})
+
+	batch := store.BatchedWriter()
+	for _, txid := range toDelete {
+		batch.Set(common.Concat(rootRecordDBPartition, txid[:]), nil)
+	}
+	WriteLatestSlotRecord(batch, slot)
+	return batch.Commit()
+}
@@ -0,0 +1,139 @@
+package multistate
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/util"
+	"github.com/lunfardo314/proxima/util/lines"
+	"github.com/lunfardo314/unitrie/common"
+)
+
+// StateDiff is the difference between two ledger states, from the first state to the second.
+// Added are outputs present in the second state only, Removed are outputs present in the first state only.
+// Same for committed transaction IDs
+type StateDiff struct {
+	Added          []*ledger.OutputDataWithID
+	Removed        []*ledger.OutputDataWithID
+	CommittedTxIDs []ledger.TransactionID
+	ForgottenTxIDs []ledger.TransactionID
+}
+
+// Diff computes difference between two ledger states given by roots
+func Diff(store common.KVReader, rootA, rootB common.VCommitment) (*StateDiff, error) {
+	rdrA, err := NewReadable(store, rootA)
+	if err != nil {
+		return nil, fmt.Errorf("Diff: %w", err)
+	}
+	rdrB, err := NewReadable(store, rootB)
+	if err != nil {
+		return nil, fmt.Errorf("Diff: %w", err)
+	}
+	outsA, txidsA := rdrA.collectForDiff()
+	outsB, txidsB := rdrB.collectForDiff()
+
+	ret := &StateDiff{
+		Added:          make([]*ledger.OutputDataWithID, 0),
+		Removed:        make([]*ledger.OutputDataWithID, 0),
+		CommittedTxIDs: make([]ledger.TransactionID, 0),
+		ForgottenTxIDs: make([]ledger.TransactionID, 0),
+	}
+	for oid, data := range outsB {
+		if _, found := outsA[oid]; !found {
+			ret.Added = append(ret.Added, &ledger.OutputDataWithID{ID: oid, OutputData: data})
+		}
+	}
+	for oid, data := range outsA {
+		if _, found := outsB[oid]; !found {
+			ret.Removed = append(ret.Removed, &ledger.OutputDataWithID{ID: oid, OutputData: data})
+		}
+	}
+	for txid := range txidsB {
+		if _, found := txidsA[txid]; !found {
+			ret.CommittedTxIDs = append(ret.CommittedTxIDs, txid)
+		}
+	}
+	for txid := range txidsA {
+		if _, found := txidsB[txid]; !found {
+			ret.ForgottenTxIDs = append(ret.ForgottenTxIDs, txid)
+		}
+	}
+	ret.sort()
+	return ret, nil
+}
+
+// BranchStateDiff computes difference between ledger states of two branches, given by branch transaction IDs
+func BranchStateDiff(store common.KVReader, branchA, branchB ledger.TransactionID) (*StateDiff, error) {
+	rrA, found := FetchRootRecord(store, branchA)
+	if !found {
+		return nil, fmt.Errorf("BranchStateDiff: unknown branch %s", branchA.StringShort())
+	}
+	rrB, found := FetchRootRecord(store, branchB)
+	if !found {
+		return nil, fmt.Errorf("BranchStateDiff: unknown branch %s", branchB.StringShort())
+	}
+	return Diff(store, rrA.Root, rrB.Root)
+}
+
+func (r *Readable) collectForDiff() (map[ledger.OutputID][]byte, map[ledger.TransactionID]struct{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	outs := make(map[ledger.OutputID][]byte)
+	common.MakeTraversableReaderPartition(r.trie, TriePartitionLedgerState).Iterator(nil).Iterate(func(k, v []byte) bool {
+		oid, err := ledger.OutputIDFromBytes(k[1:])
+		util.AssertNoError(err)
+		outs[oid] = v
+		return true
+	})
+	txids := make(map[ledger.TransactionID]struct{})
+	common.MakeTraversableReaderPartition(r.trie, TriePartitionCommittedTransactionID).Iterator(nil).IterateKeys(func(k []byte) bool {
+		txid, err := ledger.TransactionIDFromBytes(k[1:])
+		util.AssertNoError(err)
+		txids[txid] = struct{}{}
+		return true
+	})
+	return outs, txids
+}
+
+func (d *StateDiff) sort() {
+	sort.Slice(d.Added, func(i, j int) bool {
+		return bytes.Compare(d.Added[i].ID[:], d.Added[j].ID[:]) < 0
+	})
+	sort.Slice(d.Removed, func(i, j int) bool {
+		return bytes.Compare(d.Removed[i].ID[:], d.Removed[j].ID[:]) < 0
+	})
+	sort.Slice(d.CommittedTxIDs, func(i, j int) bool {
+		return bytes.Compare(d.CommittedTxIDs[i][:], d.CommittedTxIDs[j][:]) < 0
+	})
+	sort.Slice(d.ForgottenTxIDs, func(i, j int) bool {
+		return bytes.Compare(d.ForgottenTxIDs[i][:], d.ForgottenTxIDs[j][:]) < 0
+	})
+}
+
+func (d *StateDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.CommittedTxIDs) == 0 && len(d.ForgottenTxIDs) == 0
+}
+
+func (d *StateDiff) Lines(prefix ...string) *lines.Lines {
+	ret := lines.New(prefix...)
+	ret.Add("added outputs (%d):", len(d.Added))
+	for _, o := range d.Added {
+		ret.Add("    %s", o.ID.StringShort())
+	}
+	ret.Add("removed outputs (%d):", len(d.Removed))
+	for _, o := range d.Removed {
+		ret.Add("    %s", o.ID.StringShort())
+	}
+	ret.Add("committed transactions (%d):", len(d.CommittedTxIDs))
+	for i := range d.CommittedTxIDs {
+		ret.Add("    %s", d.CommittedTxIDs[i].StringShort())
+	}
+	ret.Add("forgotten transactions (%d):", len(d.ForgottenTxIDs))
+	for i := range d.ForgottenTxIDs {
+		ret.Add("    %s", d.ForgottenTxIDs[i].StringShort())
+	}
+	return ret
+}
@@ -1,14 +1,13 @@
 package multistate
 
 import (
-	"os"
 	"strconv"
 
 	"github.com/dominikbraun/graph"
-	"github.com/dominikbraun/graph/draw"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/util"
+	"github.com/lunfardo314/proxima/util/graphviz"
 )
 
 var (
@@ -26,31 +25,41 @@ var (
 func MakeTree(stateStore global.StateStore, slots ...int) graph.Graph[string, string] {
 	ret := graph.New(graph.StringHash, graph.Directed(), graph.Acyclic())
 
-	var branches []*BranchData
-	if len(slots) == 0 {
-		branches = FetchBranchDataMulti(stateStore, FetchAllRootRecords(stateStore)...)
-	} else {
-		branches = FetchBranchDataMulti(stateStore, FetchRootRecordsNSlotsBack(stateStore, slots[0])...)
+	slotsBack := 0
+	if len(slots) > 0 {
+		slotsBack = slots[0]
+		if slotsBack <= 0 {
+			return ret
+		}
+	}
+	type branch struct {
+		rr   RootRecord
+		stem *ledger.OutputWithID
 	}
+	branches := make([]branch, 0)
+	iterateStemsWithRootRecords(stateStore, slotsBack, func(rr RootRecord, stem *ledger.OutputWithID) bool {
+		branches = append(branches, branch{rr: rr, stem: stem})
+		return true
+	})
 
-	byOid := make(map[ledger.OutputID]*BranchData)
+	byOid := make(map[ledger.OutputID]*ledger.OutputWithID)
 	idDict := make(map[ledger.ChainID]int)
 	for _, b := range branches {
-		byOid[b.Stem.ID] = b
-		txid := b.Stem.ID.TransactionID()
+		byOid[b.stem.ID] = b.stem
+		txid := b.stem.ID.TransactionID()
 		id := txid.StringShort()
-		err := ret.AddVertex(id, branchNodeAttributes(&b.SequencerID, b.LedgerCoverage, idDict)...)
+		err := ret.AddVertex(id, branchNodeAttributes(&b.rr.SequencerID, b.rr.LedgerCoverage, idDict)...)
 		util.AssertNoError(err)
 	}
 
 	for _, b := range branches {
-		txid := b.Stem.ID.TransactionID()
+		txid := b.stem.ID.TransactionID()
 		id := txid.StringShort()
-		stemLock, stemLockFound := b.Stem.Output.StemLock()
+		stemLock, stemLockFound := b.stem.Output.StemLock()
 		util.Assertf(stemLockFound, "stem lock not found")
 
 		if pred, ok := byOid[stemLock.PredecessorOutputID]; ok {
-			txid := pred.Stem.ID.TransactionID()
+			txid := pred.ID.TransactionID()
 			predID := txid.StringShort()
 			err := ret.AddEdge(id, predID)
 			util.AssertNoError(err)
@@ -60,11 +69,13 @@ func MakeTree(stateStore global.StateStore, slots ...int) graph.Graph[string, st
 }
 
 func SaveBranchTree(stateStore global.StateStore, fname string, slotsBack ...int) {
-	gr := MakeTree(stateStore, slotsBack...)
-	dotFile, _ := os.Create(fname + ".gv")
-	err := draw.DOT(gr, dotFile)
+	err := SaveBranchTreeAs(stateStore, fname, graphviz.FormatDOT, slotsBack...)
 	util.AssertNoError(err)
-	_ = dotFile.Close()
+}
+
+// SaveBranchTreeAs saves branch tree in DOT format and optionally renders it in the format, see graphviz.SaveGraphAs
+func SaveBranchTreeAs(stateStore global.StateStore, fname, format string, slotsBack ...int) error {
+	return graphviz.SaveGraphAs(MakeTree(stateStore, slotsBack...), fname, format)
 }
 
 func branchNodeAttributes(seqID *ledger.ChainID, coverage uint64, dict map[ledger.ChainID]int) []func(*graph.VertexProperties) {
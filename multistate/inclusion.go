@@ -5,6 +5,7 @@ import (
 
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/util"
 )
 
 type (
@@ -72,11 +73,10 @@ func GetTxInclusion(store global.StateStoreReader, txid *ledger.TransactionID, s
 		LatestSlot:   latestSlot,
 		EarliestSlot: latestSlot,
 		Inclusion:    nil,
-		LRBID:        lrb.Stem.ID.TransactionID(),
-		LRBRoot:      lrb.RootRecord,
 	}
 	if lrb != nil {
 		ret.LRBID = lrb.Stem.ID.TransactionID()
+		ret.LRBRoot = lrb.RootRecord
 		ret.IncludedInLRB = RootHasTransaction(store, lrb.Root, txid)
 	}
 	back := 1
@@ -99,6 +99,45 @@ func GetTxInclusion(store global.StateStoreReader, txid *ledger.TransactionID, s
 	return ret
 }
 
+// Score calculates weak and strong inclusion score of the transaction (in percents).
+// Weak score is percentage of branches which contain the transaction.
+// Strong score is percentage of branches with coverage above threshold, which contain the transaction
+func (i *TxInclusion) Score(thresholdNumerator, thresholdDenominator int) (weak, strong int) {
+	if len(i.Inclusion) == 0 {
+		return
+	}
+	var includedInBranches, numDominatingBranches, numIncludedInDominating int
+	for j := range i.Inclusion {
+		if i.Inclusion[j].Included {
+			includedInBranches++
+		}
+		if i.Inclusion[j].RootRecord.IsCoverageAboveThreshold(thresholdNumerator, thresholdDenominator) {
+			numDominatingBranches++
+			if i.Inclusion[j].Included {
+				numIncludedInDominating++
+			}
+		}
+	}
+	weak = (includedInBranches * 100) / len(i.Inclusion)
+	if numDominatingBranches > 0 {
+		strong = (numIncludedInDominating * 100) / numDominatingBranches
+	}
+	return
+}
+
+// InclusionScore computes inclusion score of the transaction directly from root records in the state store,
+// without running node. It is the offline counterpart of the 'query_inclusion_score' API call
+func InclusionScore(store global.StateStoreReader, txid *ledger.TransactionID, numerator, denominator, slotSpan int) (weak, strong int, err error) {
+	if !ValidInclusionThresholdFraction(numerator, denominator) {
+		return 0, 0, fmt.Errorf("InclusionScore: wrong threshold fraction %d/%d", numerator, denominator)
+	}
+	err = util.CatchPanicOrError(func() error {
+		weak, strong = GetTxInclusion(store, txid, slotSpan).Score(numerator, denominator)
+		return nil
+	})
+	return
+}
+
 func (r *RootInclusionJSONAble) Parse() (*RootInclusion, error) {
 	rr, err := r.RootRecord.Parse()
 	if err != nil {
@@ -14,6 +14,9 @@ func (r *RootRecord) JSONAble() *RootRecordJSONAble {
 		LedgerCoverage: r.LedgerCoverage,
 		SlotInflation:  r.SlotInflation,
 		Supply:         r.Supply,
+		TotalOutputs:   r.TotalOutputs,
+		TotalChains:    r.TotalChains,
+		Version:        r.version,
 	}
 }
 
@@ -21,6 +24,9 @@ func (r *RootRecordJSONAble) Parse() (*RootRecord, error) {
 	ret := &RootRecord{
 		SlotInflation: r.SlotInflation,
 		Supply:        r.Supply,
+		TotalOutputs:  r.TotalOutputs,
+		TotalChains:   r.TotalChains,
+		version:       r.Version,
 	}
 	var err error
 	rootBin, err := hex.DecodeString(r.Root)
@@ -125,6 +125,23 @@ func (mut *Mutations) InsertAddTxMutation(id ledger.TransactionID, slot ledger.S
 	})
 }
 
+// outputCounts returns number of added and deleted outputs and number of added chain origins.
+// Chain records are never deleted from the state, so chain origins is the increment of chain records
+func (mut *Mutations) outputCounts() (added, deleted, chainOrigins uint64) {
+	for _, m := range mut.mut {
+		switch m := m.(type) {
+		case *mutationAddOutput:
+			added++
+			if cc, _ := m.Output.ChainConstraint(); cc != nil && cc.IsOrigin() {
+				chainOrigins++
+			}
+		case *mutationDelOutput:
+			deleted++
+		}
+	}
+	return
+}
+
 func (mut *Mutations) Lines(prefix ...string) *lines.Lines {
 	ret := lines.New(prefix...)
 	for _, m := range mut.mut {
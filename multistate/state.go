@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/hashicorp/golang-lru"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/util"
@@ -26,6 +27,17 @@ type (
 	Readable struct {
 		mutex *sync.Mutex
 		trie  *immutable.TrieReader
+		// store is needed to reach root record of the state
+		store common.KVReader
+		// optional LRU cache of parsed outputs. State is immutable, so cache never needs invalidation
+		outputCache *lru.Cache
+	}
+
+	// parsedOutput output parsed with ledger.OutputFromBytesMain
+	parsedOutput struct {
+		output *ledger.Output
+		amount ledger.Amount
+		lock   ledger.Lock
 	}
 
 	// RootRecord is a persistent data stored in the DB partition with each state root
@@ -44,7 +56,14 @@ type (
 		Supply uint64
 		// Number of new transactions in the slot of the branch
 		NumTransactions uint32
-		// TODO probably there's a need for other deterministic values, such as total number of outputs, of transactions, of chains
+		// TotalOutputs: total number of outputs in the state
+		TotalOutputs uint64
+		// TotalChains: total number of chain records in the state
+		TotalChains uint64
+		// version of the record format. rootRecordVersionLegacy for records written before TotalOutputs
+		// and TotalChains were introduced
+		version byte
+		// TODO probably there's a need for other deterministic values, such as total number of transactions
 	}
 
 	RootRecordJSONAble struct {
@@ -53,6 +72,10 @@ type (
 		LedgerCoverage uint64 `json:"ledger_coverage"`
 		SlotInflation  uint64 `json:"slot_inflation"`
 		Supply         uint64 `json:"supply"`
+		TotalOutputs   uint64 `json:"total_outputs,omitempty"`
+		TotalChains    uint64 `json:"total_chains,omitempty"`
+		// Version of the root record format. Totals are meaningful only if Version > 0
+		Version byte `json:"version,omitempty"`
 	}
 
 	BranchData struct {
@@ -96,20 +119,33 @@ func LedgerIdentityBytesFromRoot(store global.StateStoreReader, root common.VCom
 	return trie.Get(nil)
 }
 
-// NewReadable creates read-only ledger state with the given root
-func NewReadable(store common.KVReader, root common.VCommitment, clearCacheAtSize ...int) (*Readable, error) {
+// NewReadable creates read-only ledger state with the given root.
+// Optional cacheParams[0] is size at which trie cache is cleared, cacheParams[1] is size of the
+// LRU cache of parsed outputs. No cache of parsed outputs by default
+func NewReadable(store common.KVReader, root common.VCommitment, cacheParams ...int) (*Readable, error) {
+	var clearCacheAtSize []int
+	if len(cacheParams) > 0 {
+		clearCacheAtSize = cacheParams[:1]
+	}
 	trie, err := immutable.NewTrieReader(ledger.CommitmentModel, store, root, clearCacheAtSize...)
 	if err != nil {
 		return nil, err
 	}
-	return &Readable{
+	ret := &Readable{
 		mutex: &sync.Mutex{},
 		trie:  trie,
-	}, nil
+		store: store,
+	}
+	if len(cacheParams) > 1 && cacheParams[1] > 0 {
+		if ret.outputCache, err = lru.New(cacheParams[1]); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
 }
 
-func MustNewReadable(store common.KVReader, root common.VCommitment, clearCacheAtSize ...int) *Readable {
-	ret, err := NewReadable(store, root, clearCacheAtSize...)
+func MustNewReadable(store common.KVReader, root common.VCommitment, cacheParams ...int) *Readable {
+	ret, err := NewReadable(store, root, cacheParams...)
 	util.AssertNoError(err)
 	return ret
 }
@@ -148,6 +184,42 @@ func (r *Readable) _getUTXO(oid *ledger.OutputID) ([]byte, bool) {
 	return ret, true
 }
 
+// GetParsedOutput returns parsed output. Uses cache of parsed outputs, if enabled.
+// The cached output is never returned to the caller, only its copy
+func (r *Readable) GetParsedOutput(oid *ledger.OutputID) (*ledger.Output, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	oData, found := r._getUTXO(oid)
+	if !found {
+		return nil, false
+	}
+	o, _, _, err := r._parseOutput(oid, oData)
+	util.AssertNoError(err)
+	if r.outputCache != nil {
+		o = o.Clone()
+	}
+	return o, true
+}
+
+// _parseOutput parses output data or takes it from the cache of parsed outputs, if enabled
+func (r *Readable) _parseOutput(oid *ledger.OutputID, oData []byte) (*ledger.Output, ledger.Amount, ledger.Lock, error) {
+	if r.outputCache != nil {
+		if p, ok := r.outputCache.Get(*oid); ok {
+			po := p.(parsedOutput)
+			return po.output, po.amount, po.lock, nil
+		}
+	}
+	o, amount, lock, err := ledger.OutputFromBytesMain(oData)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if r.outputCache != nil {
+		r.outputCache.Add(*oid, parsedOutput{output: o, amount: amount, lock: lock})
+	}
+	return o, amount, lock, nil
+}
+
 func (r *Readable) HasUTXO(oid *ledger.OutputID) bool {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
@@ -219,6 +291,76 @@ func (r *Readable) GetUTXOsLockedInAccount(addr ledger.AccountID) ([]*ledger.Out
 	return ret, err
 }
 
+// GetUTXOsLockedInAccounts returns UTXOs of several accounts, bucketed by account (string of account ID bytes).
+// Accounts partition of the trie is iterated once, with mutex taken once. Each requested account is in the result,
+// possibly with empty list
+func (r *Readable) GetUTXOsLockedInAccounts(addrs []ledger.AccountID) (map[string][]*ledger.OutputDataWithID, error) {
+	ret := make(map[string][]*ledger.OutputDataWithID)
+	for _, addr := range addrs {
+		if len(addr) > 255 {
+			return nil, fmt.Errorf("accountID length should be <= 255")
+		}
+		ret[string(addr)] = make([]*ledger.OutputDataWithID, 0)
+	}
+	if len(ret) == 0 {
+		return ret, nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var err error
+	var found bool
+	r.trie.Iterator([]byte{TriePartitionAccounts}).IterateKeys(func(k []byte) bool {
+		// key: partition byte, account length byte, account ID, output ID
+		if len(k) < 2 || len(k) < 2+int(k[1]) {
+			err = fmt.Errorf("GetUTXOsLockedInAccounts: wrong key in the accounts partition")
+			return false
+		}
+		addr := k[2 : 2+int(k[1])]
+		outs, requested := ret[string(addr)]
+		if !requested {
+			return true
+		}
+		o := &ledger.OutputDataWithID{}
+		o.ID, err = ledger.OutputIDFromBytes(k[2+len(addr):])
+		if err != nil {
+			return false
+		}
+		o.OutputData, found = r._getUTXO(&o.ID)
+		if !found {
+			// skip this output ID
+			return true
+		}
+		ret[string(addr)] = append(outs, o)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetChainControlledOutputs returns all outputs locked with the ChainLock of the chain ID.
+// The chain output itself is not included, unless it is locked in its own chain
+func (r *Readable) GetChainControlledOutputs(chainID ledger.ChainID) ([]*ledger.OutputDataWithID, error) {
+	oDatas, err := r.GetUTXOsLockedInAccount(chainID.AsAccountID())
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*ledger.OutputDataWithID, 0, len(oDatas))
+	for _, o := range oDatas {
+		out, err := ledger.OutputFromBytesReadOnly(o.OutputData)
+		if err != nil {
+			return nil, err
+		}
+		if chainLock, ok := out.Lock().(ledger.ChainLock); ok && chainLock.ChainID() == chainID {
+			ret = append(ret, o)
+		}
+	}
+	return ret, nil
+}
+
 func (r *Readable) GetUTXOForChainID(id *ledger.ChainID) (*ledger.OutputDataWithID, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
@@ -252,25 +394,58 @@ func (r *Readable) GetStem() (ledger.Slot, []byte) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	accountPrefix := common.Concat(TriePartitionAccounts, byte(len(ledger.StemAccountID)), ledger.StemAccountID)
+	// Stem output must always be present in the state
+	id, err := r._stemOutputID()
+	util.AssertNoError(err)
+	retBytes, found := r._getUTXO(&id)
+	util.Assertf(found, "can't find stem output")
+	return id.Slot(), retBytes
+}
 
-	var found bool
-	var retSlot ledger.Slot
-	var retBytes []byte
+// SupplyAndInflation returns supply, slot inflation and number of transactions from the root record of the state.
+// Root record is found by the branch transaction of the stem output
+func (r *Readable) SupplyAndInflation() (supply, slotInflation uint64, numTx uint32, err error) {
+	r.mutex.Lock()
+	stemID, err := r._stemOutputID()
+	r.mutex.Unlock()
+	if err != nil {
+		return
+	}
+	branchTxID := stemID.TransactionID()
+	rr, found := FetchRootRecord(r.store, branchTxID)
+	if !found {
+		err = fmt.Errorf("SupplyAndInflation: root record for branch %s not found", branchTxID.StringShort())
+		return
+	}
+	if !ledger.CommitmentModel.EqualCommitments(rr.Root, r.Root()) {
+		err = fmt.Errorf("SupplyAndInflation: root record of branch %s does not correspond to the root of the state",
+			branchTxID.StringShort())
+		return
+	}
+	return rr.Supply, rr.SlotInflation, rr.NumTransactions, nil
+}
 
-	// we iterate one element. Stem output ust always be present in the state
-	count := 0
-	r.trie.Iterator(accountPrefix).IterateKeys(func(k []byte) bool {
-		util.Assertf(count == 0, "inconsistency: must be exactly 1 index record for stem output")
-		count++
-		id, err := ledger.OutputIDFromBytes(k[len(accountPrefix):])
-		util.AssertNoError(err)
-		retSlot = id.Slot()
-		retBytes, found = r._getUTXO(&id)
-		util.Assertf(found, "can't find stem output")
-		return true
+func (r *Readable) _stemOutputID() (ledger.OutputID, error) {
+	ret, found, err := stemOutputIDInTrie(r.trie)
+	if err == nil && !found {
+		err = fmt.Errorf("stem output not found in the state")
+	}
+	return ret, err
+}
+
+// stemOutputIDInTrie finds ID of the stem output in the index of the stem account
+func stemOutputIDInTrie(trie *immutable.TrieReader) (ret ledger.OutputID, found bool, err error) {
+	accountPrefix := common.Concat(TriePartitionAccounts, byte(len(ledger.StemAccountID)), ledger.StemAccountID)
+	trie.Iterator(accountPrefix).IterateKeys(func(k []byte) bool {
+		if found {
+			err = fmt.Errorf("inconsistency: must be exactly 1 index record for stem output")
+			return false
+		}
+		ret, err = ledger.OutputIDFromBytes(k[len(accountPrefix):])
+		found = err == nil
+		return found
 	})
-	return retSlot, retBytes
+	return
 }
 
 func (r *Readable) MustLedgerIdentityBytes() []byte {
@@ -339,7 +514,7 @@ func (r *Readable) AccountsByLocks() map[string]LockedAccountInfo {
 		oData, found := r._getUTXO(&oid)
 		util.Assertf(found, "can't get output")
 
-		_, amount, lock, err := ledger.OutputFromBytesMain(oData)
+		_, amount, lock, err := r._parseOutput(&oid, oData)
 		util.AssertNoError(err)
 
 		lockStr := lock.String()
@@ -354,24 +529,12 @@ func (r *Readable) AccountsByLocks() map[string]LockedAccountInfo {
 }
 
 func (r *Readable) ChainInfo() map[ledger.ChainID]ChainRecordInfo {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
 	ret := make(map[ledger.ChainID]ChainRecordInfo)
-	var chainID ledger.ChainID
-	var err error
-	var oData *ledger.OutputDataWithID
-	var amount ledger.Amount
-
-	r.trie.Iterator([]byte{TriePartitionChainID}).Iterate(func(k, v []byte) bool {
-		chainID, err = ledger.ChainIDFromBytes(k[1:])
-		util.AssertNoError(err)
-		oData, err = r._getUTXOForChainID(&chainID)
-		util.AssertNoError(err)
-
+	r.IterateChains(func(chainID ledger.ChainID, oData *ledger.OutputDataWithID) bool {
 		_, already := ret[chainID]
 		util.Assertf(!already, "repeating chain record")
-		_, amount, _, err = ledger.OutputFromBytesMain(oData.OutputData)
+		// callback is called with the mutex held
+		_, amount, _, err := r._parseOutput(&oData.ID, oData.OutputData)
 		util.AssertNoError(err)
 
 		ret[chainID] = ChainRecordInfo{
@@ -385,6 +548,25 @@ func (r *Readable) ChainInfo() map[ledger.ChainID]ChainRecordInfo {
 	return ret
 }
 
+// IterateChains iterates all chains in the state with their current outputs until fun returns false.
+// The mutex is held during the whole traversal
+func (r *Readable) IterateChains(fun func(chainID ledger.ChainID, o *ledger.OutputDataWithID) bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var chainID ledger.ChainID
+	var err error
+	var oData *ledger.OutputDataWithID
+
+	r.trie.Iterator([]byte{TriePartitionChainID}).IterateKeys(func(k []byte) bool {
+		chainID, err = ledger.ChainIDFromBytes(k[1:])
+		util.AssertNoError(err)
+		oData, err = r._getUTXOForChainID(&chainID)
+		util.AssertNoError(err)
+		return fun(chainID, oData)
+	})
+}
+
 func (r *Readable) Root() common.VCommitment {
 	// non need to lock
 	return r.trie.Root()
@@ -394,6 +576,7 @@ func (u *Updatable) Readable() *Readable {
 	return &Readable{
 		mutex: &sync.Mutex{},
 		trie:  u.trie.TrieReader,
+		store: u.store,
 	}
 }
 
@@ -416,7 +599,7 @@ type RootRecordParams struct {
 func (u *Updatable) Update(muts *Mutations, rootRecordParams *RootRecordParams) error {
 	return u.updateUTXOLedgerDB(func(trie *immutable.TrieUpdatable) error {
 		return UpdateTrie(u.trie, muts)
-	}, rootRecordParams)
+	}, rootRecordParams, muts)
 }
 
 func (u *Updatable) MustUpdate(muts *Mutations, par *RootRecordParams) {
@@ -424,7 +607,29 @@ func (u *Updatable) MustUpdate(muts *Mutations, par *RootRecordParams) {
 	util.AssertNoError(err)
 }
 
-func (u *Updatable) updateUTXOLedgerDB(updateFun func(updatable *immutable.TrieUpdatable) error, rootRecordsParams *RootRecordParams) error {
+func (u *Updatable) updateUTXOLedgerDB(updateFun func(updatable *immutable.TrieUpdatable) error, rootRecordsParams *RootRecordParams, muts *Mutations) error {
+	var totalOutputs, totalChains uint64
+	if rootRecordsParams != nil {
+		prev, err := u.predecessorRootRecord()
+		if err != nil {
+			return err
+		}
+		if err = checkSupply(rootRecordsParams, prev); err != nil {
+			return err
+		}
+		switch {
+		case prev == nil:
+			// genesis, empty state
+		case prev.HasTotals():
+			totalOutputs, totalChains = prev.TotalOutputs, prev.TotalChains
+		default:
+			// predecessor record does not have totals, count them in the state before update
+			totalOutputs, totalChains = countOutputsAndChains(u.trie.TrieReader)
+		}
+		added, deleted, chainOrigins := muts.outputCounts()
+		totalOutputs = totalOutputs + added - deleted
+		totalChains += chainOrigins
+	}
 	if err := updateFun(u.trie); err != nil {
 		return err
 	}
@@ -446,6 +651,9 @@ func (u *Updatable) updateUTXOLedgerDB(updateFun func(updatable *immutable.TrieU
 			SlotInflation:   rootRecordsParams.SlotInflation,
 			Supply:          rootRecordsParams.Supply,
 			NumTransactions: rootRecordsParams.NumTransactions,
+			TotalOutputs:    totalOutputs,
+			TotalChains:     totalChains,
+			version:         rootRecordVersion,
 		})
 	}
 	var err error
@@ -458,6 +666,47 @@ func (u *Updatable) updateUTXOLedgerDB(updateFun func(updatable *immutable.TrieU
 	return nil
 }
 
+// predecessorRootRecord returns root record of the branch of the stem in the state before update.
+// Genesis state has no predecessor, then nil is returned
+func (u *Updatable) predecessorRootRecord() (*RootRecord, error) {
+	prevStemID, found, err := stemOutputIDInTrie(u.trie.TrieReader)
+	if err != nil || !found {
+		return nil, err
+	}
+	prevBranchID := prevStemID.TransactionID()
+	prev, found := FetchRootRecord(u.store, prevBranchID)
+	if !found {
+		return nil, fmt.Errorf("root record of the predecessor branch %s not found", prevBranchID.StringShort())
+	}
+	return &prev, nil
+}
+
+// checkSupply checks invariant Supply == predecessor's Supply + SlotInflation. The check is skipped for genesis
+func checkSupply(par *RootRecordParams, prev *RootRecord) error {
+	if prev == nil {
+		return nil
+	}
+	if par.Supply != prev.Supply+par.SlotInflation {
+		branchID := par.StemOutputID.TransactionID()
+		return fmt.Errorf("checkSupply: inconsistent supply of the branch %s: supply %s != predecessor's supply %s + slot inflation %s",
+			branchID.StringShort(), util.Th(par.Supply), util.Th(prev.Supply), util.Th(par.SlotInflation))
+	}
+	return nil
+}
+
+// countOutputsAndChains counts outputs and chain records in the state
+func countOutputsAndChains(trie *immutable.TrieReader) (numOutputs, numChains uint64) {
+	trie.Iterator([]byte{TriePartitionLedgerState}).IterateKeys(func(_ []byte) bool {
+		numOutputs++
+		return true
+	})
+	trie.Iterator([]byte{TriePartitionChainID}).IterateKeys(func(_ []byte) bool {
+		numChains++
+		return true
+	})
+	return
+}
+
 func RootHasTransaction(store common.KVReader, root common.VCommitment, txid *ledger.TransactionID) bool {
 	return MustNewSugaredReadableState(store, root, 0).KnowsCommittedTransaction(txid)
 }
@@ -0,0 +1,219 @@
+package multistate
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/lunfardo314/proxima/global"
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/unitrie/common"
+	"github.com/lunfardo314/unitrie/immutable"
+)
+
+// UTXO set export format:
+//   - header: format version byte, root commitment of the source state, ledger identity bytes
+//   - sequence of (key, value) pairs of the state trie in the trie iteration order, except the ledger identity record
+//   - terminator: zero length key
+//
+// All byte slices are prefixed with 4 bytes big-endian length.
+// Besides UTXOs, the export contains account and chain indices and committed transaction IDs, i.e. everything
+// which is needed to reproduce the source state. So the root of the imported state is equal to the root of the
+// source state, which can be checked against the trusted root record
+
+const (
+	utxoSetExportVersion = byte(1)
+	// number of key/value pairs committed to the trie in one batch when rebuilding the state
+	utxoSetImportBatchSize = 10_000
+	// limits of key and value sizes in the export, way above the sizes in the valid state.
+	// Protect importer from allocating huge buffers while reading the corrupted input
+	maxUTXOSetExportKeySize   = 512
+	maxUTXOSetExportValueSize = 1 << 20
+)
+
+type UTXOSetExportHeader struct {
+	Root          common.VCommitment
+	LedgerIDBytes []byte
+}
+
+// ExportUTXOSet writes the state to the writer in deterministic order, in one pass
+func (r *Readable) ExportUTXOSet(w io.Writer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ledgerIDBytes := r.trie.Get(nil)
+	if len(ledgerIDBytes) == 0 {
+		return fmt.Errorf("ExportUTXOSet: ledger identity record not found")
+	}
+	header := &UTXOSetExportHeader{
+		Root:          r.trie.Root(),
+		LedgerIDBytes: ledgerIDBytes,
+	}
+	bw := bufio.NewWriter(w)
+	if err := header.write(bw); err != nil {
+		return fmt.Errorf("ExportUTXOSet: %w", err)
+	}
+	var err error
+	r.trie.Iterator(nil).Iterate(func(k, v []byte) bool {
+		if len(k) == 0 {
+			// skip ledger identity record
+			return true
+		}
+		if err = writeBytes32(bw, k); err != nil {
+			return false
+		}
+		err = writeBytes32(bw, v)
+		return err == nil
+	})
+	if err == nil {
+		// terminator
+		err = writeBytes32(bw, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("ExportUTXOSet: %w", err)
+	}
+	return bw.Flush()
+}
+
+// ImportUTXOSet rebuilds state from the UTXO set export in the store.
+// Checks if the root of the resulting state is equal to the root in the header. If trustedRoot is provided,
+// the root in the header must be equal to it, otherwise the export is only checked for consistency with its own header.
+// The store is expected to be empty. Root records are not written
+func ImportUTXOSet(store global.StateStore, rd io.Reader, trustedRoot ...common.VCommitment) (*Updatable, error) {
+	br := bufio.NewReader(rd)
+	header, err := readUTXOSetExportHeader(br)
+	if err != nil {
+		return nil, fmt.Errorf("ImportUTXOSet: %w", err)
+	}
+	if len(trustedRoot) > 0 && !ledger.CommitmentModel.EqualCommitments(header.Root, trustedRoot[0]) {
+		return nil, fmt.Errorf("ImportUTXOSet: root in the header %s is not equal to the trusted root %s",
+			header.Root.String(), trustedRoot[0].String())
+	}
+	u, err := newStateWithLedgerIdentity(store, header.LedgerIDBytes)
+	if err != nil {
+		return nil, fmt.Errorf("ImportUTXOSet: %w", err)
+	}
+	for done := false; !done; {
+		err = u.updateUTXOLedgerDB(func(trie *immutable.TrieUpdatable) error {
+			for i := 0; i < utxoSetImportBatchSize; i++ {
+				k, v, err := readUTXOSetExportKV(br)
+				if err != nil {
+					return err
+				}
+				if len(k) == 0 {
+					done = true
+					return nil
+				}
+				trie.Update(k, v)
+			}
+			return nil
+		}, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("ImportUTXOSet: %w", err)
+		}
+	}
+	if !ledger.CommitmentModel.EqualCommitments(u.Root(), header.Root) {
+		return nil, fmt.Errorf("ImportUTXOSet: root of the imported state %s is not equal to the expected %s",
+			u.Root().String(), header.Root.String())
+	}
+	return u, nil
+}
+
+// newStateWithLedgerIdentity creates empty state with the ledger identity record
+func newStateWithLedgerIdentity(store global.StateStore, ledgerIDBytes []byte) (*Updatable, error) {
+	batch := store.BatchedWriter()
+	emptyRoot := immutable.MustInitRoot(batch, ledger.CommitmentModel, ledgerIDBytes)
+	if err := batch.Commit(); err != nil {
+		return nil, err
+	}
+	return NewUpdatable(store, emptyRoot)
+}
+
+// readUTXOSetExportKV reads next key/value pair. Returns empty key at the end of the export.
+// Keys of unknown partitions and unparsable outputs are rejected
+func readUTXOSetExportKV(r io.Reader) ([]byte, []byte, error) {
+	k, err := readBytes32(r, maxUTXOSetExportKeySize)
+	if err != nil || len(k) == 0 {
+		return nil, nil, err
+	}
+	v, err := readBytes32(r, maxUTXOSetExportValueSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch k[0] {
+	case TriePartitionLedgerState:
+		oid, err := ledger.OutputIDFromBytes(k[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err = ledger.OutputFromBytesReadOnly(v); err != nil {
+			return nil, nil, fmt.Errorf("can't parse output %s: %w", oid.StringShort(), err)
+		}
+	case TriePartitionAccounts, TriePartitionChainID, TriePartitionCommittedTransactionID:
+	default:
+		return nil, nil, fmt.Errorf("unknown partition %d of the key", k[0])
+	}
+	return k, v, nil
+}
+
+func (h *UTXOSetExportHeader) write(w io.Writer) error {
+	if _, err := w.Write([]byte{utxoSetExportVersion}); err != nil {
+		return err
+	}
+	if err := writeBytes32(w, h.Root.Bytes()); err != nil {
+		return err
+	}
+	return writeBytes32(w, h.LedgerIDBytes)
+}
+
+func readUTXOSetExportHeader(r io.Reader) (*UTXOSetExportHeader, error) {
+	var version [1]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return nil, err
+	}
+	if version[0] != utxoSetExportVersion {
+		return nil, fmt.Errorf("unsupported UTXO set export format version %d", version[0])
+	}
+	rootBin, err := readBytes32(r, maxUTXOSetExportKeySize)
+	if err != nil {
+		return nil, err
+	}
+	ret := &UTXOSetExportHeader{}
+	if ret.Root, err = common.VectorCommitmentFromBytes(ledger.CommitmentModel, rootBin); err != nil {
+		return nil, err
+	}
+	if ret.LedgerIDBytes, err = readBytes32(r, maxUTXOSetExportValueSize); err != nil {
+		return nil, err
+	}
+	if _, err = ledger.IdentityDataFromBytes(ret.LedgerIDBytes); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func writeBytes32(w io.Writer, data []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func readBytes32(r io.Reader, maxSize int) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > uint32(maxSize) {
+		return nil, fmt.Errorf("length %d exceeds maximum %d", n, maxSize)
+	}
+	ret := make([]byte, n)
+	if _, err := io.ReadFull(r, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,171 @@
+package multistate
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/unitrie/common"
+	"github.com/lunfardo314/unitrie/immutable"
+)
+
+// VerifyTrieIntegrity checks integrity of the ledger state trie with the given root stored in the store.
+// It re-calculates commitment of each trie node and compares it with the key the node is stored with,
+// and checks terminal commitments against the values from the value store.
+// If parallel == true, subtrees of state partitions (UTXO, accounts, etc.) are verified concurrently,
+// otherwise the whole trie is verified in one goroutine
+func VerifyTrieIntegrity(store common.KVReader, root common.VCommitment, parallel bool) error {
+	v := &trieVerifier{
+		model:      ledger.CommitmentModel,
+		trieStore:  common.MakeReaderPartition(store, immutable.PartitionTrieNodes),
+		valueStore: common.MakeReaderPartition(store, immutable.PartitionValues),
+		// trie key of the partition prefix byte
+		partitionKeyLen: len(common.UnpackBytes([]byte{TriePartitionLedgerState}, ledger.CommitmentModel.PathArity())),
+	}
+	return v.verifySubtree(root, nil, parallel)
+}
+
+type trieVerifier struct {
+	model           common.CommitmentModel
+	trieStore       common.KVReader
+	valueStore      common.KVReader
+	partitionKeyLen int
+}
+
+func (v *trieVerifier) verifyNode(c common.VCommitment, triePath []byte) (*common.NodeData, error) {
+	nodeBin := v.trieStore.Get(common.AsKey(c))
+	if len(nodeBin) == 0 {
+		return nil, fmt.Errorf("trie node %s not found. Trie path: '%s'", c.String(), hex.EncodeToString(triePath))
+	}
+	noValueStore := func(_ []byte) ([]byte, error) {
+		return nil, fmt.Errorf("terminal commitment is not stored in the trie node")
+	}
+	n, err := common.NodeDataFromBytes(v.model, nodeBin, v.model.PathArity(), noValueStore)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse trie node %s: %w", c.String(), err)
+	}
+	if calculated := v.model.CalcNodeCommitment(n, triePath); !v.model.EqualCommitments(calculated, c) {
+		return nil, fmt.Errorf("inconsistent trie node. Trie path: '%s', expected commitment: %s, calculated: %s",
+			hex.EncodeToString(triePath), c.String(), calculated.String())
+	}
+	if common.IsNil(n.Terminal) {
+		return n, nil
+	}
+	if _, valueInTerminal := n.Terminal.ExtractValue(); valueInTerminal {
+		return n, nil
+	}
+	value := v.valueStore.Get(common.AsKey(n.Terminal))
+	if !v.model.EqualCommitments(v.model.CommitToData(value), n.Terminal) {
+		return nil, fmt.Errorf("inconsistent value of the terminal %s. Trie path: '%s'",
+			n.Terminal.String(), hex.EncodeToString(triePath))
+	}
+	return n, nil
+}
+
+// verifySubtree verifies subtree recursively. In parallel mode, nodes above partition prefix are
+// verified in the calling goroutine and subtrees at the partition prefix level are verified concurrently
+func (v *trieVerifier) verifySubtree(c common.VCommitment, triePath []byte, parallel bool) error {
+	n, err := v.verifyNode(c, triePath)
+	if err != nil {
+		return err
+	}
+	if !parallel {
+		n.IterateChildren(func(childIdx byte, childCommitment common.VCommitment) bool {
+			err = v.verifySubtree(childCommitment, common.Concat(triePath, n.PathFragment, childIdx), false)
+			return err == nil
+		})
+		return err
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var errRet error
+
+	n.IterateChildren(func(childIdx byte, childCommitment common.VCommitment) bool {
+		childPath := common.Concat(triePath, n.PathFragment, childIdx)
+		if len(childPath) < v.partitionKeyLen {
+			// still above partition subtrees
+			if err = v.verifySubtree(childCommitment, childPath, true); err != nil {
+				mutex.Lock()
+				errRet = err
+				mutex.Unlock()
+				return false
+			}
+			return true
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err1 := v.verifySubtree(childCommitment, childPath, false); err1 != nil {
+				mutex.Lock()
+				errRet = err1
+				mutex.Unlock()
+			}
+		}()
+		return true
+	})
+	wg.Wait()
+	return errRet
+}
+
+// VerifyIntegrity checks consistency of the ledger state against its indices: each output in the UTXO partition
+// must parse, must be indexed in the accounts partition under each account of its lock, and each chain output
+// must be the one recorded for its chain ID. The stem output must be unique.
+// Returns the first inconsistency found, with the offending key
+func (r *Readable) VerifyIntegrity() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var err error
+	numStems := 0
+	utxoPrefix := []byte{TriePartitionLedgerState}
+	r.trie.Iterator(utxoPrefix).Iterate(func(k, data []byte) bool {
+		var oid ledger.OutputID
+		if oid, err = ledger.OutputIDFromBytes(k[len(utxoPrefix):]); err != nil {
+			err = fmt.Errorf("VerifyIntegrity: wrong UTXO key %s: %w", hex.EncodeToString(k), err)
+			return false
+		}
+		var o *ledger.Output
+		if o, _, _, err = ledger.OutputFromBytesMain(data); err != nil {
+			err = fmt.Errorf("VerifyIntegrity: can't parse output %s (key %s): %w", oid.StringShort(), hex.EncodeToString(k), err)
+			return false
+		}
+		for _, accountable := range o.Lock().Accounts() {
+			if accountKey := makeAccountKey(accountable.AccountID(), &oid); !r.trie.Has(accountKey) {
+				err = fmt.Errorf("VerifyIntegrity: output %s is not indexed in account %s (key %s)",
+					oid.StringShort(), accountable.String(), hex.EncodeToString(accountKey))
+				return false
+			}
+		}
+		if _, isStem := o.StemLock(); isStem {
+			if numStems++; numStems > 1 {
+				err = fmt.Errorf("VerifyIntegrity: stem output %s is not unique (key %s)", oid.StringShort(), hex.EncodeToString(k))
+				return false
+			}
+		}
+		chainConstraint, _ := o.ChainConstraint()
+		if chainConstraint == nil {
+			return true
+		}
+		chainID := chainConstraint.ID
+		if chainConstraint.IsOrigin() {
+			chainID = ledger.MakeOriginChainID(&oid)
+		}
+		chainKey := makeChainIDKey(&chainID)
+		if recorded := r.trie.Get(chainKey); !bytes.Equal(recorded, oid[:]) {
+			err = fmt.Errorf("VerifyIntegrity: chain output %s is not recorded for chain %s (key %s)",
+				oid.StringShort(), chainID.StringShort(), hex.EncodeToString(chainKey))
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if numStems == 0 {
+		return fmt.Errorf("VerifyIntegrity: stem output not found")
+	}
+	return nil
+}
@@ -5,10 +5,12 @@ import (
 
 	"github.com/lunfardo314/proxima/api"
 	"github.com/lunfardo314/proxima/api/server"
+	"github.com/lunfardo314/proxima/core/memdag"
 	"github.com/lunfardo314/proxima/core/vertex"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/multistate"
+	"github.com/lunfardo314/proxima/util/graphviz"
 	"github.com/spf13/viper"
 )
 
@@ -43,24 +45,85 @@ func (p *ProximaNode) GetNodeInfo() *global.NodeInfo {
 		Version:         global.Version,
 		NumStaticAlive:  uint16(aliveStaticPeers),
 		NumDynamicAlive: uint16(aliveDynamicPeers),
+		Isolated:        p.peers.IsIsolated(),
+		ServingPaused:   p.peers.IsServingPaused(),
+		Addrs:           p.selfAddrStrings(),
+		NATStatus:       p.peers.NATStatus(),
 		Sequencer:       p.GetOwnSequencerID(),
+		Sequencers:      p.latestMilestonesInfo(),
+		Branches:        p.latestBranchesInfo(),
+	}
+	if ts, age, err := multistate.LatestBranchTime(p.StateStore()); err == nil {
+		ret.LatestBranchTime = ts.String()
+		ret.LatestBranchAgeMs = age.Milliseconds()
+	}
+	return ret
+}
+
+func (p *ProximaNode) selfAddrStrings() []string {
+	addrs := p.peers.SelfAddrs()
+	ret := make([]string, len(addrs))
+	for i, a := range addrs {
+		ret[i] = a.String()
+	}
+	return ret
+}
+
+func (p *ProximaNode) latestMilestonesInfo() []global.SequencerInfo {
+	milestones := p.workflow.LatestMilestonesDescending()
+	ret := make([]global.SequencerInfo, 0, len(milestones))
+	for _, vid := range milestones {
+		seqID := vid.SequencerID.Load()
+		if seqID == nil {
+			continue
+		}
+		si := global.SequencerInfo{
+			ID:              *seqID,
+			LatestMilestone: vid.ID,
+			LedgerCoverage:  vid.GetLedgerCoverage(),
+		}
+		if msData := p.workflow.ParseMilestoneData(vid); msData != nil {
+			si.Name = msData.Name
+		}
+		ret = append(ret, si)
+	}
+	return ret
+}
+
+func (p *ProximaNode) latestBranchesInfo() []global.BranchInfo {
+	branches := multistate.FetchLatestBranches(p.StateStore())
+	ret := make([]global.BranchInfo, len(branches))
+	for i, bd := range branches {
+		ret[i] = global.BranchInfo{
+			ID:             bd.Stem.ID.TransactionID(),
+			Slot:           uint32(bd.Stem.ID.Slot()),
+			SequencerID:    bd.SequencerID,
+			LedgerCoverage: bd.LedgerCoverage,
+		}
 	}
 	return ret
 }
 
 // GetSyncInfo TODO not finished
 func (p *ProximaNode) GetSyncInfo() *api.SyncInfo {
-	latestSlot, latestHealthySlot, synced := p.workflow.LatestBranchSlots()
+	syncStatus := p.workflow.SyncStatus()
 	ret := &api.SyncInfo{
-		Synced:       synced,
-		PerSequencer: make(map[string]api.SequencerSyncInfo),
+		Synced:              syncStatus.Synced,
+		PerSequencer:        make(map[string]api.SequencerSyncInfo),
+		LatestCommittedSlot: uint32(syncStatus.LatestSlot),
+		LatestHealthySlot:   uint32(syncStatus.LatestHealthySlot),
+		NowSlot:             uint32(syncStatus.NowSlot),
+		SlotsBehind:         syncStatus.SlotsBehind,
+	}
+	if _, age, err := multistate.LatestBranchTime(p.StateStore()); err == nil {
+		ret.LatestBranchAgeMs = age.Milliseconds()
 	}
 	if p.sequencer != nil {
 		seqInfo := p.sequencer.Info()
 		ssi := api.SequencerSyncInfo{
-			Synced:              synced,
-			LatestHealthySlot:   uint32(latestHealthySlot),
-			LatestCommittedSlot: uint32(latestSlot),
+			Synced:              syncStatus.Synced,
+			LatestHealthySlot:   uint32(syncStatus.LatestHealthySlot),
+			LatestCommittedSlot: uint32(syncStatus.LatestSlot),
 			LedgerCoverage:      seqInfo.LedgerCoverage,
 		}
 		chainId := p.sequencer.SequencerID()
@@ -77,8 +140,9 @@ func (p *ProximaNode) LatestReliableState() (multistate.SugaredStateReader, erro
 	return p.workflow.LatestReliableState()
 }
 
-func (p *ProximaNode) SubmitTxBytesFromAPI(txBytes []byte, trace bool) {
-	p.workflow.TxBytesInFromAPIQueued(txBytes, trace)
+// SubmitTxBytesFromAPI returns false if transaction was not submitted because the input queue is full
+func (p *ProximaNode) SubmitTxBytesFromAPI(txBytes []byte, trace bool) bool {
+	return p.workflow.TryTxBytesInFromAPIQueued(txBytes, trace)
 }
 
 func (p *ProximaNode) QueryTxIDStatusJSONAble(txid *ledger.TransactionID) vertex.TxIDStatusJSONAble {
@@ -92,3 +156,29 @@ func (p *ProximaNode) GetTxInclusion(txid *ledger.TransactionID, slotsBack int)
 func (p *ProximaNode) GetLatestReliableBranch() *multistate.BranchData {
 	return multistate.FindLatestReliableBranch(p.StateStore(), global.FractionHealthyBranch)
 }
+
+func (p *ProximaNode) GetBranchRootRecordsInSlot(slot *ledger.Slot) (map[ledger.TransactionID]multistate.RootRecord, ledger.Slot) {
+	latestSlot := multistate.FetchLatestCommittedSlot(p.StateStore())
+	if slot == nil {
+		slot = &latestSlot
+	}
+	ret := make(map[ledger.TransactionID]multistate.RootRecord)
+	multistate.IterateRootRecords(p.StateStore(), func(branchTxID ledger.TransactionID, rootData multistate.RootRecord) bool {
+		ret[branchTxID] = rootData
+		return true
+	}, *slot)
+	return ret, latestSlot
+}
+
+func (p *ProximaNode) GetPastConeGraphDOT(txid *ledger.TransactionID, maxVertices int) ([]byte, error, error) {
+	vid := p.workflow.GetVertex(txid)
+	if vid == nil {
+		return nil, nil, fmt.Errorf("transaction %s is not in the memDAG", txid.StringShort())
+	}
+	gr, incomplete := memdag.MakeGraphPastCone(vid, maxVertices)
+	dot, err := graphviz.DOT(gr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dot, incomplete, nil
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/lunfardo314/proxima/core/workflow"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
@@ -92,6 +93,17 @@ func (p *ProximaNode) PullFromNPeers(nPeers int, txid *ledger.TransactionID) int
 	return p.peers.PullTransactionsFromNPeers(nPeers, *txid)
 }
 
+// PullFromPeer pulls transaction from the specific peer, for example the one the dependent transaction was received from.
+// The pull is escalated to nPeersFallback peers immediately if the peer is not alive or not a pull target,
+// and after global.PullFromPeerMaxFailures pulls from the peer which were not answered.
+// Returns number of peers pull request was sent to and true if it was sent to the specific peer
+func (p *ProximaNode) PullFromPeer(id peer.ID, txid *ledger.TransactionID, failedPulls, nPeersFallback int) (int, bool) {
+	if failedPulls < global.PullFromPeerMaxFailures && p.peers.PullTransactionsFromPeer(id, *txid) {
+		return 1, true
+	}
+	return p.peers.PullTransactionsFromNPeers(nPeersFallback, *txid), false
+}
+
 func (p *ProximaNode) GetOwnSequencerID() *ledger.ChainID {
 	if p.sequencer == nil {
 		return nil
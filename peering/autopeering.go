@@ -8,6 +8,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/discovery"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/lunfardo314/proxima/util"
+	"github.com/lunfardo314/proxima/util/set"
 )
 
 const (
@@ -16,7 +17,7 @@ const (
 )
 
 func (ps *Peers) isCandidateToConnect(id peer.ID) (yes bool) {
-	if id == ps.host.ID() {
+	if id == ps.host.ID() || ps.IsPeerGated(id) {
 		return
 	}
 	ps.withPeer(id, func(p *Peer) {
@@ -36,16 +37,19 @@ func (ps *Peers) discoverPeersIfNeeded() {
 	util.Assertf(maxToAdd > 0, "maxToAdd > 0")
 
 	const peerDiscoveryLimit = 20
-	peerChan, err := ps.routingDiscovery.FindPeers(ps.Ctx(), ps.rendezvousString, discovery.Limit(peerDiscoveryLimit))
-	if err != nil {
-		ps.Log().Errorf("[peering] unexpected error while trying to discover peers")
-		return
-	}
-
 	candidates := make([]peer.AddrInfo, 0)
-	for addrInfo := range peerChan {
-		if ps.isCandidateToConnect(addrInfo.ID) {
-			candidates = append(candidates, addrInfo)
+	seen := set.New[peer.ID]()
+	for _, rendezvous := range ps.rendezvousStrings {
+		peerChan, err := ps.routingDiscovery.FindPeers(ps.Ctx(), rendezvous, discovery.Limit(peerDiscoveryLimit))
+		if err != nil {
+			ps.Log().Errorf("[peering] unexpected error while trying to discover peers with rendezvous '%s'", rendezvous)
+			continue
+		}
+		for addrInfo := range peerChan {
+			if !seen.Contains(addrInfo.ID) && ps.isCandidateToConnect(addrInfo.ID) {
+				seen.Insert(addrInfo.ID)
+				candidates = append(candidates, addrInfo)
+			}
 		}
 	}
 	ps.Tracef(TraceTagAutopeering, "FindPeers: len(candidates) = %d", len(candidates))
@@ -0,0 +1,77 @@
+package peering
+
+import (
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/connmgr"
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/lunfardo314/proxima/util/set"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/spf13/viper"
+)
+
+// peerGater is libp2p connection gater which rejects connections to/from statically denied peers.
+// If allow list is not empty, only peers from the list are allowed to connect.
+// Static deny list is permanent, as opposite to the dynamic blacklist with TTL, which only
+// makes messages from the peer ignored
+type peerGater struct {
+	deny  set.Set[peer.ID]
+	allow set.Set[peer.ID]
+}
+
+var _ connmgr.ConnectionGater = &peerGater{}
+
+func newPeerGater(deny, allow []peer.ID) *peerGater {
+	return &peerGater{
+		deny:  set.New[peer.ID](deny...),
+		allow: set.New[peer.ID](allow...),
+	}
+}
+
+func (g *peerGater) isAllowed(id peer.ID) bool {
+	if g.deny.Contains(id) {
+		return false
+	}
+	return len(g.allow) == 0 || g.allow.Contains(id)
+}
+
+func (g *peerGater) InterceptPeerDial(id peer.ID) bool {
+	return g.isAllowed(id)
+}
+
+func (g *peerGater) InterceptAddrDial(id peer.ID, _ multiaddr.Multiaddr) bool {
+	return g.isAllowed(id)
+}
+
+func (g *peerGater) InterceptAccept(_ network.ConnMultiaddrs) bool {
+	// peer ID is not known yet
+	return true
+}
+
+func (g *peerGater) InterceptSecured(_ network.Direction, id peer.ID, _ network.ConnMultiaddrs) bool {
+	return g.isAllowed(id)
+}
+
+func (g *peerGater) InterceptUpgraded(_ network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+// IsPeerGated returns true if connections with the peer are rejected by the static deny/allow lists
+func (ps *Peers) IsPeerGated(id peer.ID) bool {
+	return !ps.gater.isAllowed(id)
+}
+
+func readPeerIDList(key string) ([]peer.ID, error) {
+	lst := viper.GetStringSlice(key)
+	ret := make([]peer.ID, 0, len(lst))
+	for _, s := range lst {
+		id, err := peer.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: can't decode peer ID '%s': %w", key, s, err)
+		}
+		ret = append(ret, id)
+	}
+	return ret, nil
+}
@@ -2,12 +2,16 @@ package peering
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/util"
 	"github.com/lunfardo314/proxima/util/lines"
 	"github.com/multiformats/go-multiaddr"
@@ -18,12 +22,26 @@ type heartbeatInfo struct {
 	clock                  time.Time
 	counter                uint32
 	respondsToPullRequests bool
+	goingAway              bool
+	// sync status of the sender. Not present in heartbeats from older versions
+	hasSyncInfo         bool
+	latestCommittedSlot uint32
+	synced              bool
 }
 
 // flags of the heartbeat message. Information for the peer about the node
 const (
 	// flagRespondsToPullRequests if false, node ignores all pull requests from the message target
 	flagRespondsToPullRequests = byte(0b00000001)
+	// flagGoingAway node is shutting down. The peer is marked dead immediately and is not used as a pull target
+	flagGoingAway = byte(0b00000010)
+	// flagSynced node is synced with the network
+	flagSynced = byte(0b00000100)
+)
+
+const (
+	heartbeatSizeWithoutSyncInfo = 1 + 8 + 4
+	heartbeatSize                = heartbeatSizeWithoutSyncInfo + 4
 )
 
 const (
@@ -93,23 +111,32 @@ func (ps *Peers) heartbeatStreamHandler(stream network.Stream) {
 	}
 
 	var hbInfo heartbeatInfo
-	msgData, err := readFrame(stream)
+	msgData, err := readFrame(stream, ps.maxMessageBytes())
 	_ = stream.Close()
 
 	if err != nil {
 		ps.Log().Errorf("[peering] hb: error while reading message from peer %s: err='%v'. Ignore", ShortPeerIDString(id), err)
 		return
 	}
-	if hbInfo, err = heartbeatInfoFromBytes(msgData); err != nil {
+	if hbInfo, err = heartbeatInfoFromBytes(msgData, stream.Protocol() == ps.lppProtocolHeartbeat); err != nil {
 		// protocol violation
 		err = fmt.Errorf("[peering] hb: error while serializing message from peer %s: %v. Reset connection", ShortPeerIDString(id), err)
 		ps.Log().Error(err)
 		ps.dropPeer(id, err.Error())
 		return
 	}
+	ps.processHeartbeat(id, remote, hbInfo)
+}
 
+// processHeartbeat evidences heartbeat from the peer. Unknown peer is added as dynamic, unless throttled
+func (ps *Peers) processHeartbeat(id peer.ID, remote multiaddr.Multiaddr, hbInfo heartbeatInfo) {
 	ps.withPeer(id, func(p *Peer) {
 		if p == nil {
+			if !ps._acceptNewDynamicPeer() {
+				ps.Tracef(TraceTagAutopeering, "too many new dynamic peers within %v. Ignore heartbeat from %s",
+					newDynamicPeersWindow, ShortPeerIDString(id))
+				return
+			}
 			addrInfo := &peer.AddrInfo{
 				ID:    id,
 				Addrs: []multiaddr.Multiaddr{remote},
@@ -121,12 +148,24 @@ func (ps *Peers) heartbeatStreamHandler(stream network.Stream) {
 }
 
 func (ps *Peers) _evidenceHeartBeat(p *Peer, hbInfo heartbeatInfo) {
+	if hbInfo.goingAway {
+		if !p.goingAway {
+			ps.Log().Infof("[peering] %s peer %s ('%s') is going away",
+				util.Cond(p.isStatic, "static", "dynamic"), ShortPeerIDString(p.id), p.name)
+		}
+		p.goingAway = true
+		p.respondsToPullRequests = false
+		return
+	}
+	// normal heartbeat after going away means peer is back, e.g. restarted
+	p.goingAway = false
 	nowis := time.Now()
 
 	// clock differences
 	diff := nowis.Sub(hbInfo.clock)
 	p.clockDifferences[p.clockDifferencesIdx] = diff
 	p.clockDifferencesIdx = (p.clockDifferencesIdx + 1) % len(p.clockDifferences)
+	p.clockDifferencesNum = min(p.clockDifferencesNum+1, len(p.clockDifferences))
 	q := util.Quartiles(p.clockDifferences[:])
 	p.clockDifferenceQuartiles = q
 
@@ -141,28 +180,101 @@ func (ps *Peers) _evidenceHeartBeat(p *Peer, hbInfo heartbeatInfo) {
 	p.lastHeartbeatReceived = nowis
 
 	p.respondsToPullRequests = hbInfo.respondsToPullRequests
+	if hbInfo.hasSyncInfo {
+		p.hasSyncInfo = true
+		p.latestCommittedSlot = ledger.Slot(hbInfo.latestCommittedSlot)
+		p.synced = hbInfo.synced
+	}
+	if ps.host != nil {
+		ps._refreshDynamicPeerAddrTTL(p)
+	}
 
 	ps.Tracef(TraceTagHeartBeatRecv, ">>>>> received #%d from %s: clock diff: %v, median: %v, responds to pull: %v, alive: %v",
 		hbInfo.counter, ShortPeerIDString(p.id), diff, q[1], p.respondsToPullRequests, p._isAlive())
 }
 
 func (ps *Peers) sendHeartbeatToPeer(id peer.ID, hbCounter uint32) {
-	respondsToPull := true
-	if ps.cfg.IgnoreAllPullRequests {
+	msg := ps.makeHeartbeat(id, hbCounter)
+	if ps.sendMsgOut(id, ps.heartbeatProtocols(), msg.bytesForProtocol(ps.lppProtocolHeartbeat)) {
+		ps.Tracef(TraceTagHeartBeatSend, ">>>>>>> sent #%d to %s", hbCounter, ShortPeerIDString(id))
+	}
+}
+
+func (ps *Peers) makeHeartbeat(id peer.ID, hbCounter uint32) *heartbeatInfo {
+	goingAway := ps.goingAway.Load()
+	respondsToPull := !goingAway
+	if ps.ignoresAllPullRequests() {
 		respondsToPull = false
 	} else if ps.cfg.AcceptPullRequestsFromStaticPeersOnly {
-		respondsToPull = ps.staticPeers.Contains(id)
+		ps.mutex.RLock()
+		respondsToPull = respondsToPull && ps.staticPeers.Contains(id)
+		ps.mutex.RUnlock()
 	}
 
-	msg := &heartbeatInfo{
+	return &heartbeatInfo{
 		// time now will be set in the queue consumer
 		respondsToPullRequests: respondsToPull,
+		goingAway:              goingAway,
+		hasSyncInfo:            true,
+		latestCommittedSlot:    ps.latestCommittedSlot.Load(),
+		synced:                 ps.synced.Load(),
 		counter:                hbCounter,
 		clock:                  time.Now(),
 	}
-	if ps.sendMsgBytesOut(id, ps.lppProtocolHeartbeat, msg.Bytes()) {
-		ps.Tracef(TraceTagHeartBeatSend, ">>>>>>> sent #%d to %s", hbCounter, ShortPeerIDString(id))
+}
+
+// heartbeatProtocols current heartbeat protocol is preferred, legacy one is used with peers of older versions
+func (ps *Peers) heartbeatProtocols() []protocol.ID {
+	return []protocol.ID{ps.lppProtocolHeartbeat, ps.lppProtocolHeartbeatLegacy}
+}
+
+// updateSyncStatus takes sync status from the source once per heartbeat round
+func (ps *Peers) updateSyncStatus() {
+	ps.mutex.RLock()
+	source := ps.syncStatusSource
+	ps.mutex.RUnlock()
+
+	if source == nil {
+		return
 	}
+	slot, synced := source()
+	ps.latestCommittedSlot.Store(uint32(slot))
+	ps.synced.Store(synced)
+}
+
+// PeerLatestSlot returns latest committed slot reported by the peer in heartbeats.
+// Returns false if peer is unknown or it does not report sync status
+func (ps *Peers) PeerLatestSlot(id peer.ID) (slot ledger.Slot, ok bool) {
+	ps.withPeer(id, func(p *Peer) {
+		if p != nil && p.hasSyncInfo {
+			slot, ok = p.latestCommittedSlot, true
+		}
+	})
+	return
+}
+
+// PeerIsSynced returns true if peer reported it is synced with the network
+func (ps *Peers) PeerIsSynced(id peer.ID) (synced bool) {
+	ps.withPeer(id, func(p *Peer) {
+		synced = p != nil && p.synced
+	})
+	return
+}
+
+// sendGoingAway notifies all peers that the node is shutting down. Global context is already done at this point
+func (ps *Peers) sendGoingAway() {
+	ps.goingAway.Store(true)
+
+	var wg sync.WaitGroup
+	for _, id := range ps.peerIDs() {
+		wg.Add(1)
+		go func(id peer.ID) {
+			defer wg.Done()
+			msg := ps.makeHeartbeat(id, 0)
+			ps.sendMsgOutWithContext(context.Background(), id, ps.heartbeatProtocols(), msg.bytesForProtocol(ps.lppProtocolHeartbeat))
+		}(id)
+	}
+	wg.Wait()
 }
 
 func (ps *Peers) peerIDsAlive(except ...peer.ID) []peer.ID {
@@ -190,16 +302,17 @@ func (ps *Peers) logBigClockDiffs() {
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 
+	tolerance := ps.ClockTolerance()
 	logLines := lines.New()
 	warn := false
 	for _, p := range ps.peers {
-		if p.clockDifferenceQuartiles[1] > clockTolerance {
+		if p.clockDifferenceQuartiles[1] > tolerance {
 			logLines.Add("%s(%s): %v", ShortPeerIDString(p.id), util.Cond(p.isStatic, "static", "dynamic"), p.clockDifferenceQuartiles)
 			warn = true
 		}
 	}
 	if warn {
-		ps.Log().Warnf("peers with clock difference median > tolerance (%d): {%s}", clockTolerance, logLines.Join(", "))
+		ps.Log().Warnf("peers with clock difference median > tolerance (%v): {%s}", tolerance, logLines.Join(", "))
 	}
 }
 
@@ -207,11 +320,33 @@ func (hi *heartbeatInfo) flags() (ret byte) {
 	if hi.respondsToPullRequests {
 		ret |= flagRespondsToPullRequests
 	}
+	if hi.goingAway {
+		ret |= flagGoingAway
+	}
+	if hi.synced {
+		ret |= flagSynced
+	}
 	return
 }
 
 func (hi *heartbeatInfo) setFromFlags(fl byte) {
 	hi.respondsToPullRequests = (fl & flagRespondsToPullRequests) != 0
+	hi.goingAway = (fl & flagGoingAway) != 0
+	hi.synced = (fl & flagSynced) != 0
+}
+
+// bytesForProtocol serializes heartbeat for the negotiated protocol. Sync info is only sent with the current protocol,
+// because parsers of older versions reject heartbeats of other sizes
+func (hi *heartbeatInfo) bytesForProtocol(current protocol.ID) func(protocolID protocol.ID) []byte {
+	return func(protocolID protocol.ID) []byte {
+		if protocolID == current {
+			return hi.Bytes()
+		}
+		legacy := *hi
+		legacy.hasSyncInfo = false
+		legacy.synced = false
+		return legacy.Bytes()
+	}
 }
 
 func (hi *heartbeatInfo) Bytes() []byte {
@@ -220,11 +355,15 @@ func (hi *heartbeatInfo) Bytes() []byte {
 	buf.WriteByte(hi.flags())
 	_ = binary.Write(&buf, binary.BigEndian, uint64(hi.clock.UnixNano()))
 	_ = binary.Write(&buf, binary.BigEndian, hi.counter)
+	if hi.hasSyncInfo {
+		_ = binary.Write(&buf, binary.BigEndian, hi.latestCommittedSlot)
+	}
 	return buf.Bytes()
 }
 
-func heartbeatInfoFromBytes(data []byte) (heartbeatInfo, error) {
-	if len(data) != 1+8+4 {
+// heartbeatInfoFromBytes parses heartbeat of the current protocol (with sync info) or of the legacy one (without)
+func heartbeatInfoFromBytes(data []byte, withSyncInfo bool) (heartbeatInfo, error) {
+	if len(data) != util.Cond(withSyncInfo, heartbeatSize, heartbeatSizeWithoutSyncInfo) {
 		return heartbeatInfo{}, fmt.Errorf("heartbeatInfoFromBytes: wrong data len")
 	}
 	ret := heartbeatInfo{
@@ -232,5 +371,9 @@ func heartbeatInfoFromBytes(data []byte) (heartbeatInfo, error) {
 		counter: binary.BigEndian.Uint32(data[9 : 9+4]),
 	}
 	ret.setFromFlags(data[0])
+	if withSyncInfo {
+		ret.hasSyncInfo = true
+		ret.latestCommittedSlot = binary.BigEndian.Uint32(data[heartbeatSizeWithoutSyncInfo:])
+	}
 	return ret, nil
 }
@@ -0,0 +1,130 @@
+package peering
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+)
+
+const (
+	TraceTagIsolation = "peering_isolation"
+	// rebootstrapConnectTimeout is timeout for each connect attempt to the static peer while re-bootstrapping
+	rebootstrapConnectTimeout = 5 * time.Second
+	// re-bootstrap attempts while isolated are repeated with exponential backoff between min and max
+	rebootstrapMinBackoff = 5 * time.Second
+	rebootstrapMaxBackoff = 2 * time.Minute
+)
+
+// isIsolatedNow node is isolated when it has peers and none of them is alive. Peers in the grace period are not considered dead.
+// Node without peers, for example the bootstrap node without pre-configured peers, is not isolated
+func (ps *Peers) isIsolatedNow() bool {
+	stats := ps.peerStats()
+	return stats.peersAll > 0 && stats.peersAlive == 0 && stats.peersDead == stats.peersAll
+}
+
+// IsIsolated returns true if node has lost all its peers
+func (ps *Peers) IsIsolated() bool {
+	return ps.isolated.Load()
+}
+
+// NumRebootstrapAttempts returns number of re-bootstrap attempts made while node was isolated
+func (ps *Peers) NumRebootstrapAttempts() int {
+	return int(ps.rebootstrapAttempts.Load())
+}
+
+// checkIsolation updates isolation state. If node is isolated and recovery is enabled, makes the re-bootstrap attempt
+func (ps *Peers) checkIsolation() {
+	isolated := ps.isIsolatedNow()
+	if wasIsolated := ps.isolated.Swap(isolated); wasIsolated != isolated {
+		if isolated {
+			ps.Log().Warnf("[peering] node is ISOLATED: all peers are dead")
+		} else {
+			ps.Log().Infof("[peering] node is not isolated anymore")
+		}
+	}
+	ps.updateIsolationMetrics(isolated)
+
+	if !isolated {
+		ps.rebootstrapBackoff.Store(0)
+		ps.nextRebootstrap.Store(0)
+		return
+	}
+	if !ps.cfg.RecoverWhenIsolated || time.Now().UnixNano() < ps.nextRebootstrap.Load() {
+		return
+	}
+	if !ps.rebootstrapInProgress.CompareAndSwap(false, true) {
+		// previous attempt is not finished yet
+		return
+	}
+	backoff := time.Duration(ps.rebootstrapBackoff.Load())
+	backoff = min(max(2*backoff, rebootstrapMinBackoff), rebootstrapMaxBackoff)
+	ps.rebootstrapBackoff.Store(int64(backoff))
+	ps.nextRebootstrap.Store(time.Now().Add(backoff).UnixNano())
+
+	ps.rebootstrap()
+}
+
+// rebootstrap makes an attempt to recover from isolation:
+// - un-blacklists static peers and re-connects to them with fresh addresses
+// - triggers immediate autopeering round, if autopeering is enabled
+// The attempt is finished asynchronously, then rebootstrapInProgress flag is cleared
+func (ps *Peers) rebootstrap() {
+	attempt := ps.rebootstrapAttempts.Add(1)
+	ps.rebootstrapCounter.Inc()
+	ps.Tracef(TraceTagIsolation, "re-bootstrap attempt #%d, next not earlier than in %v",
+		attempt, time.Duration(ps.rebootstrapBackoff.Load()))
+
+	addrInfos := make([]peer.AddrInfo, 0, len(ps.cfg.PreConfiguredPeers))
+
+	ps.mutex.Lock()
+	for _, maddr := range ps.cfg.PreConfiguredPeers {
+		info, err := peer.AddrInfoFromP2pAddr(maddr.Multiaddr)
+		if err != nil || !ps.staticPeers.Contains(info.ID) {
+			// skip pre-configured peers removed at runtime
+			continue
+		}
+		addrInfos = append(addrInfos, *info)
+	}
+	for _, info := range addrInfos {
+		delete(ps.blacklist, info.ID)
+		ps.host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+	}
+	ps.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, info := range addrInfos {
+		infoCopy := info
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ps.Ctx(), rebootstrapConnectTimeout)
+			defer cancel()
+
+			if err := ps.host.Connect(ctx, infoCopy); err != nil {
+				ps.Tracef(TraceTagIsolation, "re-bootstrap: failed to connect to %s: %v", ShortPeerIDString(infoCopy.ID), err)
+			}
+		}()
+	}
+
+	if ps.isAutopeeringEnabled() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := ps.kademliaDHT.Bootstrap(ps.Ctx()); err != nil {
+				ps.Log().Errorf("[peering] re-bootstrap of DHT failed: %v", err)
+				return
+			}
+			ps.discoverPeersIfNeeded()
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		ps.rebootstrapInProgress.Store(false)
+	}()
+}
@@ -1,6 +1,10 @@
 package peering
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 type metrics struct {
 	// msg metrics
@@ -8,6 +12,8 @@ type metrics struct {
 	outMsgCounter   prometheus.Counter
 	pullRequestsIn  prometheus.Counter
 	pullRequestsOut prometheus.Counter
+	// pull requests issued, split by target selection: 'random' subset, 'all' pull targets or specific 'peer'
+	pullRequestsIssued *prometheus.CounterVec
 
 	// peers metrics
 	peersAll         prometheus.Gauge
@@ -15,10 +21,20 @@ type metrics struct {
 	peersDead        prometheus.Gauge
 	peersAlive       prometheus.Gauge
 	peersPullTargets prometheus.Gauge
+	peersIsolated    prometheus.Gauge
+	// alive peers, blacklisted peers and clock differences
+	peersAliveStatic  prometheus.Gauge
+	peersAliveDynamic prometheus.Gauge
+	peersBlacklisted  prometheus.Gauge
+	peerClockDiff     prometheus.Histogram
+
+	rebootstrapCounter prometheus.Counter
 
 	// txMsg metrics
 	transactionsReceivedCounter prometheus.Counter
 	txBytesReceivedCounter      prometheus.Counter
+	gossipParseFailures         prometheus.Counter
+	gossipRateLimited           prometheus.Counter
 }
 
 func (ps *Peers) registerMetrics() {
@@ -38,7 +54,11 @@ func (ps *Peers) registerMetrics() {
 		Name: "proxima_peering_pullRequestsOut",
 		Help: "counts number of sent pull request messages",
 	})
-	ps.MetricsRegistry().MustRegister(ps.inMsgCounter, ps.outMsgCounter, ps.pullRequestsIn, ps.pullRequestsOut)
+	ps.pullRequestsIssued = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxima_peering_pullRequestsIssued",
+		Help: "counts number of issued pull requests, by target selection: random subset, all pull targets or specific peer",
+	}, []string{"targets"})
+	ps.MetricsRegistry().MustRegister(ps.inMsgCounter, ps.outMsgCounter, ps.pullRequestsIn, ps.pullRequestsOut, ps.pullRequestsIssued)
 
 	// peers metrics
 	ps.peersAll = prometheus.NewGauge(prometheus.GaugeOpts{
@@ -61,7 +81,34 @@ func (ps *Peers) registerMetrics() {
 		Name: "proxima_peers_pull_targets",
 		Help: "number of possible pull targets",
 	})
-	ps.MetricsRegistry().MustRegister(ps.peersAll, ps.peersStatic, ps.peersDead, ps.peersAlive, ps.peersPullTargets)
+	ps.peersIsolated = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "proxima_peers_isolated",
+		Help: "1 if all peers are dead, 0 otherwise",
+	})
+	ps.rebootstrapCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "proxima_peering_rebootstrap",
+		Help: "counts number of re-bootstrap attempts while isolated",
+	})
+	ps.MetricsRegistry().MustRegister(ps.peersAll, ps.peersStatic, ps.peersDead, ps.peersAlive, ps.peersPullTargets, ps.peersIsolated, ps.rebootstrapCounter)
+
+	ps.peersAliveStatic = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "proxima_peers_alive_static",
+		Help: "number of alive static peers",
+	})
+	ps.peersAliveDynamic = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "proxima_peers_alive_dynamic",
+		Help: "number of alive dynamic peers",
+	})
+	ps.peersBlacklisted = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "proxima_peers_blacklisted",
+		Help: "number of currently blacklisted peers",
+	})
+	ps.peerClockDiff = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "proxima_peer_clock_diff_seconds",
+		Help:    "average clock difference with alive peers in seconds, absolute value",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8},
+	})
+	ps.MetricsRegistry().MustRegister(ps.peersAliveStatic, ps.peersAliveDynamic, ps.peersBlacklisted, ps.peerClockDiff)
 
 	// tx counters
 	ps.transactionsReceivedCounter = prometheus.NewCounter(prometheus.CounterOpts{
@@ -73,7 +120,15 @@ func (ps *Peers) registerMetrics() {
 		Name: "proxima_peering_txBytesReceived",
 		Help: "counts number of received transaction bytes",
 	})
-	ps.MetricsRegistry().MustRegister(ps.transactionsReceivedCounter, ps.txBytesReceivedCounter)
+	ps.gossipParseFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "proxima_peering_gossipParseFailures",
+		Help: "counts number of gossip messages which failed to parse. Number by peer is in the peers info",
+	})
+	ps.gossipRateLimited = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "proxima_peering_gossipRateLimited",
+		Help: "counts number of incoming gossip messages rejected by the rate limiter",
+	})
+	ps.MetricsRegistry().MustRegister(ps.transactionsReceivedCounter, ps.txBytesReceivedCounter, ps.gossipParseFailures, ps.gossipRateLimited)
 }
 
 func (ps *Peers) peerStats() (ret peersStats) {
@@ -81,6 +136,12 @@ func (ps *Peers) peerStats() (ret peersStats) {
 		ret.peersAll++
 		if p._isAlive() {
 			ret.peersAlive++
+			if p.isStatic {
+				ret.peersAliveStatic++
+			} else {
+				ret.peersAliveDynamic++
+			}
+			ret.clockDiffs = append(ret.clockDiffs, p._avgClockDifference())
 		}
 		if p._isDead() {
 			ret.peersDead++
@@ -93,6 +154,9 @@ func (ps *Peers) peerStats() (ret peersStats) {
 		}
 		return true
 	})
+	ps.mutex.RLock()
+	ret.peersBlacklisted = len(ps.blacklist)
+	ps.mutex.RUnlock()
 	return
 }
 
@@ -102,4 +166,18 @@ func (ps *Peers) updatePeerMetrics(stats peersStats) {
 	ps.peersDead.Set(float64(stats.peersDead))
 	ps.peersAlive.Set(float64(stats.peersAlive))
 	ps.peersPullTargets.Set(float64(stats.peersPullTargets))
+	ps.peersAliveStatic.Set(float64(stats.peersAliveStatic))
+	ps.peersAliveDynamic.Set(float64(stats.peersAliveDynamic))
+	ps.peersBlacklisted.Set(float64(stats.peersBlacklisted))
+	for _, d := range stats.clockDiffs {
+		ps.peerClockDiff.Observe(math.Abs(d.Seconds()))
+	}
+}
+
+func (ps *Peers) updateIsolationMetrics(isolated bool) {
+	if isolated {
+		ps.peersIsolated.Set(1)
+	} else {
+		ps.peersIsolated.Set(0)
+	}
 }
@@ -5,24 +5,27 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"time"
 
-	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
-// MaxPayloadSize caps the message size. It includes 4 bytes of the size
 const (
+	// MaxPayloadSize default cap of the message size. It includes 4 bytes of the size
 	MaxPayloadSize = math.MaxUint16 - 4
+	// MaxMessageBytesLimit absolute limit of the configurable message size cap
+	MaxMessageBytesLimit = 1<<24 - 4
 )
 
-func readFrame(stream network.Stream) ([]byte, error) {
+// readFrame reads size-prefixed message. Message bigger than maxSize is rejected
+func readFrame(stream io.Reader, maxSize int) ([]byte, error) {
 	var size uint32
 
 	if err := binary.Read(stream, binary.BigEndian, &size); err != nil {
 		return nil, fmt.Errorf("readFrame: failed to read frame size prefix: %w", err)
 	}
-	if size > MaxPayloadSize {
-		return nil, fmt.Errorf("payload size %d exceeds maximum %d bytes", size, MaxPayloadSize)
+	if int64(size) > int64(maxSize) {
+		return nil, fmt.Errorf("readFrame: payload size %d exceeds maximum %d bytes", size, maxSize)
 	}
 	if size == 0 {
 		return nil, nil
@@ -38,9 +41,10 @@ func readFrame(stream network.Stream) ([]byte, error) {
 	return msgBuf, nil
 }
 
-func writeFrame(stream network.Stream, payload []byte) error {
-	if len(payload) > MaxPayloadSize {
-		return fmt.Errorf("payload size %d exceeds maximum %d bytes", len(payload), MaxPayloadSize)
+// writeFrame writes size-prefixed message. Message bigger than maxSize is not sent
+func writeFrame(stream io.Writer, payload []byte, maxSize int) error {
+	if len(payload) > maxSize {
+		return fmt.Errorf("writeFrame: payload size %d exceeds maximum %d bytes", len(payload), maxSize)
 	}
 	if err := binary.Write(stream, binary.BigEndian, uint32(len(payload))); err != nil {
 		return fmt.Errorf("writeFrame: failed to write size prefix: %v", err)
@@ -57,6 +61,18 @@ func writeFrame(stream network.Stream, payload []byte) error {
 	return nil
 }
 
+// writeFrameWithDeadline bounds writing of the frame by the deadline. Peer which does not read the stream
+// makes the write fail after the deadline
+func writeFrameWithDeadline(stream interface {
+	io.Writer
+	SetWriteDeadline(time.Time) error
+}, payload []byte, maxSize int, deadline time.Time) error {
+	if err := stream.SetWriteDeadline(deadline); err != nil {
+		return fmt.Errorf("writeFrameWithDeadline: %v", err)
+	}
+	return writeFrame(stream, payload, maxSize)
+}
+
 func ShortPeerIDString(id peer.ID) string {
 	s := id.String()
 
@@ -2,19 +2,31 @@ package peering
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/discovery"
+	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/lunfardo314/proxima/api"
 	"github.com/lunfardo314/proxima/core/txmetadata"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/util"
 	"github.com/lunfardo314/proxima/util/countdown"
 	"github.com/lunfardo314/proxima/util/set"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 )
@@ -332,3 +344,932 @@ func TestSendMsg(t *testing.T) {
 		require.EqualValues(t, 0, len(txSet))
 	})
 }
+
+func TestIsolation(t *testing.T) {
+	const numHosts = 3
+	cfg := MakeConfigFor(numHosts, 0)
+	cfg.RecoverWhenIsolated = true
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	ps.checkIsolation()
+	require.False(t, ps.IsIsolated())
+	require.EqualValues(t, 0, ps.NumRebootstrapAttempts())
+
+	// simulate loss of all peers: other hosts are not running and grace period is over
+	ps.forEachPeerRLock(func(p *Peer) bool {
+		p.whenAdded = time.Now().Add(-2 * gracePeriodAfterAdded)
+		return true
+	})
+	for _, id := range ps.getPeerIDs() {
+		ps.dropPeer(id, "test")
+	}
+	ps.checkIsolation()
+	require.True(t, ps.IsIsolated())
+	require.EqualValues(t, 1, ps.NumRebootstrapAttempts())
+	require.EqualValues(t, rebootstrapMinBackoff, time.Duration(ps.rebootstrapBackoff.Load()))
+
+	// static peers are un-blacklisted by the re-bootstrap
+	ps.mutex.RLock()
+	require.EqualValues(t, 0, len(ps.blacklist))
+	ps.mutex.RUnlock()
+
+	// no new attempt while the previous one is in progress or before the backoff expires
+	ps.checkIsolation()
+	require.EqualValues(t, 1, ps.NumRebootstrapAttempts())
+	require.Eventually(t, func() bool { return !ps.rebootstrapInProgress.Load() }, 2*rebootstrapConnectTimeout, 50*time.Millisecond)
+	ps.checkIsolation()
+	require.EqualValues(t, 1, ps.NumRebootstrapAttempts())
+
+	// backoff expired -> next attempt with doubled backoff
+	ps.nextRebootstrap.Store(time.Now().UnixNano())
+	ps.checkIsolation()
+	require.EqualValues(t, 2, ps.NumRebootstrapAttempts())
+	require.EqualValues(t, 2*rebootstrapMinBackoff, time.Duration(ps.rebootstrapBackoff.Load()))
+
+	env.Stop()
+}
+
+func TestIsolationNoPeers(t *testing.T) {
+	cfg := MakeConfigFor(1, 0)
+	cfg.RecoverWhenIsolated = true
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	// node without configured peers is never isolated
+	ps.checkIsolation()
+	require.False(t, ps.IsIsolated())
+	require.EqualValues(t, 0, ps.NumRebootstrapAttempts())
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestDynamicPeerAddrTTL(t *testing.T) {
+	const ttl = 300 * time.Millisecond
+	cfg := MakeConfigFor(2, 0)
+	cfg.DynamicPeerAddrTTL = ttl
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	staticID, err := peer.Decode(hostID[1])
+	require.NoError(t, err)
+	dynamicInfo, err := peer.AddrInfoFromString(MultiAddrString(2, BeginPort+2))
+	require.NoError(t, err)
+	require.True(t, ps.addPeer(dynamicInfo, "", false))
+
+	require.True(t, len(ps.host.Peerstore().Addrs(staticID)) > 0)
+	require.True(t, len(ps.host.Peerstore().Addrs(dynamicInfo.ID)) > 0)
+
+	// heartbeat refreshes TTL of the dynamic peer
+	time.Sleep(ttl / 2)
+	ps.withPeer(dynamicInfo.ID, func(p *Peer) {
+		ps._evidenceHeartBeat(p, heartbeatInfo{clock: time.Now()})
+	})
+	time.Sleep(ttl / 2)
+	require.True(t, len(ps.host.Peerstore().Addrs(dynamicInfo.ID)) > 0)
+
+	// without heartbeats dynamic addresses expire, static persist
+	time.Sleep(2 * ttl)
+	require.EqualValues(t, 0, len(ps.host.Peerstore().Addrs(dynamicInfo.ID)))
+	require.True(t, len(ps.host.Peerstore().Addrs(staticID)) > 0)
+
+	env.Stop()
+}
+
+func TestPauseServing(t *testing.T) {
+	const numHosts = 2
+	hosts := makeHosts(t, numHosts, false)
+
+	var pullsReceived, txReceived atomic.Int64
+	hosts[1].OnReceivePullTxRequest(func(from peer.ID, txid ledger.TransactionID) {
+		pullsReceived.Inc()
+		hosts[1].SendTxBytesWithMetadataToPeer(from, txid[:], nil)
+	})
+	hosts[0].OnReceiveTxBytes(func(from peer.ID, txBytes []byte, _ *txmetadata.TransactionMetadata) {
+		txReceived.Inc()
+	})
+	for _, h := range hosts {
+		h.Run()
+	}
+	time.Sleep(heartbeatRate * 3)
+	id1 := hosts[1].host.ID()
+
+	hosts[1].PauseServing()
+	require.True(t, hosts[1].IsServingPaused())
+	time.Sleep(heartbeatRate * 3)
+	// paused host announces it does not respond to pulls
+	require.EqualValues(t, 0, hosts[0].PullTransactionsFromNPeers(1, ledger.RandomTransactionID(false)))
+
+	hosts[0].sendPullTransactionToPeers([]peer.ID{id1}, ledger.RandomTransactionID(false))
+	time.Sleep(time.Second)
+	require.EqualValues(t, 0, pullsReceived.Load())
+	require.EqualValues(t, 0, txReceived.Load())
+
+	hosts[1].ResumeServing()
+	require.False(t, hosts[1].IsServingPaused())
+	time.Sleep(heartbeatRate * 3)
+	require.EqualValues(t, 1, hosts[0].PullTransactionsFromNPeers(1, ledger.RandomTransactionID(false)))
+	time.Sleep(time.Second)
+	require.EqualValues(t, 1, pullsReceived.Load())
+	require.EqualValues(t, 1, txReceived.Load())
+
+	for _, h := range hosts {
+		h.Stop()
+	}
+}
+
+func TestSelfAddrs(t *testing.T) {
+	cfg := MakeConfigFor(1, 0)
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	addrs := ps.SelfAddrs()
+	require.True(t, len(addrs) > 0)
+	for _, a := range addrs {
+		t.Logf("self addr: %s", a.String())
+		port, err := a.ValueForProtocol(multiaddr.P_UDP)
+		require.NoError(t, err)
+		require.EqualValues(t, strconv.Itoa(cfg.HostPort), port)
+	}
+	require.EqualValues(t, "unknown", ps.NATStatus())
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestConnectionGater(t *testing.T) {
+	const numHosts = 3
+	deniedID, err := peer.Decode(hostID[1])
+	require.NoError(t, err)
+	allowedID, err := peer.Decode(hostID[2])
+	require.NoError(t, err)
+
+	cfg := MakeConfigFor(numHosts, 0)
+	cfg.DenyPeers = []peer.ID{deniedID}
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	// denied static peer is not added at all
+	require.True(t, ps.IsPeerGated(deniedID))
+	require.True(t, ps.getPeer(deniedID) == nil)
+	require.False(t, ps.IsPeerGated(allowedID))
+	require.True(t, ps.getPeer(allowedID) != nil)
+
+	// at runtime, denied peer is rejected with the error
+	maddrDenied, err := multiaddr.NewMultiaddr(MultiAddrString(1, BeginPort+1))
+	require.NoError(t, err)
+	err = ps.AddStaticPeer(maddrDenied, "denied")
+	util.RequireErrorWith(t, err, "denied by the connection gater")
+	require.True(t, ps.getPeer(deniedID) == nil)
+
+	// inbound connection from the denied peer is rejected at the gate
+	require.False(t, ps.gater.InterceptSecured(network.DirInbound, deniedID, nil))
+	require.True(t, ps.gater.InterceptSecured(network.DirInbound, allowedID, nil))
+
+	// outbound dial to the denied peer is rejected before any connection attempt
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrInfo, err := peer.AddrInfoFromString(MultiAddrString(1, BeginPort+1))
+	require.NoError(t, err)
+	err = ps.host.Connect(ctx, *addrInfo)
+	require.Error(t, err)
+	require.NotEqual(t, network.Connected, ps.host.Network().Connectedness(deniedID))
+
+	env.Stop()
+	_ = ps.host.Close()
+
+	// with allow list, only listed peers pass the gate
+	g := newPeerGater(nil, []peer.ID{allowedID})
+	require.True(t, g.InterceptPeerDial(allowedID))
+	require.False(t, g.InterceptPeerDial(deniedID))
+
+	// deny list takes precedence over allow list
+	g = newPeerGater([]peer.ID{allowedID}, []peer.ID{allowedID})
+	require.False(t, g.InterceptPeerDial(allowedID))
+}
+
+func TestGossipParseFailures(t *testing.T) {
+	const maxFailures = 2
+	cfg := MakeConfigFor(2, 0)
+	cfg.MaxGossipParseFailures = maxFailures
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	id, err := peer.Decode(hostID[1])
+	require.NoError(t, err)
+	require.True(t, ps.getPeer(id) != nil)
+
+	garbage := []byte{0xff, 0xff, 0xff}
+	for i := 0; i < maxFailures; i++ {
+		ps.processGossipMsg(id, garbage)
+		_, blacklisted, _ := ps.knownPeer(id, nil)
+		require.False(t, blacklisted)
+	}
+	info := ps.GetPeersInfo()
+	require.EqualValues(t, 1, len(info.Peers))
+	require.EqualValues(t, maxFailures, info.Peers[0].NumGossipParseFailures)
+
+	// exceeding the threshold blacklists the peer
+	ps.processGossipMsg(id, garbage)
+	_, blacklisted, _ := ps.knownPeer(id, nil)
+	require.True(t, blacklisted)
+	require.EqualValues(t, maxFailures+1, int(testutil.ToFloat64(ps.gossipParseFailures)))
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+type discoveryForTesting struct {
+	mutex      sync.Mutex
+	advertised set.Set[string]
+	searched   set.Set[string]
+}
+
+func (d *discoveryForTesting) Advertise(_ context.Context, ns string, _ ...discovery.Option) (time.Duration, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.advertised.Insert(ns)
+	return time.Hour, nil
+}
+
+func (d *discoveryForTesting) FindPeers(_ context.Context, ns string, _ ...discovery.Option) (<-chan peer.AddrInfo, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.searched.Insert(ns)
+	ret := make(chan peer.AddrInfo)
+	close(ret)
+	return ret, nil
+}
+
+func TestAdditionalRendezvous(t *testing.T) {
+	cfg := MakeConfigFor(2, 0)
+	cfg.AdditionalRendezvous = []string{"alt1", "alt2", "alt1"}
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+	// the ledger-derived default is always the first
+	require.EqualValues(t, 3, len(ps.rendezvousStrings))
+	require.EqualValues(t, []string{"alt1", "alt2"}, ps.rendezvousStrings[1:])
+
+	d := &discoveryForTesting{advertised: set.New[string](), searched: set.New[string]()}
+	ps.routingDiscovery = d
+	ps.cfg.MaxDynamicPeers = 1
+
+	ps.advertiseRendezvous()
+	ps.discoverPeersIfNeeded()
+
+	require.Eventually(t, func() bool {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		return len(d.advertised) == len(ps.rendezvousStrings)
+	}, 5*time.Second, 10*time.Millisecond)
+	for _, r := range ps.rendezvousStrings {
+		require.True(t, d.advertised.Contains(r))
+		require.True(t, d.searched.Contains(r))
+	}
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestClockTolerance(t *testing.T) {
+	cfg := MakeConfigFor(2, 0)
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+	require.EqualValues(t, ClockTolerance, ps.ClockTolerance())
+	env.Stop()
+	_ = ps.host.Close()
+
+	cfg.ClockTolerance = 10 * time.Second
+	env = newEnvironment()
+	ps, err = New(env, cfg)
+	require.NoError(t, err)
+	require.EqualValues(t, 10*time.Second, ps.ClockTolerance())
+	env.Stop()
+	_ = ps.host.Close()
+
+	cfg.ClockTolerance = -time.Second
+	_, err = New(newEnvironment(), cfg)
+	util.RequireErrorWith(t, err, "clock tolerance must be positive")
+}
+
+func TestAddRemoveStaticPeer(t *testing.T) {
+	cfg := MakeConfigFor(3, 0)
+	delete(cfg.PreConfiguredPeers, "peer2")
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	id1, err := peer.Decode(hostID[1])
+	require.NoError(t, err)
+	id2, err := peer.Decode(hostID[2])
+	require.NoError(t, err)
+	require.True(t, ps.getPeer(id2) == nil)
+
+	maddr2, err := multiaddr.NewMultiaddr(MultiAddrString(2, BeginPort+2))
+	require.NoError(t, err)
+	// duplicate name is rejected
+	err = ps.AddStaticPeer(maddr2, "peer1")
+	util.RequireErrorWith(t, err, "already exists")
+
+	err = ps.AddStaticPeer(maddr2, "peer2")
+	require.NoError(t, err)
+	p := ps.getPeer(id2)
+	require.True(t, p != nil && p.isStatic)
+	require.True(t, len(ps.host.Peerstore().Addrs(id2)) > 0)
+
+	// already known peer is rejected
+	err = ps.AddStaticPeer(maddr2, "peer2a")
+	util.RequireErrorWith(t, err, "already known")
+
+	// own peer ID of the host is rejected
+	maddr0, err := multiaddr.NewMultiaddr(MultiAddrString(0, BeginPort))
+	require.NoError(t, err)
+	err = ps.AddStaticPeer(maddr0, "self")
+	util.RequireErrorWith(t, err, "peer ID of the host")
+
+	// pre-configured peer is deleted and not blacklisted
+	err = ps.RemoveStaticPeer(id1)
+	require.NoError(t, err)
+	require.True(t, ps.getPeer(id1) == nil)
+	require.False(t, ps.staticPeers.Contains(id1))
+	require.False(t, ps._isInBlacklist(id1))
+	require.True(t, len(ps.host.Peerstore().Addrs(id1)) == 0)
+
+	err = ps.RemoveStaticPeer(id1)
+	util.RequireErrorWith(t, err, "not found")
+
+	err = ps.RemoveStaticPeer(id2)
+	require.NoError(t, err)
+	require.True(t, ps.getPeer(id2) == nil)
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestAddStaticPeerUniqueName(t *testing.T) {
+	cfg := MakeConfigFor(4, 0)
+	delete(cfg.PreConfiguredPeers, "peer1")
+	delete(cfg.PreConfiguredPeers, "peer2")
+	delete(cfg.PreConfiguredPeers, "peer3")
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	// concurrent additions of different peers with the same name: only one succeeds
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		maddr, err := multiaddr.NewMultiaddr(MultiAddrString(i+1, BeginPort+i+1))
+		require.NoError(t, err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = ps.AddStaticPeer(maddr, "same")
+		}()
+	}
+	wg.Wait()
+
+	nSuccess := 0
+	for _, err := range errs {
+		if err == nil {
+			nSuccess++
+		} else {
+			util.RequireErrorWith(t, err, "already exists")
+		}
+	}
+	require.EqualValues(t, 1, nSuccess)
+	require.EqualValues(t, 1, len(ps.getPeerIDs()))
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestPeerMetrics(t *testing.T) {
+	cfg := MakeConfigFor(3, 0)
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	id1, err := peer.Decode(hostID[1])
+	require.NoError(t, err)
+	id2, err := peer.Decode(hostID[2])
+	require.NoError(t, err)
+
+	// static peer and dynamic peer are alive
+	ps.withPeer(id1, func(p *Peer) {
+		ps._evidenceHeartBeat(p, heartbeatInfo{clock: time.Now().Add(-time.Second)})
+	})
+	ps.mutex.Lock()
+	pDynamic := ps._addPeer(&peer.AddrInfo{ID: "dynamic"}, "", false)
+	ps._evidenceHeartBeat(pDynamic, heartbeatInfo{clock: time.Now()})
+	ps._addToBlacklist(id2, "test")
+	ps.mutex.Unlock()
+
+	stats := ps.peerStats()
+	require.EqualValues(t, 2, len(stats.clockDiffs))
+	// average over the received heartbeats, not the median of the whole ring buffer
+	require.True(t, slices.ContainsFunc(stats.clockDiffs, func(d time.Duration) bool { return d >= time.Second }))
+	ps.updatePeerMetrics(stats)
+	require.EqualValues(t, 1, int(testutil.ToFloat64(ps.peersAliveStatic)))
+	require.EqualValues(t, 1, int(testutil.ToFloat64(ps.peersAliveDynamic)))
+	require.EqualValues(t, 1, int(testutil.ToFloat64(ps.peersBlacklisted)))
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestNewDynamicPeersThrottle(t *testing.T) {
+	const maxNew = 5
+	cfg := MakeConfigFor(1, 0)
+	cfg.MaxDynamicPeers = 100
+	cfg.MaxNewDynamicPeers = maxNew
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	remote, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/udp/5000/quic-v1")
+	require.NoError(t, err)
+	for i := 0; i < 3*maxNew; i++ {
+		ps.processHeartbeat(peer.ID("new peer #"+strconv.Itoa(i)), remote, heartbeatInfo{clock: time.Now()})
+	}
+	_, aliveDynamic, _ := ps.NumAlive()
+	require.EqualValues(t, maxNew, aliveDynamic)
+
+	// heartbeats from already accepted peers are not throttled
+	before := ps.getPeer("new peer #0").lastHeartbeatReceived
+	ps.processHeartbeat(peer.ID("new peer #0"), remote, heartbeatInfo{clock: time.Now()})
+	require.True(t, ps.getPeer("new peer #0").lastHeartbeatReceived.After(before))
+
+	// next window accepts new peers again
+	ps.mutex.Lock()
+	ps.newDynamicPeersSince = time.Now().Add(-newDynamicPeersWindow - time.Second)
+	ps.mutex.Unlock()
+	ps.processHeartbeat(peer.ID("one more peer"), remote, heartbeatInfo{clock: time.Now()})
+	require.True(t, ps.getPeer("one more peer") != nil)
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestSecurity(t *testing.T) {
+	t.Run("wrong option", func(t *testing.T) {
+		cfg := MakeConfigFor(1, 0)
+		cfg.Security = "tls"
+		_, err := New(newEnvironment(), cfg)
+		util.RequireErrorWith(t, err, "wrong security option")
+	})
+	for _, security := range []string{SecurityNone, SecurityNoise} {
+		t.Run(security, func(t *testing.T) {
+			hosts := make([]*Peers, 2)
+			for i := range hosts {
+				cfg := MakeConfigFor(len(hosts), i)
+				cfg.Security = security
+				var err error
+				hosts[i], err = New(newEnvironment(), cfg)
+				require.NoError(t, err)
+				require.EqualValues(t, cfg.HostID, hosts[i].host.ID())
+			}
+			remote := hosts[1].host
+			err := hosts[0].host.Connect(context.Background(), peer.AddrInfo{ID: remote.ID(), Addrs: remote.Addrs()})
+			require.NoError(t, err)
+
+			// connection is authenticated with the ed25519 host ID key of the remote
+			conns := hosts[0].host.Network().ConnsToPeer(remote.ID())
+			require.True(t, len(conns) > 0)
+			for _, conn := range conns {
+				remoteID, err := peer.IDFromPublicKey(conn.RemotePublicKey())
+				require.NoError(t, err)
+				require.EqualValues(t, remote.ID(), remoteID)
+			}
+			for _, ps := range hosts {
+				ps.environment.Stop()
+				_ = ps.host.Close()
+			}
+		})
+	}
+}
+
+func TestGossipRateLimit(t *testing.T) {
+	const maxRate = 10
+	cfg := MakeConfigFor(1, 0)
+	cfg.MaxGossipMsgsPerSec = maxRate
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	dynamicPeer := &Peer{id: "dynamic peer"}
+	staticPeer := &Peer{id: "static peer", isStatic: true}
+
+	ps.mutex.Lock()
+	accepted := 0
+	for i := 0; i < 3*maxRate; i++ {
+		if ps._takeGossipToken(dynamicPeer) {
+			accepted++
+		}
+		require.True(t, ps._takeGossipToken(staticPeer))
+	}
+	// a few tokens may be refilled while looping
+	require.True(t, accepted >= maxRate && accepted < 2*maxRate)
+	require.EqualValues(t, 3*maxRate-accepted, dynamicPeer.numGossipRateLimited)
+	require.EqualValues(t, 0, staticPeer.numGossipRateLimited)
+
+	// bucket is refilled with time
+	dynamicPeer.gossipTokensUpdated = dynamicPeer.gossipTokensUpdated.Add(-time.Second)
+	require.True(t, ps._takeGossipToken(dynamicPeer))
+	ps.mutex.Unlock()
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestPeerStats(t *testing.T) {
+	cfg := MakeConfigFor(3, 0)
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	stats := ps.PeerStats()
+	require.EqualValues(t, 2, len(stats))
+	for _, s := range stats {
+		require.True(t, s.IsStatic)
+		require.False(t, s.IsAlive)
+		require.EqualValues(t, 0, s.IncomingGood+s.IncomingBad)
+		require.EqualValues(t, 0, s.AvgClockDifference)
+	}
+
+	id := stats[0].ID
+	ps.withPeer(id, func(p *Peer) {
+		p.numIncomingTx = 10
+		p.numGossipParseFailures = 1
+		p.numGossipRateLimited = 2
+	})
+	now := time.Now()
+	ps.processHeartbeat(id, nil, heartbeatInfo{clock: now.Add(-time.Second), respondsToPullRequests: true})
+	ps.processHeartbeat(id, nil, heartbeatInfo{clock: now.Add(-3 * time.Second), respondsToPullRequests: true})
+
+	for _, s := range ps.PeerStats() {
+		if s.ID != id {
+			continue
+		}
+		require.True(t, s.IsAlive)
+		require.True(t, s.HasTxStore)
+		require.EqualValues(t, 7, s.IncomingGood)
+		require.EqualValues(t, 3, s.IncomingBad)
+		require.True(t, s.AvgClockDifference >= 2*time.Second && s.AvgClockDifference < 3*time.Second)
+		require.False(t, s.LastActivity.Before(now))
+	}
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestFleetPeers(t *testing.T) {
+	const numHosts = 3
+	fleetPeers := api.NewFleetPeers()
+	hostIDs := make([]string, numHosts)
+	for i := 0; i < numHosts; i++ {
+		cfg := MakeConfigFor(numHosts, i)
+		env := newEnvironment()
+		ps, err := New(env, cfg)
+		require.NoError(t, err)
+		hostIDs[i] = ps.host.ID().String()
+
+		// only peers from which the heartbeat was received are alive
+		for _, id := range ps.getPeerIDs() {
+			ps.processHeartbeat(id, nil, heartbeatInfo{clock: time.Now()})
+		}
+		fleetPeers.AddPeersInfo(ps.GetPeersInfo())
+		env.Stop()
+		_ = ps.host.Close()
+	}
+	require.EqualValues(t, numHosts, len(fleetPeers.Nodes))
+	for i, id := range hostIDs {
+		require.EqualValues(t, numHosts-1, len(fleetPeers.Nodes[id]))
+		require.NotContains(t, fleetPeers.Nodes[id], hostIDs[i])
+	}
+	// full mesh
+	require.EqualValues(t, numHosts*(numHosts-1)/2, fleetPeers.NumEdges())
+}
+
+func TestPullTargetSelection(t *testing.T) {
+	cfg := MakeConfigFor(4, 0)
+	cfg.ForcePullFromAllPeers = true
+	cfg.PullTargetSelection = PullTargetSelectionReputation
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	ids := ps.getPeerIDs()
+	require.EqualValues(t, 3, len(ids))
+	badPeer := ids[0]
+	ps.withPeer(badPeer, func(p *Peer) {
+		p.numIncomingTx = 5
+		p.numGossipParseFailures = 5
+	})
+	ps.withPeer(ids[1], func(p *Peer) {
+		p.numIncomingTx = 100
+		p.numGossipParseFailures = 1
+	})
+	for i := 0; i < 100; i++ {
+		targets := ps.chooseNPullTargets(2)
+		require.EqualValues(t, 2, len(targets))
+		require.NotContains(t, targets, badPeer)
+
+		targets = ps.chooseNPullTargets(5)
+		require.EqualValues(t, 3, len(targets))
+		require.EqualValues(t, badPeer, targets[2])
+	}
+	env.Stop()
+	_ = ps.host.Close()
+
+	cfg.PullTargetSelection = "best"
+	_, err = New(newEnvironment(), cfg)
+	util.RequireErrorWith(t, err, "wrong pull target selection")
+}
+
+func TestPullFromPeer(t *testing.T) {
+	cfg := MakeConfigFor(3, 0)
+	cfg.ForcePullFromAllPeers = true
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	ids := ps.getPeerIDs()
+	require.EqualValues(t, 2, len(ids))
+	txid := ledger.RandomTransactionID(true)
+
+	// no heartbeats received yet
+	require.False(t, ps.PullTransactionsFromPeer(ids[0], txid))
+
+	ps.withPeer(ids[0], func(p *Peer) {
+		p.lastHeartbeatReceived = time.Now()
+	})
+	require.True(t, ps.PullTransactionsFromPeer(ids[0], txid))
+	require.False(t, ps.PullTransactionsFromPeer(ids[1], txid))
+	require.False(t, ps.PullTransactionsFromPeer(ps.host.ID(), txid))
+
+	ps.withPeer(ids[0], func(p *Peer) {
+		p.goingAway = true
+	})
+	require.False(t, ps.PullTransactionsFromPeer(ids[0], txid))
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestPeerLifecycleHandlers(t *testing.T) {
+	cfg := MakeConfigFor(3, 0)
+	delete(cfg.PreConfiguredPeers, "peer2")
+	cfg.MaxDynamicPeers = 100
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	type event struct {
+		id     peer.ID
+		static bool
+		reason string
+	}
+	added := make(chan event, 10)
+	dropped := make(chan event, 10)
+	ps.OnPeerAdded(func(id peer.ID, _ string, static bool) {
+		added <- event{id: id, static: static}
+	})
+	ps.OnPeerDropped(func(id peer.ID, reason string) {
+		dropped <- event{id: id, reason: reason}
+	})
+	receive := func(ch chan event) event {
+		select {
+		case e := <-ch:
+			return e
+		case <-time.After(time.Second):
+			t.Fatalf("handler wasn't called")
+		}
+		return event{}
+	}
+
+	maddr2, err := multiaddr.NewMultiaddr(MultiAddrString(2, BeginPort+2))
+	require.NoError(t, err)
+	require.NoError(t, ps.AddStaticPeer(maddr2, "peer2"))
+	id2, err := peer.Decode(hostID[2])
+	require.NoError(t, err)
+	require.EqualValues(t, event{id: id2, static: true}, receive(added))
+
+	require.NoError(t, ps.RemoveStaticPeer(id2))
+	require.EqualValues(t, event{id: id2, reason: "removed static peer"}, receive(dropped))
+
+	remote, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/udp/5000/quic-v1")
+	require.NoError(t, err)
+	dynamicID := peer.ID("dynamic peer")
+	ps.processHeartbeat(dynamicID, remote, heartbeatInfo{clock: time.Now()})
+	require.EqualValues(t, event{id: dynamicID}, receive(added))
+
+	ps.dropPeer(dynamicID, "test")
+	require.EqualValues(t, event{id: dynamicID, reason: "test"}, receive(dropped))
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestFrameMaxSize(t *testing.T) {
+	var buf bytes.Buffer
+	payload := bytes.Repeat([]byte{1}, MaxPayloadSize+100)
+
+	// default maximum
+	err := writeFrame(&buf, payload, MaxPayloadSize)
+	util.RequireErrorWith(t, err, "exceeds maximum")
+	require.EqualValues(t, 0, buf.Len())
+
+	// bigger frame with 4 bytes size prefix
+	const maxSize = 2 * MaxPayloadSize
+	require.NoError(t, writeFrame(&buf, payload, maxSize))
+	require.EqualValues(t, len(payload)+4, buf.Len())
+	frameBytes := slices.Clone(buf.Bytes())
+
+	back, err := readFrame(&buf, maxSize)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(payload, back))
+
+	// receiving node with smaller maximum rejects the frame
+	_, err = readFrame(bytes.NewReader(frameBytes), MaxPayloadSize)
+	util.RequireErrorWith(t, err, "exceeds maximum")
+
+	cfg := MakeConfigFor(1, 0)
+	cfg.MaxMessageBytes = MaxMessageBytesLimit + 1
+	_, err = New(newEnvironment(), cfg)
+	util.RequireErrorWith(t, err, "maximum message size must be")
+}
+
+func TestHeartbeatGoingAway(t *testing.T) {
+	hb := heartbeatInfo{clock: time.Now(), counter: 5, goingAway: true}
+	hbBack, err := heartbeatInfoFromBytes(hb.Bytes(), false)
+	require.NoError(t, err)
+	require.True(t, hbBack.goingAway)
+	require.False(t, hbBack.respondsToPullRequests)
+
+	cfg := MakeConfigFor(2, 0)
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	id := ps.peerIDs()[0]
+	ps.processHeartbeat(id, nil, heartbeatInfo{clock: time.Now(), respondsToPullRequests: true})
+	require.True(t, ps.IsAlive(id))
+	_, _, pullTargets := ps.NumAlive()
+	require.EqualValues(t, 1, pullTargets)
+
+	ps.processHeartbeat(id, nil, heartbeatInfo{clock: time.Now(), respondsToPullRequests: true, goingAway: true})
+	require.False(t, ps.IsAlive(id))
+	_, _, pullTargets = ps.NumAlive()
+	require.EqualValues(t, 0, pullTargets)
+
+	// peer is back
+	ps.processHeartbeat(id, nil, heartbeatInfo{clock: time.Now(), respondsToPullRequests: true})
+	require.True(t, ps.IsAlive(id))
+
+	ps.goingAway.Store(true)
+	hbOut := ps.makeHeartbeat(id, 0)
+	require.True(t, hbOut.goingAway)
+	require.False(t, hbOut.respondsToPullRequests)
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestSendFailureEviction(t *testing.T) {
+	// peer which never reads the stream
+	local, remote := net.Pipe()
+	defer func() { _ = local.Close(); _ = remote.Close() }()
+
+	start := time.Now()
+	err := writeFrameWithDeadline(local, []byte("hello"), MaxPayloadSize, time.Now().Add(100*time.Millisecond))
+	require.Error(t, err)
+	require.True(t, time.Since(start) < time.Second)
+	t.Logf("expected error: %v", err)
+
+	cfg := MakeConfigFor(2, 0)
+	cfg.MaxDynamicPeers = 100
+	cfg.MaxSendFailures = 3
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	remoteAddr, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/udp/5000/quic-v1")
+	require.NoError(t, err)
+	dynamicID := peer.ID("dynamic peer")
+	ps.processHeartbeat(dynamicID, remoteAddr, heartbeatInfo{clock: time.Now()})
+
+	writeErr := fmt.Errorf("%w: %v", errWriteFailed, context.DeadlineExceeded)
+	// failures to open stream are not counted
+	for i := 0; i < 2*cfg.MaxSendFailures; i++ {
+		ps.evidenceSendResult(dynamicID, errors.New("failed to dial"))
+	}
+	require.True(t, ps.IsAlive(dynamicID))
+
+	// successful send resets the counter
+	ps.evidenceSendResult(dynamicID, writeErr)
+	ps.evidenceSendResult(dynamicID, writeErr)
+	ps.evidenceSendResult(dynamicID, nil)
+	ps.evidenceSendResult(dynamicID, writeErr)
+	ps.evidenceSendResult(dynamicID, writeErr)
+	require.True(t, ps.IsAlive(dynamicID))
+
+	// failures outside the window are forgotten
+	ps.withPeer(dynamicID, func(p *Peer) {
+		p.sendFailuresSince = time.Now().Add(-2 * sendFailuresWindow)
+	})
+	ps.evidenceSendResult(dynamicID, writeErr)
+	require.True(t, ps.IsAlive(dynamicID))
+	ps.evidenceSendResult(dynamicID, writeErr)
+	require.True(t, ps.IsAlive(dynamicID))
+
+	ps.evidenceSendResult(dynamicID, writeErr)
+	known, blacklisted, _ := ps.knownPeer(dynamicID, func(_ *Peer) {})
+	require.False(t, known)
+	require.True(t, blacklisted)
+
+	// static peer is reconnected, but never removed
+	staticID := ps.peerIDs()[0]
+	ps.processHeartbeat(staticID, nil, heartbeatInfo{clock: time.Now()})
+	require.True(t, ps.IsAlive(staticID))
+	for i := 0; i < 2*cfg.MaxSendFailures; i++ {
+		ps.evidenceSendResult(staticID, writeErr)
+	}
+	require.True(t, ps.IsAlive(staticID))
+	known, blacklisted, _ = ps.knownPeer(staticID, func(_ *Peer) {})
+	require.True(t, known)
+	require.False(t, blacklisted)
+
+	env.Stop()
+	_ = ps.host.Close()
+}
+
+func TestHeartbeatSyncStatus(t *testing.T) {
+	hb := heartbeatInfo{clock: time.Now(), counter: 1, hasSyncInfo: true, latestCommittedSlot: 1234, synced: true}
+	data := hb.Bytes()
+	require.EqualValues(t, heartbeatSize, len(data))
+	hbBack, err := heartbeatInfoFromBytes(data, true)
+	require.NoError(t, err)
+	require.True(t, hbBack.hasSyncInfo)
+	require.True(t, hbBack.synced)
+	require.EqualValues(t, 1234, hbBack.latestCommittedSlot)
+
+	// heartbeat without sync info, e.g. from older version
+	hbOld := heartbeatInfo{clock: time.Now(), counter: 1}
+	require.EqualValues(t, heartbeatSizeWithoutSyncInfo, len(hbOld.Bytes()))
+	hbBack, err = heartbeatInfoFromBytes(hbOld.Bytes(), false)
+	require.NoError(t, err)
+	require.False(t, hbBack.hasSyncInfo)
+
+	// each protocol accepts only its own size
+	_, err = heartbeatInfoFromBytes(data[:heartbeatSize-1], true)
+	require.Error(t, err)
+	_, err = heartbeatInfoFromBytes(hbOld.Bytes(), true)
+	require.Error(t, err)
+	_, err = heartbeatInfoFromBytes(data, false)
+	require.Error(t, err)
+
+	// legacy protocol gets heartbeat of the old size, without sync info
+	const legacyProtocol = protocol.ID("legacy")
+	toBytes := hb.bytesForProtocol("current")
+	require.EqualValues(t, heartbeatSize, len(toBytes("current")))
+	require.EqualValues(t, heartbeatSizeWithoutSyncInfo, len(toBytes(legacyProtocol)))
+	hbBack, err = heartbeatInfoFromBytes(toBytes(legacyProtocol), false)
+	require.NoError(t, err)
+	require.False(t, hbBack.hasSyncInfo || hbBack.synced)
+
+	cfg := MakeConfigFor(3, 0)
+	env := newEnvironment()
+	ps, err := New(env, cfg)
+	require.NoError(t, err)
+
+	ps.SetSyncStatusSource(func() (ledger.Slot, bool) { return 777, true })
+	ps.updateSyncStatus()
+	hbOut := ps.makeHeartbeat(ps.peerIDs()[0], 0)
+	require.True(t, hbOut.hasSyncInfo && hbOut.synced)
+	require.EqualValues(t, 777, hbOut.latestCommittedSlot)
+
+	ids := ps.peerIDs()
+	_, ok := ps.PeerLatestSlot(ids[0])
+	require.False(t, ok)
+
+	ps.processHeartbeat(ids[0], nil, hb)
+	ps.processHeartbeat(ids[1], nil, hbOld)
+	slot, ok := ps.PeerLatestSlot(ids[0])
+	require.True(t, ok)
+	require.EqualValues(t, 1234, slot)
+	require.True(t, ps.PeerIsSynced(ids[0]))
+	_, ok = ps.PeerLatestSlot(ids[1])
+	require.False(t, ok)
+	require.False(t, ps.PeerIsSynced(ids[1]))
+
+	env.Stop()
+	_ = ps.host.Close()
+}
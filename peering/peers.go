@@ -5,6 +5,7 @@ import (
 	"crypto/ed25519"
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"slices"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/libp2p/go-libp2p/p2p/discovery/routing"
 	p2putil "github.com/libp2p/go-libp2p/p2p/discovery/util"
+	"github.com/libp2p/go-libp2p/p2p/security/noise"
 	p2pquic "github.com/libp2p/go-libp2p/p2p/transport/quic"
 	"github.com/lunfardo314/proxima/api"
 	"github.com/lunfardo314/proxima/core/txmetadata"
@@ -33,6 +35,7 @@ func NewPeersDummy() *Peers {
 	ret := &Peers{
 		peers:           make(map[peer.ID]*Peer),
 		blacklist:       make(map[peer.ID]_deadlineWithReason),
+		gater:           newPeerGater(nil, nil),
 		onReceiveTx:     func(_ peer.ID, _ []byte, _ *txmetadata.TransactionMetadata) {},
 		onReceivePullTx: func(_ peer.ID, _ ledger.TransactionID) {},
 	}
@@ -41,18 +44,44 @@ func NewPeersDummy() *Peers {
 }
 
 func New(env environment, cfg *Config) (*Peers, error) {
+	if cfg.ClockTolerance < 0 {
+		return nil, fmt.Errorf("clock tolerance must be positive duration, got %v", cfg.ClockTolerance)
+	}
+	if cfg.SendTimeout < 0 {
+		return nil, fmt.Errorf("send timeout must be positive duration, got %v", cfg.SendTimeout)
+	}
+	if cfg.MaxMessageBytes < 0 || cfg.MaxMessageBytes > MaxMessageBytesLimit {
+		return nil, fmt.Errorf("maximum message size must be between 0 and %d bytes, got %d", MaxMessageBytesLimit, cfg.MaxMessageBytes)
+	}
+	switch cfg.PullTargetSelection {
+	case "", PullTargetSelectionRank, PullTargetSelectionUniform, PullTargetSelectionReputation:
+	default:
+		return nil, fmt.Errorf("wrong pull target selection '%s'. Must be '%s', '%s' or '%s'",
+			cfg.PullTargetSelection, PullTargetSelectionRank, PullTargetSelectionUniform, PullTargetSelectionReputation)
+	}
+	var securityOption libp2p.Option
+	switch cfg.Security {
+	case "", SecurityNone:
+		securityOption = libp2p.NoSecurity
+	case SecurityNoise:
+		securityOption = libp2p.Security(noise.ID, noise.New)
+	default:
+		return nil, fmt.Errorf("wrong security option '%s'. Must be '%s' or '%s'", cfg.Security, SecurityNone, SecurityNoise)
+	}
 	hostIDPrivateKey, err := p2pcrypto.UnmarshalEd25519PrivateKey(cfg.HostIDPrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("wrong private key: %w", err)
 	}
+	gater := newPeerGater(cfg.DenyPeers, cfg.AllowPeers)
 	lppHost, err := libp2p.New(
 		libp2p.Identity(hostIDPrivateKey),
 
 		libp2p.ListenAddrStrings(fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic-v1", cfg.HostPort)),
 		libp2p.Transport(p2pquic.NewTransport),
-		libp2p.NoSecurity,
+		securityOption,
 		libp2p.DisableRelay(),
 		libp2p.AddrsFactory(FilterAddresses(cfg.AllowLocalIPs)),
+		libp2p.ConnectionGater(gater),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("unable create libp2p host: %w", err)
@@ -62,23 +91,33 @@ func New(env environment, cfg *Config) (*Peers, error) {
 	rendezvousNumber := binary.BigEndian.Uint64(ledgerLibraryHash[:8])
 
 	ret := &Peers{
-		environment:          env,
-		cfg:                  cfg,
-		host:                 lppHost,
-		peers:                make(map[peer.ID]*Peer),
-		staticPeers:          set.New[peer.ID](),
-		blacklist:            make(map[peer.ID]_deadlineWithReason),
-		onReceiveTx:          func(_ peer.ID, _ []byte, _ *txmetadata.TransactionMetadata) {},
-		onReceivePullTx:      func(_ peer.ID, _ ledger.TransactionID) {},
-		lppProtocolGossip:    protocol.ID(fmt.Sprintf(lppProtocolGossip, rendezvousNumber)),
-		lppProtocolPull:      protocol.ID(fmt.Sprintf(lppProtocolPull, rendezvousNumber)),
-		lppProtocolHeartbeat: protocol.ID(fmt.Sprintf(lppProtocolHeartbeat, rendezvousNumber)),
-		rendezvousString:     fmt.Sprintf("%d", rendezvousNumber),
+		environment:                env,
+		cfg:                        cfg,
+		host:                       lppHost,
+		peers:                      make(map[peer.ID]*Peer),
+		staticPeers:                set.New[peer.ID](),
+		blacklist:                  make(map[peer.ID]_deadlineWithReason),
+		gater:                      gater,
+		onReceiveTx:                func(_ peer.ID, _ []byte, _ *txmetadata.TransactionMetadata) {},
+		onReceivePullTx:            func(_ peer.ID, _ ledger.TransactionID) {},
+		lppProtocolGossip:          protocol.ID(fmt.Sprintf(lppProtocolGossip, rendezvousNumber)),
+		lppProtocolPull:            protocol.ID(fmt.Sprintf(lppProtocolPull, rendezvousNumber)),
+		lppProtocolHeartbeat:       protocol.ID(fmt.Sprintf(lppProtocolHeartbeat, rendezvousNumber)),
+		lppProtocolHeartbeatLegacy: protocol.ID(fmt.Sprintf(lppProtocolHeartbeatLegacy, rendezvousNumber)),
+		rendezvousStrings:          []string{fmt.Sprintf("%d", rendezvousNumber)},
 	}
 
 	env.Log().Infof("[peering] rendezvous number is %d", rendezvousNumber)
+	for _, r := range cfg.AdditionalRendezvous {
+		if r != "" && !slices.Contains(ret.rendezvousStrings, r) {
+			ret.rendezvousStrings = append(ret.rendezvousStrings, r)
+		}
+	}
+	if len(ret.rendezvousStrings) > 1 {
+		env.Log().Infof("[peering] additional rendezvous: %v", ret.rendezvousStrings[1:])
+	}
 	for name, maddr := range cfg.PreConfiguredPeers {
-		if err = ret.addStaticPeer(maddr.Multiaddr, name, maddr.addrString); err != nil {
+		if err = ret.addStaticPeer(maddr.Multiaddr, name, maddr.addrString, false); err != nil {
 			return nil, err
 		}
 	}
@@ -100,7 +139,7 @@ func New(env environment, cfg *Config) (*Peers, error) {
 			return nil, err
 		}
 		ret.routingDiscovery = routing.NewRoutingDiscovery(ret.kademliaDHT)
-		p2putil.Advertise(env.Ctx(), ret.routingDiscovery, ret.rendezvousString)
+		ret.advertiseRendezvous()
 
 		env.Log().Infof("[peering] autopeering is enabled with max dynamic peers = %d", cfg.MaxDynamicPeers)
 		env.Tracef(TraceTagAutopeering, "autopeering is enabled")
@@ -110,6 +149,17 @@ func New(env environment, cfg *Config) (*Peers, error) {
 	}
 	env.Log().Infof("[peering] ignore all pull requests: %v", cfg.IgnoreAllPullRequests)
 	env.Log().Infof("[peering] only pull requests from static peers are accepted: %v", cfg.AcceptPullRequestsFromStaticPeersOnly)
+	env.Log().Infof("[peering] recover when isolated: %v", cfg.RecoverWhenIsolated)
+	env.Log().Infof("[peering] TTL of dynamic peer addresses: %v", ret.dynamicPeerAddrTTL())
+	env.Log().Infof("[peering] clock tolerance: %v", ret.ClockTolerance())
+	env.Log().Infof("[peering] max gossip parse failures per %v: %d", gossipParseFailuresWindow, ret.maxGossipParseFailures())
+	env.Log().Infof("[peering] max gossip messages per second from dynamic peer: %d", ret.maxGossipMsgsPerSec())
+	env.Log().Infof("[peering] pull target selection: %s", util.Cond(cfg.PullTargetSelection == "", PullTargetSelectionRank, cfg.PullTargetSelection))
+	env.Log().Infof("[peering] maximum message size: %d bytes", ret.maxMessageBytes())
+	env.Log().Infof("[peering] security: %s", util.Cond(cfg.Security == SecurityNoise, SecurityNoise, SecurityNone))
+	env.Log().Infof("[peering] max new dynamic peers per %v: %d", newDynamicPeersWindow, ret.maxNewDynamicPeers())
+	env.Log().Infof("[peering] send timeout: %v, max consecutive write failures: %d", ret.sendTimeout(), ret.maxSendFailures())
+	env.Log().Infof("[peering] statically denied peers: %d, allowed peers: %d (0 means all)", len(cfg.DenyPeers), len(cfg.AllowPeers))
 
 	ret.registerMetrics()
 
@@ -173,6 +223,30 @@ func readPeeringConfig() (*Config, error) {
 	cfg.IgnoreAllPullRequests = viper.GetBool("peering.ignore_pull_requests")
 	cfg.AcceptPullRequestsFromStaticPeersOnly = viper.GetBool("peering.pull_requests_from_static_peers_only")
 	cfg.AllowLocalIPs = viper.GetBool("peering.allow_local_ips")
+	cfg.DynamicPeerAddrTTL = time.Duration(viper.GetInt("peering.dynamic_peer_addr_ttl_sec")) * time.Second
+	cfg.RecoverWhenIsolated = viper.GetBool("peering.recover_when_isolated")
+	cfg.MaxGossipParseFailures = viper.GetInt("peering.max_gossip_parse_failures")
+	cfg.MaxGossipMsgsPerSec = viper.GetInt("peering.max_gossip_msgs_per_sec")
+	cfg.PullTargetSelection = viper.GetString("peering.pull_target_selection")
+	cfg.MaxMessageBytes = viper.GetInt("peering.max_message_bytes")
+	cfg.Security = viper.GetString("peering.security")
+	cfg.MaxNewDynamicPeers = viper.GetInt("peering.max_new_dynamic_peers")
+	cfg.AdditionalRendezvous = viper.GetStringSlice("peering.additional_rendezvous")
+	cfg.ClockTolerance = viper.GetDuration("peering.clock_tolerance")
+	if cfg.ClockTolerance < 0 {
+		return nil, fmt.Errorf("peering.clock_tolerance: must be positive duration, got %v", cfg.ClockTolerance)
+	}
+	cfg.SendTimeout = viper.GetDuration("peering.send_timeout")
+	if cfg.SendTimeout < 0 {
+		return nil, fmt.Errorf("peering.send_timeout: must be positive duration, got %v", cfg.SendTimeout)
+	}
+	cfg.MaxSendFailures = viper.GetInt("peering.max_send_failures")
+	if cfg.DenyPeers, err = readPeerIDList("peering.deny_peers"); err != nil {
+		return nil, err
+	}
+	if cfg.AllowPeers, err = readPeerIDList("peering.allow_peers"); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
@@ -201,6 +275,7 @@ func (ps *Peers) Run() {
 	ps.host.SetStreamHandler(ps.lppProtocolGossip, ps.gossipStreamHandler)
 	ps.host.SetStreamHandler(ps.lppProtocolPull, ps.pullStreamHandler)
 	ps.host.SetStreamHandler(ps.lppProtocolHeartbeat, ps.heartbeatStreamHandler)
+	ps.host.SetStreamHandler(ps.lppProtocolHeartbeatLegacy, ps.heartbeatStreamHandler)
 
 	//ps.startHeartbeat()
 	var logNumPeersDeadline time.Time
@@ -210,11 +285,13 @@ func (ps *Peers) Run() {
 		nowis := time.Now()
 		peerIDs := ps.peerIDs()
 
+		ps.updateSyncStatus()
 		for _, id := range peerIDs {
 			ps.logConnectionStatusIfNeeded(id)
 			ps.sendHeartbeatToPeer(id, hbCounter)
 			hbCounter++
 		}
+		ps.checkIsolation()
 
 		if nowis.After(logNumPeersDeadline) {
 			aliveStatic, aliveDynamic, pullTargets := ps.NumAlive()
@@ -255,7 +332,9 @@ func (ps *Peers) Run() {
 		return true
 	})
 
-	ps.RepeatInBackground("peering_clock_tolerance_loop", 2*clockTolerance, func() bool {
+	ps.trackReachability()
+
+	ps.RepeatInBackground("peering_clock_tolerance_loop", 2*ps.ClockTolerance(), func() bool {
 		ps.logBigClockDiffs()
 		return true
 	}, true)
@@ -275,14 +354,56 @@ func (ps *Peers) Stop() {
 
 		ps.Log().Infof("[peering] stopping libp2p host %s (self)..", ShortPeerIDString(ps.host.ID()))
 		_ = ps.Log().Sync()
+		ps.sendGoingAway()
 		_ = ps.host.Close()
 		ps.Log().Infof("[peering] libp2p host %s (self) has been stopped", ShortPeerIDString(ps.host.ID()))
 	})
 }
 
-// addStaticPeer adds preconfigured peer to the list. It will never be deleted
-func (ps *Peers) addStaticPeer(maddr multiaddr.Multiaddr, name, addrString string) error {
-	if slices.Index(ps.host.Addrs(), maddr) > 0 {
+// AddStaticPeer adds static peer at runtime, without restart of the node. Name of the peer must be unique
+func (ps *Peers) AddStaticPeer(maddr multiaddr.Multiaddr, name string) error {
+	return ps.addStaticPeer(maddr, name, maddr.String(), true)
+}
+
+// RemoveStaticPeer removes static peer at runtime and closes connection with it.
+// Unlike dropping, the peer is deleted from the list even if it is pre-configured and it is not blacklisted
+func (ps *Peers) RemoveStaticPeer(id peer.ID) error {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	p := ps._getPeer(id)
+	if p == nil || !p.isStatic {
+		return fmt.Errorf("RemoveStaticPeer: static peer %s not found", ShortPeerIDString(id))
+	}
+	ps.host.Peerstore().RemovePeer(id)
+	ps.host.Peerstore().ClearAddrs(id)
+	_ = ps.host.Network().ClosePeer(id)
+	delete(ps.peers, id)
+	ps.staticPeers.Remove(id)
+	ps._firePeerDropped(id, "removed static peer")
+
+	ps.Log().Infof("[peering] removed static peer %s - %s", ShortPeerIDString(id), p.name)
+	return nil
+}
+
+func (ps *Peers) _peerNameExists(name string) bool {
+	for _, p := range ps.peers {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addStaticPeer adds static peer to the list. It is never deleted, unless removed with RemoveStaticPeer.
+// If atRuntime is false (pre-configured peers), the host's own address and gated peers are skipped with a warning.
+// If atRuntime is true (AddStaticPeer), they are rejected with an error and the name is checked for uniqueness
+// in the same critical section where peer is added
+func (ps *Peers) addStaticPeer(maddr multiaddr.Multiaddr, name, addrString string, atRuntime bool) error {
+	if slices.Index(ps.host.Addrs(), maddr) >= 0 {
+		if atRuntime {
+			return fmt.Errorf("AddStaticPeer: %s is the multiaddress of the host", addrString)
+		}
 		ps.Log().Warnf("[peering] ignore static peer with the multiaddress of the host")
 		return nil
 	}
@@ -291,9 +412,33 @@ func (ps *Peers) addStaticPeer(maddr multiaddr.Multiaddr, name, addrString strin
 	if err != nil {
 		return fmt.Errorf("can't get multiaddress info: %v", err)
 	}
-	ps.Log().Infof("[peering] added pre-configured peer %s as '%s'", addrString, name)
-	ps.addPeer(info, name, true)
+	if info.ID == ps.host.ID() {
+		if atRuntime {
+			return fmt.Errorf("AddStaticPeer: %s is the peer ID of the host", ShortPeerIDString(info.ID))
+		}
+		ps.Log().Warnf("[peering] ignore static peer with the peer ID of the host")
+		return nil
+	}
+	if ps.IsPeerGated(info.ID) {
+		if atRuntime {
+			return fmt.Errorf("AddStaticPeer: peer %s ('%s') is denied by the connection gater", addrString, name)
+		}
+		ps.Log().Warnf("[peering] ignore pre-configured peer %s as '%s': it is denied by the connection gater", addrString, name)
+		return nil
+	}
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	if atRuntime && ps._peerNameExists(name) {
+		return fmt.Errorf("AddStaticPeer: peer with name '%s' already exists", name)
+	}
+	if ps._getPeer(info.ID) != nil {
+		return fmt.Errorf("peer %s is already known", ShortPeerIDString(info.ID))
+	}
+	ps._addPeer(info, name, true)
 	ps.staticPeers.Insert(info.ID)
+
+	ps.Log().Infof("[peering] added static peer %s as '%s'", addrString, name)
 	return nil
 }
 
@@ -318,12 +463,101 @@ func (ps *Peers) _addPeer(addrInfo *peer.AddrInfo, name string, static bool) *Pe
 		whenAdded: time.Now(),
 	}
 	ps.peers[addrInfo.ID] = p
-	for _, a := range addrInfo.Addrs {
-		ps.host.Peerstore().AddAddr(addrInfo.ID, a, peerstore.PermanentAddrTTL)
+	ttl := time.Duration(peerstore.PermanentAddrTTL)
+	if !static {
+		ttl = ps.dynamicPeerAddrTTL()
 	}
+	ps.host.Peerstore().AddAddrs(addrInfo.ID, addrInfo.Addrs, ttl)
+	ps._firePeerAdded(p)
 	return p
 }
 
+func (ps *Peers) dynamicPeerAddrTTL() time.Duration {
+	if ps.cfg.DynamicPeerAddrTTL > 0 {
+		return ps.cfg.DynamicPeerAddrTTL
+	}
+	return defaultDynamicPeerAddrTTL
+}
+
+// ClockTolerance returns configured tolerance of the clock difference with peers
+func (ps *Peers) ClockTolerance() time.Duration {
+	if ps.cfg.ClockTolerance > 0 {
+		return ps.cfg.ClockTolerance
+	}
+	return ClockTolerance
+}
+
+// advertiseRendezvous advertises the node in the DHT under all rendezvous strings
+func (ps *Peers) advertiseRendezvous() {
+	for _, r := range ps.rendezvousStrings {
+		p2putil.Advertise(ps.Ctx(), ps.routingDiscovery, r)
+	}
+}
+
+func (ps *Peers) maxGossipParseFailures() int {
+	if ps.cfg.MaxGossipParseFailures > 0 {
+		return ps.cfg.MaxGossipParseFailures
+	}
+	return defaultMaxGossipParseFailures
+}
+
+func (ps *Peers) maxMessageBytes() int {
+	if ps.cfg.MaxMessageBytes > 0 {
+		return ps.cfg.MaxMessageBytes
+	}
+	return MaxPayloadSize
+}
+
+func (ps *Peers) maxGossipMsgsPerSec() int {
+	if ps.cfg.MaxGossipMsgsPerSec > 0 {
+		return ps.cfg.MaxGossipMsgsPerSec
+	}
+	return defaultMaxGossipMsgsPerSec
+}
+
+func (ps *Peers) sendTimeout() time.Duration {
+	if ps.cfg.SendTimeout > 0 {
+		return ps.cfg.SendTimeout
+	}
+	return defaultSendTimeout
+}
+
+func (ps *Peers) maxSendFailures() int {
+	if ps.cfg.MaxSendFailures > 0 {
+		return ps.cfg.MaxSendFailures
+	}
+	return defaultMaxSendFailures
+}
+
+func (ps *Peers) maxNewDynamicPeers() int {
+	if ps.cfg.MaxNewDynamicPeers > 0 {
+		return ps.cfg.MaxNewDynamicPeers
+	}
+	return defaultMaxNewDynamicPeers
+}
+
+// _acceptNewDynamicPeer returns false if maximum number of new dynamic peers in the current window is reached
+func (ps *Peers) _acceptNewDynamicPeer() bool {
+	nowis := time.Now()
+	if nowis.Sub(ps.newDynamicPeersSince) > newDynamicPeersWindow {
+		ps.newDynamicPeersSince = nowis
+		ps.newDynamicPeersInWindow = 0
+	}
+	if ps.newDynamicPeersInWindow >= ps.maxNewDynamicPeers() {
+		return false
+	}
+	ps.newDynamicPeersInWindow++
+	return true
+}
+
+// _refreshDynamicPeerAddrTTL extends TTL of addresses of the dynamic peer in the peerstore
+func (ps *Peers) _refreshDynamicPeerAddrTTL(p *Peer) {
+	if p.isStatic {
+		return
+	}
+	ps.host.Peerstore().AddAddrs(p.id, ps.host.Peerstore().Addrs(p.id), ps.dynamicPeerAddrTTL())
+}
+
 // dropPeer removes dynamic peer and blacklists for 1 min. Ignores otherwise
 func (ps *Peers) dropPeer(id peer.ID, reason string) {
 	ps.withPeer(id, func(p *Peer) {
@@ -350,6 +584,7 @@ func (ps *Peers) _dropPeer(p *Peer, reason string) {
 	delete(ps.peers, p.id)
 
 	ps._addToBlacklist(p.id, reason)
+	ps._firePeerDropped(p.id, reason)
 
 	ps.Log().Infof("[peering] dropped dynamic peer %s - %s%s", ShortPeerIDString(p.id), p.name, why)
 }
@@ -380,6 +615,45 @@ func (ps *Peers) OnReceivePullTxRequest(fun func(from peer.ID, txid ledger.Trans
 	ps.onReceivePullTx = fun
 }
 
+// SetSyncStatusSource sets function which provides own sync status, sent to peers with heartbeats
+func (ps *Peers) SetSyncStatusSource(fun func() (latestCommittedSlot ledger.Slot, synced bool)) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	ps.syncStatusSource = fun
+}
+
+// OnPeerAdded registers handler, called when static or dynamic peer is added
+func (ps *Peers) OnPeerAdded(fun func(id peer.ID, name string, static bool)) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	ps.onPeerAdded = fun
+}
+
+// OnPeerDropped registers handler, called when dynamic peer is dropped or static peer is removed
+func (ps *Peers) OnPeerDropped(fun func(id peer.ID, reason string)) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	ps.onPeerDropped = fun
+}
+
+// handlers are called in goroutines to prevent deadlocks in user callbacks
+
+func (ps *Peers) _firePeerAdded(p *Peer) {
+	if fun := ps.onPeerAdded; fun != nil {
+		id, name, static := p.id, p.name, p.isStatic
+		go fun(id, name, static)
+	}
+}
+
+func (ps *Peers) _firePeerDropped(id peer.ID, reason string) {
+	if fun := ps.onPeerDropped; fun != nil {
+		go fun(id, reason)
+	}
+}
+
 func (ps *Peers) _getPeer(id peer.ID) *Peer {
 	if ret, ok := ps.peers[id]; ok {
 		return ret
@@ -416,6 +690,13 @@ func (ps *Peers) withPeer(id peer.ID, fun func(p *Peer)) {
 	fun(ps._getPeer(id))
 }
 
+func (ps *Peers) withPeerRLock(id peer.ID, fun func(p *Peer)) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	fun(ps._getPeer(id))
+}
+
 func (ps *Peers) forEachPeerRLock(fun func(p *Peer) bool) {
 	ps.mutex.RLock()
 	defer ps.mutex.RUnlock()
@@ -471,42 +752,141 @@ func (ps *Peers) IsAlive(id peer.ID) (isAlive bool) {
 	return
 }
 
+// _avgClockDifference average of the clock differences in the ring buffer. Only filled entries are taken into account
+func (p *Peer) _avgClockDifference() time.Duration {
+	if p.clockDifferencesNum == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for i := 0; i < p.clockDifferencesNum; i++ {
+		sum += p.clockDifferences[i]
+	}
+	return sum / time.Duration(p.clockDifferencesNum)
+}
+
 func (p *Peer) _isAlive() bool {
-	return time.Since(p.lastHeartbeatReceived) < aliveDuration
+	return !p.goingAway && time.Since(p.lastHeartbeatReceived) < aliveDuration
 }
 
 // for QUIC timeout 'NewStream' is necessary, otherwise it may hang if peer is unavailable
 
-const defaultSendTimeout = 500 * time.Millisecond
-
 //const TraceTagSendMsg = "sendMsg"
 
 func (ps *Peers) sendMsgBytesOut(peerID peer.ID, protocolID protocol.ID, data []byte, timeout ...time.Duration) bool {
-	to := defaultSendTimeout
+	return ps.sendMsgOut(peerID, []protocol.ID{protocolID}, func(_ protocol.ID) []byte { return data }, timeout...)
+}
+
+// sendMsgOut negotiates one of the protocols with the peer, in the order of preference, and sends the message
+// serialized for the negotiated protocol
+func (ps *Peers) sendMsgOut(peerID peer.ID, protocolIDs []protocol.ID, data func(protocolID protocol.ID) []byte, timeout ...time.Duration) bool {
+	err := ps.sendMsgOutWithContext(ps.Ctx(), peerID, protocolIDs, data, timeout...)
+	if ps.Ctx().Err() == nil {
+		ps.evidenceSendResult(peerID, err)
+	}
+	return err == nil
+}
+
+// errWriteFailed is returned by sendMsgOutWithContext when stream was opened but message could not be written
+// to it within the deadline. Only those failures are counted by evidenceSendResult
+var errWriteFailed = errors.New("write failed")
+
+// evidenceSendResult counts consecutive write failures within sendFailuresWindow. Failures to open the stream are
+// not counted, because the peer may just be unreachable for a while, which is detected by heartbeats.
+// When maximum is reached, dynamic peer is dropped and static peer is reconnected. Static peers are never dropped.
+// This way half-open peer, which accepts connections but does not read, does not degrade the outbound path.
+// Successful send is the hot path: exclusive lock is taken only when failures of the peer must be reset
+func (ps *Peers) evidenceSendResult(id peer.ID, err error) {
+	if err == nil {
+		var reset bool
+		ps.withPeerRLock(id, func(p *Peer) {
+			reset = p != nil && p.sendFailures > 0
+		})
+		if reset {
+			ps.withPeer(id, func(p *Peer) {
+				if p != nil {
+					p.sendFailures = 0
+				}
+			})
+		}
+		return
+	}
+	if !errors.Is(err, errWriteFailed) {
+		return
+	}
+	var reconnect bool
+	ps.withPeer(id, func(p *Peer) {
+		if p == nil {
+			return
+		}
+		nowis := time.Now()
+		if p.sendFailures == 0 || nowis.Sub(p.sendFailuresSince) > sendFailuresWindow {
+			p.sendFailures = 0
+			p.sendFailuresSince = nowis
+		}
+		p.sendFailures++
+		if p.sendFailures < ps.maxSendFailures() {
+			return
+		}
+		p.sendFailures = 0
+		reason := fmt.Sprintf("%d consecutive write failures within %v", ps.maxSendFailures(), sendFailuresWindow)
+		if !p.isStatic {
+			ps._dropPeer(p, reason)
+			return
+		}
+		ps.Log().Infof("[peering] reconnecting static peer %s - %s: %s", ShortPeerIDString(p.id), p.name, reason)
+		reconnect = true
+	})
+	if reconnect {
+		go ps.reconnectStaticPeer(id)
+	}
+}
+
+// reconnectStaticPeer closes connection with the static peer and connects to it again
+func (ps *Peers) reconnectStaticPeer(id peer.ID) {
+	_ = ps.host.Network().ClosePeer(id)
+
+	ctx, cancel := context.WithTimeout(ps.Ctx(), reconnectStaticPeerTimeout)
+	defer cancel()
+
+	if err := ps.host.Connect(ctx, ps.host.Peerstore().PeerInfo(id)); err != nil {
+		ps.Log().Warnf("[peering] failed to reconnect static peer %s: %v", ShortPeerIDString(id), err)
+	}
+}
+
+// sendMsgBytesOutWithContext is used when the global context may already be done, e.g. on shutdown
+func (ps *Peers) sendMsgBytesOutWithContext(parent context.Context, peerID peer.ID, protocolID protocol.ID, data []byte, timeout ...time.Duration) bool {
+	return ps.sendMsgOutWithContext(parent, peerID, []protocol.ID{protocolID}, func(_ protocol.ID) []byte { return data }, timeout...) == nil
+}
+
+func (ps *Peers) sendMsgOutWithContext(parent context.Context, peerID peer.ID, protocolIDs []protocol.ID, data func(protocolID protocol.ID) []byte, timeout ...time.Duration) error {
+	to := ps.sendTimeout()
 	if len(timeout) > 0 {
 		to = timeout[0]
 	}
 
-	ctx, cancel := context.WithTimeoutCause(ps.Ctx(), to, context.DeadlineExceeded)
+	ctx, cancel := context.WithTimeoutCause(parent, to, context.DeadlineExceeded)
 	defer cancel()
 
 	// the NewStream waits until context is done
-	stream, err := ps.host.NewStream(ctx, peerID, protocolID)
+	stream, err := ps.host.NewStream(ctx, peerID, protocolIDs...)
 	if err != nil {
-		return false
+		return err
 	}
 
 	if ctx.Err() != nil {
-		return false
+		return ctx.Err()
 	}
 	util.Assertf(stream != nil, "stream != nil")
-	defer func() { _ = stream.Close() }()
 
-	if err = writeFrame(stream, data); err != nil {
-		ps.Log().Errorf("[peering] error while sending message to peer %s", ShortPeerIDString(peerID))
+	deadline, _ := ctx.Deadline()
+	if err = writeFrameWithDeadline(stream, data(stream.Protocol()), ps.maxMessageBytes(), deadline); err != nil {
+		ps.Log().Errorf("[peering] error while sending message to peer %s: %v", ShortPeerIDString(peerID), err)
+		_ = stream.Reset()
+		return fmt.Errorf("%w: %v", errWriteFailed, err)
 	}
+	_ = stream.Close()
 	ps.outMsgCounter.Inc()
-	return err == nil
+	return nil
 }
 
 // sendMsgBytesOutMulti send to multiple peers in parallel
@@ -550,6 +930,10 @@ func (ps *Peers) GetPeersInfo() *api.PeersInfo {
 			NumIncomingHB:             p.numIncomingHB,
 			NumIncomingPull:           p.numIncomingPull,
 			NumIncomingTx:             p.numIncomingTx,
+			NumGossipParseFailures:    p.numGossipParseFailures,
+			NumGossipRateLimited:      p.numGossipRateLimited,
+			LatestCommittedSlot:       uint32(p.latestCommittedSlot),
+			Synced:                    p.synced,
 		}
 		pi.MultiAddresses = make([]string, 0)
 		for _, ma := range ps.host.Peerstore().Addrs(p.id) {
@@ -563,3 +947,26 @@ func (ps *Peers) GetPeersInfo() *api.PeersInfo {
 	}
 	return ret
 }
+
+// PeerStats returns statistics of all peers. The snapshot is taken under the read lock
+func (ps *Peers) PeerStats() []PeerStatsSnapshot {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	ret := make([]PeerStatsSnapshot, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		bad := p.numGossipParseFailures + p.numGossipRateLimited
+		ret = append(ret, PeerStatsSnapshot{
+			Name:               p.name,
+			ID:                 p.id,
+			IsStatic:           p.isStatic,
+			IsAlive:            p._isAlive(),
+			IncomingGood:       max(0, p.numIncomingTx-bad),
+			IncomingBad:        bad,
+			AvgClockDifference: p._avgClockDifference(),
+			LastActivity:       p.lastHeartbeatReceived,
+			HasTxStore:         p.respondsToPullRequests,
+		})
+	}
+	return ret
+}
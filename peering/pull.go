@@ -16,7 +16,7 @@ const PullTransactions = byte(iota)
 
 func (ps *Peers) pullStreamHandler(stream network.Stream) {
 	ps.inMsgCounter.Inc()
-	if ps.cfg.IgnoreAllPullRequests {
+	if ps.ignoresAllPullRequests() {
 		// ignore all pull requests
 		_ = stream.Close()
 		return
@@ -38,7 +38,7 @@ func (ps *Peers) pullStreamHandler(stream network.Stream) {
 		return
 	}
 
-	msgData, err := readFrame(stream)
+	msgData, err := readFrame(stream, ps.maxMessageBytes())
 	_ = stream.Close()
 
 	switch {
@@ -77,9 +77,40 @@ func (ps *Peers) PullTransactionsFromNPeers(nPeers int, txid ledger.TransactionI
 
 	targets := ps.chooseNPullTargets(nPeers)
 	ps.sendPullTransactionToPeers(targets, txid)
+
+	if len(targets) > 0 {
+		if len(targets) < ps.numPullTargets() {
+			ps.pullRequestsIssued.WithLabelValues("random").Inc()
+		} else {
+			ps.pullRequestsIssued.WithLabelValues("all").Inc()
+		}
+		ps.pullRequestsOut.Add(float64(len(targets)))
+	}
 	return len(targets)
 }
 
+// PullTransactionsFromPeer sends pull requests for transactions to the specific peer.
+// Returns false and sends nothing if the peer is not known, not alive or not a pull target
+func (ps *Peers) PullTransactionsFromPeer(id peer.ID, lst ...ledger.TransactionID) bool {
+	if !ps.isAlivePullTarget(id) {
+		return false
+	}
+	for _, txid := range lst {
+		ps.sendPullTransactionToPeers([]peer.ID{id}, txid)
+	}
+	ps.pullRequestsIssued.WithLabelValues("peer").Inc()
+	ps.pullRequestsOut.Add(float64(len(lst)))
+	return true
+}
+
+func (ps *Peers) isAlivePullTarget(id peer.ID) bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	p := ps._getPeer(id)
+	return p != nil && p._isAlive() && ps._isPullTarget(p)
+}
+
 func encodePullTransactionMsg(txid ledger.TransactionID) []byte {
 	var buf bytes.Buffer
 	// write request type byte
@@ -95,8 +126,15 @@ func decodePullTransactionMsg(data []byte) (ledger.TransactionID, error) {
 	return ledger.TransactionIDFromBytes(data[1:])
 }
 
+func (ps *Peers) numPullTargets() int {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	return len(ps._pullTargets())
+}
+
 func (ps *Peers) _isPullTarget(p *Peer) bool {
-	return p.respondsToPullRequests || ps.cfg.ForcePullFromAllPeers
+	return !p.goingAway && (p.respondsToPullRequests || ps.cfg.ForcePullFromAllPeers)
 }
 
 // out message wrappers
@@ -1,6 +1,8 @@
 package peering
 
 import (
+	"math"
+	"math/rand"
 	"sort"
 
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -66,7 +68,54 @@ func (ps *Peers) pullTargetsByRankDescLines(prefix ...string) *lines.Lines {
 }
 
 func (ps *Peers) chooseNPullTargets(n int) []peer.ID {
-	return ps.chooseBestNPullTargetsBestAndRandom(n)
+	switch ps.cfg.PullTargetSelection {
+	case PullTargetSelectionUniform:
+		return ps.chooseBestNPullTargetsRandom(n)
+	case PullTargetSelectionReputation:
+		return ps.chooseNPullTargetsByReputation(n)
+	default:
+		return ps.chooseBestNPullTargetsBestAndRandom(n)
+	}
+}
+
+// reputationScore is in (0,1]. Peers without evidence have score 1
+func (p *Peer) reputationScore() float64 {
+	bad := p.numGossipParseFailures + p.numGossipRateLimited
+	good := max(0, p.numIncomingTx-bad)
+	return float64(good+1) / float64(good+bad+1)
+}
+
+// onlyBadEvidence peer sent bad messages and no good ones
+func (p *Peer) onlyBadEvidence() bool {
+	return p.numGossipParseFailures+p.numGossipRateLimited >= max(1, p.numIncomingTx)
+}
+
+// chooseNPullTargetsByReputation selects n random pull targets, weighted by reputation score.
+// Peers with only bad evidence are chosen only if there are not enough other peers
+func (ps *Peers) chooseNPullTargetsByReputation(n int) []peer.ID {
+	if n <= 0 {
+		return nil
+	}
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	// weighted random sampling without replacement: sort by u^(1/w) descending
+	targets := ps._pullTargets()
+	keys := make(map[peer.ID]float64, len(targets))
+	for _, p := range targets {
+		keys[p.id] = math.Pow(rand.Float64(), 1/p.reputationScore())
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if bi, bj := targets[i].onlyBadEvidence(), targets[j].onlyBadEvidence(); bi != bj {
+			return bj
+		}
+		return keys[targets[i].id] > keys[targets[j].id]
+	})
+	ret := make([]peer.ID, 0, n)
+	for i := 0; i < n && i < len(targets); i++ {
+		ret = append(ret, targets[i].id)
+	}
+	return ret
 }
 
 // chooseBestNPullTargetsRandom just select random n out of all pull targets
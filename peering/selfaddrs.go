@@ -0,0 +1,58 @@
+package peering
+
+import (
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// SelfAddrs returns multiaddresses the libp2p host advertises to other peers.
+// If all addresses are filtered out (e.g. only local IPs are available), returns addresses of the interfaces
+// the host is listening on
+func (ps *Peers) SelfAddrs() []multiaddr.Multiaddr {
+	if ret := ps.host.Addrs(); len(ret) > 0 {
+		return ret
+	}
+	ret, err := ps.host.Network().InterfaceListenAddresses()
+	if err != nil {
+		ps.Log().Warnf("[peering] can't get interface listen addresses: %v", err)
+	}
+	return ret
+}
+
+// NATStatus returns reachability of the node as detected by the AutoNAT: 'public', 'private' or 'unknown'
+func (ps *Peers) NATStatus() string {
+	switch network.Reachability(ps.reachability.Load()) {
+	case network.ReachabilityPublic:
+		return "public"
+	case network.ReachabilityPrivate:
+		return "private"
+	default:
+		return "unknown"
+	}
+}
+
+// trackReachability listens to reachability changes reported by the AutoNAT of the libp2p host
+func (ps *Peers) trackReachability() {
+	sub, err := ps.host.EventBus().Subscribe(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		ps.Log().Warnf("[peering] can't subscribe to reachability events: %v", err)
+		return
+	}
+	go func() {
+		defer func() { _ = sub.Close() }()
+		for {
+			select {
+			case <-ps.Ctx().Done():
+				return
+			case e, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				evt := e.(event.EvtLocalReachabilityChanged)
+				ps.reachability.Store(int32(evt.Reachability))
+				ps.Log().Infof("[peering] NAT status: %s, advertised addresses: %v", ps.NATStatus(), ps.SelfAddrs())
+			}
+		}
+	}()
+}
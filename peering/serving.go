@@ -0,0 +1,25 @@
+package peering
+
+// PauseServing stops transmitting gossip and serving pull requests to peers.
+// Connections and heartbeats are kept. Peers are informed via heartbeat that pull requests are ignored
+func (ps *Peers) PauseServing() {
+	if !ps.servingPaused.Swap(true) {
+		ps.Log().Infof("[peering] serving of gossip and pull requests PAUSED")
+	}
+}
+
+// ResumeServing resumes gossip and serving of pull requests after PauseServing
+func (ps *Peers) ResumeServing() {
+	if ps.servingPaused.Swap(false) {
+		ps.Log().Infof("[peering] serving of gossip and pull requests RESUMED")
+	}
+}
+
+func (ps *Peers) IsServingPaused() bool {
+	return ps.servingPaused.Load()
+}
+
+// ignoresAllPullRequests true if pull requests are ignored either by config or because serving is paused
+func (ps *Peers) ignoresAllPullRequests() bool {
+	return ps.cfg.IgnoreAllPullRequests || ps.IsServingPaused()
+}
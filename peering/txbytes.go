@@ -2,6 +2,7 @@ package peering
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -13,36 +14,43 @@ func (ps *Peers) gossipStreamHandler(stream network.Stream) {
 	ps.inMsgCounter.Inc()
 	id := stream.Conn().RemotePeer()
 
+	rateLimited := false
 	known, blacklisted, _ := ps.knownPeer(id, func(p *Peer) {
 		p.numIncomingTx++
+		rateLimited = !ps._takeGossipToken(p)
 	})
 	if !known || blacklisted {
 		// ignore
 		_ = stream.Close()
 		return
 	}
-	txBytesWithMetadata, err := readFrame(stream)
+	if rateLimited {
+		ps.gossipRateLimited.Inc()
+		ps.Tracef(TraceTag, "gossip: rate limit of incoming messages exceeded by peer %s", ShortPeerIDString(id))
+		_ = stream.Close()
+		return
+	}
+	txBytesWithMetadata, err := readFrame(stream, ps.maxMessageBytes())
 	_ = stream.Close()
 
 	if err != nil {
 		ps.Log().Errorf("gossip: error while reading message from peer %s: %v", id.String(), err)
 		return
 	}
+	ps.processGossipMsg(id, txBytesWithMetadata)
+}
 
+func (ps *Peers) processGossipMsg(id peer.ID, txBytesWithMetadata []byte) {
 	metadataBytes, txBytes, err := txmetadata.SplitTxBytesWithMetadata(txBytesWithMetadata)
 	if err != nil {
 		// protocol violation
-		err = fmt.Errorf("gossip: error while parsing tx message from peer %s: %v", id.String(), err)
-		ps.Log().Error(err)
-		ps.dropPeer(id, err.Error())
+		ps.gossipParseFailure(id, fmt.Errorf("gossip: error while parsing tx message from peer %s: %v", id.String(), err))
 		return
 	}
 	metadata, err := txmetadata.TransactionMetadataFromBytes(metadataBytes)
 	if err != nil {
 		// protocol violation
-		err = fmt.Errorf("gossip: error while parsing tx message metadata from peer %s: %v", id.String(), err)
-		ps.Log().Error(err)
-		ps.dropPeer(id, err.Error())
+		ps.gossipParseFailure(id, fmt.Errorf("gossip: error while parsing tx message metadata from peer %s: %v", id.String(), err))
 		return
 	}
 
@@ -52,7 +60,58 @@ func (ps *Peers) gossipStreamHandler(stream network.Stream) {
 	ps.onReceiveTx(id, txBytes, metadata)
 }
 
+// _takeGossipToken implements token bucket rate limiter of incoming gossip messages from the dynamic peer.
+// Bucket is refilled at maximum rate per second and holds up to 1 second of messages.
+// Returns false if the peer exceeded the rate. Static peers are not limited
+func (ps *Peers) _takeGossipToken(p *Peer) bool {
+	if p.isStatic {
+		return true
+	}
+	nowis := time.Now()
+	maxTokens := float64(ps.maxGossipMsgsPerSec())
+	if p.gossipTokensUpdated.IsZero() {
+		p.gossipTokens = maxTokens
+	} else {
+		p.gossipTokens = min(maxTokens, p.gossipTokens+nowis.Sub(p.gossipTokensUpdated).Seconds()*maxTokens)
+	}
+	p.gossipTokensUpdated = nowis
+	if p.gossipTokens < 1 {
+		p.numGossipRateLimited++
+		return false
+	}
+	p.gossipTokens--
+	return true
+}
+
+// gossipParseFailure counts parse failures of the peer. When number of failures within the window
+// exceeds the configured maximum, the peer is dropped and blacklisted
+func (ps *Peers) gossipParseFailure(id peer.ID, err error) {
+	ps.Log().Error(err)
+	ps.gossipParseFailures.Inc()
+
+	ps.withPeer(id, func(p *Peer) {
+		if p == nil {
+			return
+		}
+		p.numGossipParseFailures++
+		nowis := time.Now()
+		if nowis.Sub(p.gossipParseFailuresSince) > gossipParseFailuresWindow {
+			p.gossipParseFailuresSince = nowis
+			p.gossipParseFailuresInWindow = 0
+		}
+		p.gossipParseFailuresInWindow++
+		if p.gossipParseFailuresInWindow > ps.maxGossipParseFailures() {
+			reason := fmt.Sprintf("too many gossip parse failures: %d within %v", p.gossipParseFailuresInWindow, gossipParseFailuresWindow)
+			ps.Log().Warnf("[peering] peer %s: %s", ShortPeerIDString(id), reason)
+			ps._dropPeer(p, reason)
+		}
+	})
+}
+
 func (ps *Peers) GossipTxBytesToPeers(txBytes []byte, metadata *txmetadata.TransactionMetadata, except ...peer.ID) {
+	if ps.IsServingPaused() {
+		return
+	}
 	targets := ps.peerIDsAlive(except...)
 	ps.sendTxBytesWithMetadataToPeers(targets, txBytes, metadata)
 }
@@ -66,6 +125,9 @@ func (ps *Peers) sendTxBytesWithMetadataToPeers(ids []peer.ID, txBytes []byte, m
 }
 
 func (ps *Peers) SendTxBytesWithMetadataToPeer(id peer.ID, txBytes []byte, metadata *txmetadata.TransactionMetadata) bool {
+	if ps.IsServingPaused() {
+		return false
+	}
 	msg := gossipMsgWrapper{
 		metadata: metadata,
 		txBytes:  txBytes,
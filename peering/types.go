@@ -3,13 +3,14 @@ package peering
 import (
 	"crypto/ed25519"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/discovery"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
-	"github.com/libp2p/go-libp2p/p2p/discovery/routing"
 	"github.com/lunfardo314/proxima/core/txmetadata"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
@@ -33,10 +34,52 @@ type (
 		// Node info
 		IgnoreAllPullRequests                 bool
 		AcceptPullRequestsFromStaticPeersOnly bool
+		// DynamicPeerAddrTTL is TTL of dynamic peer addresses in the peerstore. It is refreshed by heartbeats.
+		// Addresses of static peers are permanent
+		DynamicPeerAddrTTL time.Duration
 		// AllowLocalIPs defines if local IPs are allowed to be used for autopeering.
 		AllowLocalIPs bool `default:"false" usage:"allow local IPs to be used for autopeering"`
 		// used for testing only. Otherwise, remote peer sets the pull flags
 		ForcePullFromAllPeers bool
+		// RecoverWhenIsolated if true, node aggressively re-bootstraps from static peers and
+		// triggers autopeering when all peers are dead
+		RecoverWhenIsolated bool
+		// DenyPeers connections to/from these peers are rejected by the connection gater
+		DenyPeers []peer.ID
+		// AllowPeers if not empty, only connections to/from these peers are accepted by the connection gater
+		AllowPeers []peer.ID
+		// MaxGossipParseFailures maximum number of gossip message parse failures from a peer tolerated
+		// within gossipParseFailuresWindow. When exceeded, the peer is dropped and blacklisted.
+		// 0 means default
+		MaxGossipParseFailures int
+		// MaxGossipMsgsPerSec maximum rate of incoming gossip messages from a dynamic peer. Messages above the rate are rejected.
+		// Static peers are not rate limited. 0 means default
+		MaxGossipMsgsPerSec int
+		// PullTargetSelection policy of choosing peers to pull transactions from: PullTargetSelectionRank,
+		// PullTargetSelectionUniform or PullTargetSelectionReputation. Empty means PullTargetSelectionRank
+		PullTargetSelection string
+		// MaxMessageBytes maximum size of the message sent or received by peering protocols.
+		// Bigger messages are rejected. 0 means MaxPayloadSize, maximum is MaxMessageBytesLimit
+		MaxMessageBytes int
+		// Security libp2p security transport of the host: SecurityNone or SecurityNoise. Empty means SecurityNone.
+		// Production nodes should use SecurityNoise. Note that QUIC connections are always encrypted
+		// by the TLS 1.3 built into QUIC, the security transport applies to other libp2p transports
+		Security string
+		// MaxNewDynamicPeers maximum number of new dynamic peers accepted from incoming heartbeats
+		// within newDynamicPeersWindow. Heartbeats from other unknown peers are ignored until the window ends.
+		// 0 means default
+		MaxNewDynamicPeers int
+		// AdditionalRendezvous rendezvous strings advertised and searched by autopeering
+		// in addition to the default one, derived from the ledger library hash
+		AdditionalRendezvous []string
+		// ClockTolerance tolerated difference between local and remote clocks. 0 means default ClockTolerance
+		ClockTolerance time.Duration
+		// SendTimeout deadline of each outgoing message, including opening of the stream. 0 means default
+		SendTimeout time.Duration
+		// MaxSendFailures number of consecutive write failures or write timeouts to the peer within sendFailuresWindow,
+		// after which dynamic peer is dropped and static peer is reconnected. Failures to open stream, e.g. when
+		// peer is not reachable, are not counted. 0 means default
+		MaxSendFailures int
 	}
 
 	_multiaddr struct {
@@ -51,18 +94,44 @@ type (
 		stopOnce         sync.Once
 		host             host.Host
 		kademliaDHT      *dht.IpfsDHT // not nil if autopeering is enabled
-		routingDiscovery *routing.RoutingDiscovery
+		routingDiscovery discovery.Discovery
 		peers            map[peer.ID]*Peer // except self/host
 		staticPeers      set.Set[peer.ID]
 		blacklist        map[peer.ID]_deadlineWithReason
+		gater            *peerGater
 		// on receive handlers
 		onReceiveTx     func(from peer.ID, txBytes []byte, mdata *txmetadata.TransactionMetadata)
 		onReceivePullTx func(from peer.ID, txid ledger.TransactionID)
+		// peer lifecycle handlers. Called in separate goroutines
+		onPeerAdded   func(id peer.ID, name string, static bool)
+		onPeerDropped func(id peer.ID, reason string)
+		// syncStatusSource provides own sync status sent to peers with heartbeats
+		syncStatusSource    func() (latestCommittedSlot ledger.Slot, synced bool)
+		latestCommittedSlot atomic.Uint32
+		synced              atomic.Bool
 		// lpp protocol names
-		lppProtocolGossip    protocol.ID
-		lppProtocolPull      protocol.ID
-		lppProtocolHeartbeat protocol.ID
-		rendezvousString     string
+		lppProtocolGossip          protocol.ID
+		lppProtocolPull            protocol.ID
+		lppProtocolHeartbeat       protocol.ID
+		lppProtocolHeartbeatLegacy protocol.ID
+		// rendezvousStrings the first one is the default, derived from the ledger
+		rendezvousStrings []string
+		// isolation state
+		isolated              atomic.Bool
+		rebootstrapAttempts   atomic.Int64
+		rebootstrapInProgress atomic.Bool
+		// current backoff between re-bootstrap attempts and the earliest time of the next one, in nanoseconds
+		rebootstrapBackoff atomic.Int64
+		nextRebootstrap    atomic.Int64
+		// throttling of new dynamic peers. Protected by the mutex
+		newDynamicPeersSince    time.Time
+		newDynamicPeersInWindow int
+		// servingPaused when true, gossip and pull requests are not served
+		servingPaused atomic.Bool
+		// goingAway when true, node is shutting down and heartbeats carry flagGoingAway
+		goingAway atomic.Bool
+		// reachability of the node as detected by AutoNAT (network.Reachability)
+		reachability atomic.Int32
 		metrics
 	}
 
@@ -71,11 +140,33 @@ type (
 		reason string
 	}
 	peersStats struct {
-		peersAll         int
-		peersStatic      int
-		peersDead        int
-		peersAlive       int
-		peersPullTargets int
+		peersAll          int
+		peersStatic       int
+		peersDead         int
+		peersAlive        int
+		peersPullTargets  int
+		peersAliveStatic  int
+		peersAliveDynamic int
+		peersBlacklisted  int
+		// median clock differences of alive peers
+		clockDiffs []time.Duration
+	}
+
+	// PeerStatsSnapshot is consistent snapshot of the peer's statistics, returned by PeerStats
+	PeerStatsSnapshot struct {
+		Name     string
+		ID       peer.ID
+		IsStatic bool
+		IsAlive  bool
+		// IncomingGood number of accepted incoming gossip messages
+		IncomingGood int
+		// IncomingBad number of incoming gossip messages rejected because of parse failures or rate limit
+		IncomingBad        int
+		AvgClockDifference time.Duration
+		// LastActivity when the last heartbeat was received
+		LastActivity time.Time
+		// HasTxStore peer responds to pull requests, i.e. serves transactions from its tx store
+		HasTxStore bool
 	}
 
 	Peer struct {
@@ -86,9 +177,11 @@ type (
 		whenAdded              time.Time
 		lastHeartbeatReceived  time.Time
 		lastLoggedConnected    bool // toggle
+		goingAway              bool // from hb info. Peer is shutting down
 		// ring buffer with last clock differences
 		clockDifferences         [10]time.Duration
 		clockDifferencesIdx      int
+		clockDifferencesNum      int // number of filled entries
 		clockDifferenceQuartiles [3]time.Duration
 		// ring buffer with durations between subsequent HB messages
 		hbMsgDifferences         [10]time.Duration
@@ -101,6 +194,21 @@ type (
 		numIncomingHB   int
 		numIncomingPull int
 		numIncomingTx   int
+		// gossip parse failures: total and within the current window
+		numGossipParseFailures      int
+		gossipParseFailuresInWindow int
+		gossipParseFailuresSince    time.Time
+		// token bucket of incoming gossip messages and number of messages rejected by the rate limiter
+		gossipTokens         float64
+		gossipTokensUpdated  time.Time
+		numGossipRateLimited int
+		// number of consecutive write failures to the peer within sendFailuresWindow. Reset by successful send
+		sendFailures      int
+		sendFailuresSince time.Time
+		// sync status from hb info
+		hasSyncInfo         bool
+		latestCommittedSlot ledger.Slot
+		synced              bool
 	}
 )
 
@@ -115,14 +223,16 @@ const (
 	// Nodes with different versions of the ledger constraints will just ignore each other
 	lppProtocolGossip    = "/proxima/gossip/%d"
 	lppProtocolPull      = "/proxima/pull/%d"
-	lppProtocolHeartbeat = "/proxima/heartbeat/%d"
+	lppProtocolHeartbeat = "/proxima/heartbeat/2/%d"
+	// lppProtocolHeartbeatLegacy heartbeat without sync info. Old nodes only understand this one
+	lppProtocolHeartbeatLegacy = "/proxima/heartbeat/%d"
 
-	// clockTolerance is how big the difference between local and remote clocks is tolerated.
+	// ClockTolerance is how big the difference between local and remote clocks is tolerated.
 	// The difference includes difference between local clocks (positive or negative) plus
 	// positive heartbeat message latency between peers
 	// In any case nodes has interest to sync their clocks with global reference.
-	// This constant indicates when to drop the peer
-	clockTolerance = 4 * time.Second
+	// This constant is the default, used when Config.ClockTolerance is not configured
+	ClockTolerance = 4 * time.Second
 
 	// if the node is bootstrap, and it has configured less than numMaxDynamicPeersForBootNodeAtLeast
 	// of dynamic peer cap, use this instead
@@ -136,4 +246,32 @@ const (
 	// gracePeriodAfterAdded period of time peer is considered not dead after added even if messages are not coming
 	gracePeriodAfterAdded = 15 * heartbeatRate
 	logPeersEvery         = 5 * time.Second
+	// defaultDynamicPeerAddrTTL is used when DynamicPeerAddrTTL is not configured
+	defaultDynamicPeerAddrTTL = 10 * time.Minute
+	// defaultMaxGossipParseFailures is used when MaxGossipParseFailures is not configured
+	defaultMaxGossipParseFailures = 3
+	gossipParseFailuresWindow     = time.Minute
+	// SecurityNone no libp2p security transport is configured
+	SecurityNone = "none"
+	// SecurityNoise libp2p Noise security protocol, authenticated with the host ID key
+	SecurityNoise = "noise"
+	// PullTargetSelectionRank the best ranked pull target plus random others
+	PullTargetSelectionRank = "rank"
+	// PullTargetSelectionUniform uniformly random pull targets. Reproducible behavior for tests
+	PullTargetSelectionUniform = "uniform"
+	// PullTargetSelectionReputation random pull targets weighted by the reputation score. Peers with only bad evidence are chosen last
+	PullTargetSelectionReputation = "reputation"
+	// defaultMaxGossipMsgsPerSec is used when MaxGossipMsgsPerSec is not configured
+	defaultMaxGossipMsgsPerSec = 200
+	// defaultMaxNewDynamicPeers is used when MaxNewDynamicPeers is not configured
+	defaultMaxNewDynamicPeers = 60
+	newDynamicPeersWindow     = time.Minute
+	// defaultSendTimeout is used when SendTimeout is not configured
+	defaultSendTimeout = 500 * time.Millisecond
+	// defaultMaxSendFailures is used when MaxSendFailures is not configured
+	defaultMaxSendFailures = 10
+	// sendFailuresWindow consecutive write failures older than the window are forgotten
+	sendFailuresWindow = time.Minute
+	// reconnectStaticPeerTimeout timeout of the connection attempt to the static peer after write failures
+	reconnectStaticPeerTimeout = 5 * time.Second
 )
@@ -11,6 +11,7 @@ import (
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/multistate"
 	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/lunfardo314/proxima/util/graphviz"
 	"github.com/spf13/cobra"
 )
 
@@ -21,11 +22,12 @@ const defaultMaxSlotsBackDAG = 100
 func initDBDAGCmd() *cobra.Command {
 	dbTreeCmd := &cobra.Command{
 		Use:   fmt.Sprintf("dag [max slots back, default %d]", defaultMaxSlotsBackDAG),
-		Short: "create .DOT file for the MemDAG of all transactions in the past cone of tip branches",
+		Short: "create graph file for the MemDAG of all transactions in the past cone of tip branches",
 		Args:  cobra.MaximumNArgs(1),
 		Run:   runDbDAGCmd,
 	}
 	dbTreeCmd.PersistentFlags().StringVarP(&outputFileDAG, "output", "o", "", "output file")
+	dbTreeCmd.PersistentFlags().StringVar(&outputFormat, "format", graphviz.FormatDOT, "output format: 'gv', 'svg' or 'png'. Rendering requires graphviz")
 	dbTreeCmd.InitDefaultHelpCmd()
 	return dbTreeCmd
 }
@@ -40,27 +42,28 @@ func runDbDAGCmd(_ *cobra.Command, args []string) {
 	glb.AssertNoError(err)
 	currentWorkingDir := filepath.Base(pwdPath)
 
-	outFile := outputFile
 	if outputFileDAG == "" {
 		outputFileDAG = global.TxStoreDBName + "_DAG_" + currentWorkingDir
 	}
 
 	branchTxIDS := multistate.FetchLatestBranchTransactionIDs(glb.StateStore())
 	numSlotsBack := defaultMaxSlotsBackDAG
-	if len(args) == 0 {
-		tmpDag := memdag.MakeDAGFromTxStore(glb.TxBytesStore(), 0, branchTxIDS...)
-		tmpDag.SaveGraph(outputFileDAG)
-	} else {
+	oldestSlot := 0
+	if len(args) > 0 {
 		latestSlot := multistate.FetchLatestCommittedSlot(glb.StateStore())
-		var err error
 		numSlotsBack, err = strconv.Atoi(args[0])
 		glb.AssertNoError(err)
-		oldestSlot := 0
 		if numSlotsBack < int(latestSlot) {
 			oldestSlot = int(latestSlot) - numSlotsBack
 		}
-		tmpDag := memdag.MakeDAGFromTxStore(glb.TxBytesStore(), ledger.Slot(oldestSlot), branchTxIDS...)
-		tmpDag.SaveGraph(outputFileDAG)
 	}
-	glb.Infof("MemDAG has been store in .DOT format in the file '%s', %d slots back", outFile, numSlotsBack)
+	tmpDag := memdag.MakeDAGFromTxStore(glb.TxBytesStore(), ledger.Slot(oldestSlot), branchTxIDS...)
+	// incomplete graph is saved anyway
+	gr, graphErr := tmpDag.MakeGraph()
+	err = graphviz.SaveGraphAs(gr, outputFileDAG, outputFormat)
+	glb.AssertNoError(err)
+	if graphErr != nil {
+		glb.Infof("warning: graph is incomplete: %v", graphErr)
+	}
+	glb.Infof("MemDAG has been stored in the file '%s.%s', %d slots back", outputFileDAG, outputFormat, numSlotsBack)
 }
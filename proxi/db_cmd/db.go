@@ -24,6 +24,9 @@ func Init() *cobra.Command {
 		initReliableBranchCmd(),
 		txstore.Init(),
 		initChainsCmd(),
+		initOrphansCmd(),
+		initRollbackCmd(),
+		initVerifyCmd(),
 	)
 	return dbCmd
 }
@@ -0,0 +1,73 @@
+package db_cmd
+
+import (
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/multistate"
+	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/lunfardo314/proxima/txstore"
+	"github.com/spf13/cobra"
+)
+
+var (
+	orphansPrune       bool
+	orphansHorizonBack int
+)
+
+const defaultOrphansHorizonSlotsBack = 100
+
+func initOrphansCmd() *cobra.Command {
+	orphansCmd := &cobra.Command{
+		Use:   "orphans",
+		Short: "lists transactions in the txStore which are not committed in any of the latest branches and are older than the horizon",
+		Args:  cobra.NoArgs,
+		Run:   runOrphansCmd,
+	}
+	orphansCmd.PersistentFlags().BoolVarP(&orphansPrune, "prune", "p", false, "delete orphaned transactions from the txStore")
+	orphansCmd.PersistentFlags().IntVarP(&orphansHorizonBack, "horizon", "z", defaultOrphansHorizonSlotsBack,
+		"number of slots back from the latest committed slot. Transactions in later slots are not considered orphaned")
+	orphansCmd.InitDefaultHelpCmd()
+	return orphansCmd
+}
+
+func runOrphansCmd(_ *cobra.Command, _ []string) {
+	glb.InitLedgerFromDB()
+	glb.InitTxStoreDB()
+	defer glb.CloseDatabases()
+
+	latestSlot := multistate.FetchLatestCommittedSlot(glb.StateStore())
+	var horizonSlot ledger.Slot
+	if int(latestSlot) > orphansHorizonBack {
+		horizonSlot = latestSlot - ledger.Slot(orphansHorizonBack)
+	}
+	glb.Infof("latest committed slot: %d, horizon slot: %d", latestSlot, horizonSlot)
+
+	// transaction is not orphaned if it is committed in any of the latest branches
+	branches := multistate.FetchLatestBranches(glb.StateStore())
+	readers := make([]*multistate.Readable, len(branches))
+	for i := range branches {
+		readers[i] = multistate.MustNewReadable(glb.StateStore(), branches[i].Root)
+	}
+	isCommitted := func(txid *ledger.TransactionID) bool {
+		for _, rdr := range readers {
+			if rdr.KnowsCommittedTransaction(txid) {
+				return true
+			}
+		}
+		return false
+	}
+
+	orphans, err := txstore.OrphanedTransactions(glb.TxBytesStoreDB(), isCommitted, horizonSlot)
+	glb.AssertNoError(err)
+
+	for i := range orphans {
+		glb.Infof("%s, hex ID = %s", orphans[i].String(), orphans[i].StringHex())
+	}
+	glb.Infof("total: %d orphaned transactions", len(orphans))
+
+	if !orphansPrune || len(orphans) == 0 {
+		return
+	}
+	err = txstore.DeleteTransactions(glb.TxBytesStoreDB(), orphans)
+	glb.AssertNoError(err)
+	glb.Infof("%d orphaned transactions deleted from the txStore", len(orphans))
+}
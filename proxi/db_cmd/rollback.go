@@ -0,0 +1,49 @@
+package db_cmd
+
+import (
+	"encoding/hex"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/multistate"
+	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/lunfardo314/unitrie/common"
+	"github.com/spf13/cobra"
+)
+
+var confirmRollback bool
+
+func initRollbackCmd() *cobra.Command {
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback <root hex>",
+		Short: "rolls the multi-state DB back to the existing root. Dangerous! For disaster recovery only, node must be stopped",
+		Long: `rolls the multi-state DB back to the existing root. The root becomes the only root of the latest slot, 
+root records of newer slots and other roots of the same slot are deleted. 
+Dangerous! For disaster recovery only, node must be stopped. Requires flag --confirm-rollback`,
+		Args: cobra.ExactArgs(1),
+		Run:  runRollbackCmd,
+	}
+	rollbackCmd.Flags().BoolVar(&confirmRollback, "confirm-rollback", false, "confirm rollback of the state")
+
+	rollbackCmd.InitDefaultHelpCmd()
+	return rollbackCmd
+}
+
+func runRollbackCmd(_ *cobra.Command, args []string) {
+	glb.Assertf(confirmRollback, "rollback of the state must be confirmed with flag --confirm-rollback")
+
+	glb.InitLedgerFromDB()
+	defer glb.CloseDatabases()
+
+	rootBin, err := hex.DecodeString(args[0])
+	glb.AssertNoError(err)
+	root, err := common.VectorCommitmentFromBytes(ledger.CommitmentModel, rootBin)
+	glb.AssertNoError(err)
+
+	if !glb.YesNoPrompt("all root records newer than the root will be deleted. Proceed?", false) {
+		glb.Infof("exit")
+		return
+	}
+	err = multistate.SetLatestRoot(glb.StateStore(), root)
+	glb.AssertNoError(err)
+	glb.Infof("latest root is %s, latest committed slot is %d", root.String(), multistate.FetchLatestCommittedSlot(glb.StateStore()))
+}
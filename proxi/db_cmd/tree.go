@@ -9,21 +9,26 @@ import (
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/multistate"
 	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/lunfardo314/proxima/util/graphviz"
 	"github.com/spf13/cobra"
 )
 
-var outputFile string
+var (
+	outputFile   string
+	outputFormat string
+)
 
 const defaultMaxSlotsBack = 100
 
 func initDBTreeCmd() *cobra.Command {
 	dbTreeCmd := &cobra.Command{
 		Use:   fmt.Sprintf("tree [max slots back, default %d]", defaultMaxSlotsBack),
-		Short: "create .DOT file for the tree of all branches",
+		Short: "create .DOT file for the tree of all branches and optionally render it as SVG or PNG",
 		Args:  cobra.MaximumNArgs(1),
 		Run:   runDbTreeCmd,
 	}
 	dbTreeCmd.PersistentFlags().StringVarP(&outputFile, "output", "o", "", "output file")
+	dbTreeCmd.PersistentFlags().StringVar(&outputFormat, "format", graphviz.FormatDOT, "output format: 'gv', 'svg' or 'png'. Rendering requires graphviz")
 
 	dbTreeCmd.InitDefaultHelpCmd()
 	return dbTreeCmd
@@ -43,13 +48,11 @@ func runDbTreeCmd(_ *cobra.Command, args []string) {
 	}
 
 	numSlotsBack := defaultMaxSlotsBack
-	if len(args) == 0 {
-		multistate.SaveBranchTree(glb.StateStore(), outFile, numSlotsBack)
-	} else {
-		var err error
+	if len(args) > 0 {
 		numSlotsBack, err = strconv.Atoi(args[0])
 		glb.AssertNoError(err)
-		multistate.SaveBranchTree(glb.StateStore(), outFile, numSlotsBack)
 	}
-	glb.Infof("branch tree has been store in .DOT format in the file '%s', %d slots back", outFile, numSlotsBack)
+	err = multistate.SaveBranchTreeAs(glb.StateStore(), outFile, outputFormat, numSlotsBack)
+	glb.AssertNoError(err)
+	glb.Infof("branch tree has been stored in the file '%s.%s', %d slots back", outFile, outputFormat, numSlotsBack)
 }
@@ -0,0 +1,58 @@
+package db_cmd
+
+import (
+	"encoding/hex"
+	"os"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/multistate"
+	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/lunfardo314/unitrie/common"
+	"github.com/spf13/cobra"
+)
+
+func initVerifyCmd() *cobra.Command {
+	verifyCmd := &cobra.Command{
+		Use:   "verify [<root hex>]",
+		Short: "verifies consistency of the ledger state with its indices. Verifies all latest roots if root is not specified",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   runVerifyCmd,
+	}
+	verifyCmd.InitDefaultHelpCmd()
+	return verifyCmd
+}
+
+func runVerifyCmd(_ *cobra.Command, args []string) {
+	glb.InitLedgerFromDB()
+	defer glb.CloseDatabases()
+
+	var roots []common.VCommitment
+	if len(args) > 0 {
+		rootBin, err := hex.DecodeString(args[0])
+		glb.AssertNoError(err)
+		root, err := common.VectorCommitmentFromBytes(ledger.CommitmentModel, rootBin)
+		glb.AssertNoError(err)
+		roots = append(roots, root)
+	} else {
+		for _, rr := range multistate.FetchLatestRootRecords(glb.StateStore()) {
+			roots = append(roots, rr.Root)
+		}
+	}
+
+	failed := false
+	for _, root := range roots {
+		rdr, err := multistate.NewReadable(glb.StateStore(), root)
+		if err == nil {
+			err = rdr.VerifyIntegrity()
+		}
+		if err != nil {
+			glb.Infof("root %s: FAILED: %v", root.String(), err)
+			failed = true
+			continue
+		}
+		glb.Infof("root %s: OK", root.String())
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
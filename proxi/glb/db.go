@@ -49,3 +49,8 @@ func InitTxStoreDB() {
 func TxBytesStore() global.TxBytesStore {
 	return txBytesStore
 }
+
+// TxBytesStoreDB returns underlying database of the txStore
+func TxBytesStoreDB() *badger_adaptor.DB {
+	return badger_adaptor.New(txBytesDB)
+}
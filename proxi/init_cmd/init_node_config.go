@@ -118,9 +118,51 @@ peering:
   # max_dynamic_peers > 0 means automatic peer discovery (autopeering) is enabled, otherwise disabled
   max_dynamic_peers: {{.MaxDynamicPeers}}
 
+  # additional rendezvous strings used by autopeering to advertise and discover peers.
+  # The default rendezvous, derived from the ledger, is always used
+  additional_rendezvous: []
+
   # defines if local IPs are allowed to be used for autopeering.
   allow_local_ips: false
 
+  # TTL in seconds of dynamic peer addresses in the peerstore. Refreshed by heartbeats. Static peer addresses are permanent
+  dynamic_peer_addr_ttl_sec: 600
+
+  # if true, node re-bootstraps from static peers and triggers autopeering immediately when all peers are dead
+  recover_when_isolated: true
+
+  # lists of peer IDs. Connections to/from denied peers are rejected by the connection gater.
+  # If allow_peers is not empty, only listed peers can connect
+  deny_peers: []
+  allow_peers: []
+
+  # tolerated difference between local and peer clocks. Peers with bigger median clock difference are reported in the log
+  clock_tolerance: 4s
+
+  # maximum number of malformed gossip messages from a peer tolerated per minute. When exceeded, the peer is dropped and blacklisted
+  max_gossip_parse_failures: 3
+
+  # maximum number of incoming gossip messages per second from a dynamic peer. Static peers are not limited
+  max_gossip_msgs_per_sec: 200
+
+  # policy of choosing peers to pull transactions from: 'rank' (default), 'uniform' or 'reputation'
+  pull_target_selection: rank
+
+  # maximum size of the peering message in bytes. Bigger messages are rejected. 0 means default 65531
+  max_message_bytes: 0
+
+  # deadline of each outgoing message to a peer
+  send_timeout: 500ms
+
+  # number of consecutive write failures within a minute after which dynamic peer is dropped and static peer is reconnected
+  max_send_failures: 10
+
+  # libp2p security transport: 'none' or 'noise'. Production nodes should use 'noise'
+  security: none
+
+  # maximum number of new dynamic peers accepted per minute. Heartbeats from other new peers are ignored until next minute
+  max_new_dynamic_peers: 60
+
 # Node's API config
 api:
     # server port
@@ -134,6 +176,12 @@ api:
     # keep latest up to 3 snapshots, older ones will be purged
   keep_latest: 3
 
+# workflow config
+workflow:
+  # transactions from API and peers with timestamp ahead of the local clock more than the tolerance are rejected.
+  # 0 means default 10 slots
+  future_tolerance_sec: 0
+
 # logger config
 # logger.previous can be 'erase' or 'save'
 logger:
@@ -185,6 +233,7 @@ sequencer:
   # sequencer pace. Distance in ticks between two subsequent sequencer transactions
   # cannot be less than the sequencer pace value set by the ledger
   pace: 12
-  # maximum tag-along inputs allowed in the sequencer transaction (maximum value is 254)
+  # maximum tag-along inputs allowed in the sequencer transaction, independent of the total inputs limit.
+  # Lower values make milestones faster to validate and propagate. Default and maximum value is 254
   max_tag_along_inputs: 100
 `
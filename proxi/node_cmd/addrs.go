@@ -0,0 +1,36 @@
+package node_cmd
+
+import (
+	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/spf13/cobra"
+)
+
+func initNodeAddrsCmd() *cobra.Command {
+	nodeAddrsCmd := &cobra.Command{
+		Use:   "addrs",
+		Short: `retrieves advertised multiaddresses and NAT status of the node`,
+		Args:  cobra.NoArgs,
+		Run:   runNodeAddrsCmd,
+	}
+
+	nodeAddrsCmd.InitDefaultHelpCmd()
+	return nodeAddrsCmd
+}
+
+func runNodeAddrsCmd(_ *cobra.Command, _ []string) {
+	glb.InitLedgerFromNode()
+
+	nodeInfo, err := glb.GetClient().GetNodeInfo()
+	glb.AssertNoError(err)
+
+	glb.Infof("host ID: %s", nodeInfo.ID.String())
+	glb.Infof("NAT status: %s", nodeInfo.NATStatus)
+	if len(nodeInfo.Addrs) == 0 {
+		glb.Infof("no advertised addresses")
+		return
+	}
+	glb.Infof("advertised addresses (%d):", len(nodeInfo.Addrs))
+	for _, a := range nodeInfo.Addrs {
+		glb.Infof("    %s/p2p/%s", a, nodeInfo.ID.String())
+	}
+}
@@ -0,0 +1,40 @@
+package node_cmd
+
+import (
+	"encoding/hex"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/spf13/cobra"
+)
+
+func initChainControlledCmd() *cobra.Command {
+	chainControlledCmd := &cobra.Command{
+		Use:   "chain_controlled <chain ID hex-encoded>",
+		Short: `returns all outputs locked in the chain (with chain lock) from the latest reliable branch`,
+		Args:  cobra.ExactArgs(1),
+		Run:   runChainControlledCmd,
+	}
+	chainControlledCmd.InitDefaultHelpCmd()
+	return chainControlledCmd
+}
+
+func runChainControlledCmd(_ *cobra.Command, args []string) {
+	glb.InitLedgerFromNode()
+
+	chainID, err := ledger.ChainIDFromHexString(args[0])
+	glb.AssertNoError(err)
+
+	outs, lrbid, err := glb.GetClient().GetChainControlledOutputs(chainID)
+	glb.AssertNoError(err)
+
+	glb.PrintLRB(lrbid)
+	glb.Infof("%d outputs controlled by the chain %s", len(outs), chainID.String())
+	for i, o := range outs {
+		glb.Infof("-- output %d --", i)
+		glb.Infof(o.String())
+		glb.Verbosef("Raw bytes: %s", hex.EncodeToString(o.Output.Bytes()))
+	}
+	glb.Infof("TOTALS:")
+	displayTotals(outs)
+}
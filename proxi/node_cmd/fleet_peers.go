@@ -0,0 +1,59 @@
+package node_cmd
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/lunfardo314/proxima/api"
+	"github.com/lunfardo314/proxima/api/client"
+	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	fleetPeersOutFile   string
+	fleetPeersEndpoints []string
+)
+
+func initFleetPeersCmd() *cobra.Command {
+	fleetPeersCmd := &cobra.Command{
+		Use:   "fleet_peers",
+		Short: `collects alive peers of the node and, optionally, of other nodes of the fleet into the JSON file`,
+		Args:  cobra.NoArgs,
+		Run:   runFleetPeersCmd,
+	}
+	fleetPeersCmd.PersistentFlags().StringVarP(&fleetPeersOutFile, "out", "o", "fleet_peers.json", "output file")
+	fleetPeersCmd.PersistentFlags().StringSliceVarP(&fleetPeersEndpoints, "endpoints", "e", nil, "API endpoints of other nodes to include, comma separated")
+
+	fleetPeersCmd.InitDefaultHelpCmd()
+	return fleetPeersCmd
+}
+
+func runFleetPeersCmd(_ *cobra.Command, _ []string) {
+	glb.InitLedgerFromNode()
+
+	fleetPeers := api.NewFleetPeers()
+	peersInfo, err := glb.GetClient().GetPeersInfo()
+	glb.AssertNoError(err)
+	fleetPeers.AddPeersInfo(peersInfo)
+
+	var timeout []time.Duration
+	if timeoutSec := viper.GetInt("api.timeout_sec"); timeoutSec > 0 {
+		timeout = []time.Duration{time.Duration(timeoutSec) * time.Second}
+	}
+	for _, endpoint := range fleetPeersEndpoints {
+		if peersInfo, err = client.NewWithGoogleDNS(endpoint, timeout...).GetPeersInfo(); err != nil {
+			glb.Infof("failed to retrieve peers info from %s: %v", endpoint, err)
+			continue
+		}
+		fleetPeers.AddPeersInfo(peersInfo)
+	}
+
+	data, err := json.MarshalIndent(fleetPeers, "", "  ")
+	glb.AssertNoError(err)
+	err = os.WriteFile(fleetPeersOutFile, data, 0666)
+	glb.AssertNoError(err)
+	glb.Infof("peers of %d nodes with %d links saved to %s", len(fleetPeers.Nodes), fleetPeers.NumEdges(), fleetPeersOutFile)
+}
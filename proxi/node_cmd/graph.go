@@ -0,0 +1,55 @@
+package node_cmd
+
+import (
+	"errors"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/lunfardo314/proxima/util/graphviz"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphMaxVertices int
+	graphFormat      string
+	graphOutFile     string
+)
+
+func initGraphCmd() *cobra.Command {
+	graphCmd := &cobra.Command{
+		Use:   "graph <txid_hex>",
+		Short: `retrieves past cone of the transaction from the memDAG of the node and saves it as a graph`,
+		Args:  cobra.ExactArgs(1),
+		Run:   runGraphCmd,
+	}
+	graphCmd.PersistentFlags().IntVar(&graphMaxVertices, "max", 500, "maximum number of vertices in the graph")
+	graphCmd.PersistentFlags().StringVar(&graphFormat, "format", graphviz.FormatDOT, "output format: 'gv' or 'svg'. 'svg' requires graphviz installed")
+	graphCmd.PersistentFlags().StringVarP(&graphOutFile, "out", "o", "pastcone", "output file name without extension")
+
+	graphCmd.InitDefaultHelpCmd()
+	return graphCmd
+}
+
+func runGraphCmd(_ *cobra.Command, args []string) {
+	glb.Assertf(graphFormat == graphviz.FormatDOT || graphFormat == graphviz.FormatSVG,
+		"wrong format '%s'. Must be '%s' or '%s'", graphFormat, graphviz.FormatDOT, graphviz.FormatSVG)
+	glb.InitLedgerFromNode()
+
+	txid, err := ledger.TransactionIDFromHexString(args[0])
+	glb.AssertNoError(err)
+	glb.Infof("transaction ID: %s", txid.String())
+
+	dot, incomplete, err := glb.GetClient().GetPastConeGraph(txid, graphMaxVertices)
+	glb.AssertNoError(err)
+	if incomplete != nil {
+		glb.Infof("warning: graph is incomplete: %v", incomplete)
+	}
+
+	err = graphviz.SaveDOTAs(dot, graphOutFile, graphFormat)
+	if errors.Is(err, graphviz.ErrGraphvizNotInstalled) {
+		glb.Infof("%v", err)
+		return
+	}
+	glb.AssertNoError(err)
+	glb.Infof("past cone graph saved to %s.%s", graphOutFile, graphFormat)
+}
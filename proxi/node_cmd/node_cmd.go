@@ -43,6 +43,7 @@ func Init() *cobra.Command {
 	nodeCmd.AddCommand(
 		initGetOutputsCmd(),
 		initGetChainOutputCmd(),
+		initChainControlledCmd(),
 		initCompactOutputsCmd(),
 		initBalanceCmd(),
 		initTransferCmd(),
@@ -51,12 +52,16 @@ func Init() *cobra.Command {
 		initDeleteChainCmd(),
 		initChainsCmd(),
 		initNodeInfoCmd(),
+		initNodeAddrsCmd(),
 		seq_cmd.Init(),
 		initScoreCmd(),
+		initGraphCmd(),
 		initSeqSetupCmd(),
 		initSyncInfoCmd(),
 		initPeersInfoCmd(),
+		initFleetPeersCmd(),
 		initReliableBranchCmd(),
+		initTailCmd(),
 		//initInflateTokensCmd(),
 		initInflateChainCmd(),
 	)
@@ -1,6 +1,8 @@
 package node_cmd
 
 import (
+	"time"
+
 	"github.com/lunfardo314/proxima/proxi/glb"
 	"github.com/spf13/cobra"
 )
@@ -23,6 +25,10 @@ func runSyncInfoCmd(_ *cobra.Command, _ []string) {
 	syncInfo, err := glb.GetClient().GetSyncInfo()
 	glb.AssertNoError(err)
 	glb.Infof("  node synced: %v", syncInfo.Synced)
+	glb.Infof("  current slot: %d", syncInfo.NowSlot)
+	glb.Infof("  latest committed slot: %d (%d slots behind)", syncInfo.LatestCommittedSlot, syncInfo.SlotsBehind)
+	glb.Infof("  latest healthy slot: %d", syncInfo.LatestHealthySlot)
+	glb.Infof("  latest branch age: %v", time.Duration(syncInfo.LatestBranchAgeMs)*time.Millisecond)
 	//glb.Infof("  in the sync window: %v", syncInfo.InSyncWindow)
 	//glb.Infof("  activity by sequencer:")
 	//sorted := util.KeysSorted(syncInfo.PerSequencer, func(k1, k2 ledger.ChainID) bool {
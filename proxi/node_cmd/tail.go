@@ -0,0 +1,89 @@
+package node_cmd
+
+import (
+	"time"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/multistate"
+	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/lunfardo314/proxima/util"
+	"github.com/lunfardo314/proxima/util/set"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tailSequencer string
+	tailPeriod    time.Duration
+)
+
+func initTailCmd() *cobra.Command {
+	tailCmd := &cobra.Command{
+		Use:   "tail",
+		Short: `continuously displays new branches as they are committed by the node, like 'tail -f'`,
+		Args:  cobra.NoArgs,
+		Run:   runTailCmd,
+	}
+	tailCmd.PersistentFlags().StringVarP(&tailSequencer, "sequencer", "q", "", "display branches of the sequencer only (chain ID hex encoded)")
+	tailCmd.PersistentFlags().DurationVarP(&tailPeriod, "period", "p", 2*time.Second, "polling period")
+
+	tailCmd.InitDefaultHelpCmd()
+	return tailCmd
+}
+
+// runTailCmd polls branches slot by slot, starting from the latest committed slot, and displays each new branch.
+// The node API does not provide new branch event stream, so polling is the only mode
+func runTailCmd(_ *cobra.Command, _ []string) {
+	glb.InitLedgerFromNode()
+
+	var seqID *ledger.ChainID
+	if tailSequencer != "" {
+		id, err := ledger.ChainIDFromHexString(tailSequencer)
+		glb.AssertNoError(err)
+		seqID = &id
+		glb.Infof("displaying branches of sequencer %s only", seqID.StringShort())
+	}
+	glb.Infof("polling new branches every %v. Press Ctrl-C to stop", tailPeriod)
+
+	var slot ledger.Slot
+	started := false
+	// branches already displayed in the current slot. New branches may be committed in the slot until it is passed
+	displayed := set.New[ledger.TransactionID]()
+	for {
+		var branches map[ledger.TransactionID]*multistate.RootRecord
+		var latestSlot ledger.Slot
+		var err error
+		if started {
+			branches, latestSlot, err = glb.GetClient().GetBranchesInSlot(slot)
+		} else {
+			branches, latestSlot, err = glb.GetClient().GetBranchesInSlot()
+			slot = latestSlot
+		}
+		if err != nil {
+			glb.Infof("error while polling branches in slot %d: %v", slot, err)
+			time.Sleep(tailPeriod)
+			continue
+		}
+		started = true
+
+		branchIDs := util.KeysSorted(branches, func(id1, id2 ledger.TransactionID) bool {
+			return id1.Timestamp().Before(id2.Timestamp())
+		})
+		for _, branchID := range branchIDs {
+			if displayed.Contains(branchID) {
+				continue
+			}
+			displayed.Insert(branchID)
+			if rr := branches[branchID]; seqID == nil || rr.SequencerID == *seqID {
+				glb.Infof("slot %d, branch: %s, sequencer: %s, coverage: %s, supply: %s",
+					branchID.Slot(), branchID.StringShort(), rr.SequencerID.StringShort(), util.Th(rr.LedgerCoverage), util.Th(rr.Supply))
+			}
+		}
+		if slot < latestSlot {
+			// catch up without waiting
+			slot++
+			displayed = set.New[ledger.TransactionID]()
+			continue
+		}
+		time.Sleep(tailPeriod)
+	}
+}
@@ -21,8 +21,9 @@ import (
 )
 
 var (
-	fname     string
-	batchSize int
+	fname          string
+	batchSize      int
+	parallelVerify bool
 )
 
 func initRestoreCmd() *cobra.Command {
@@ -41,6 +42,10 @@ func initRestoreCmd() *cobra.Command {
 	err = viper.BindPFlag("batch_size", restoreCmd.PersistentFlags().Lookup("batch_size"))
 	glb.AssertNoError(err)
 
+	restoreCmd.PersistentFlags().BoolVarP(&parallelVerify, "parallel_verify", "p", false, "verify trie partitions concurrently before the final root check")
+	err = viper.BindPFlag("parallel_verify", restoreCmd.PersistentFlags().Lookup("parallel_verify"))
+	glb.AssertNoError(err)
+
 	restoreCmd.InitDefaultHelpCmd()
 	return restoreCmd
 }
@@ -137,6 +142,14 @@ func runRestoreCmd(_ *cobra.Command, args []string) {
 	err = batch.Commit()
 	glb.AssertNoError(err)
 
+	if parallelVerify {
+		glb.Infof("verifying trie partitions concurrently..")
+		verifyStart := time.Now()
+		err = multistate.VerifyTrieIntegrity(stateStore, lastRoot, true)
+		glb.AssertNoError(err)
+		glb.Infof("trie integrity verified in %v", time.Since(verifyStart))
+	}
+
 	glb.Assertf(ledger.CommitmentModel.EqualCommitments(lastRoot, kvStream.RootRecord.Root),
 		"inconsistency: final root %s is not equal to the root in the root record %s",
 		lastRoot.String(), kvStream.RootRecord.Root.String())
@@ -0,0 +1,90 @@
+package tx_cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lunfardo314/proxima/ledger/transaction"
+	"github.com/lunfardo314/proxima/proxi/glb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inFile  string
+	outFile string
+)
+
+const hexFileExtension = ".hex"
+
+func initConvertCmd() *cobra.Command {
+	convertCmd := &cobra.Command{
+		Use:   "convert",
+		Short: "converts raw transaction between hex and binary file formats",
+		Long: `converts raw transaction between hex and binary file formats. 
+File with the '.hex' extension is hex-encoded, any other file is binary. 
+Input and output must be in different formats. Transaction is parsed before it is written.
+Example: proxi tx convert --in=tx.hex --out=tx.bin`,
+		Args: cobra.NoArgs,
+		Run:  runConvertCmd,
+	}
+	convertCmd.Flags().StringVar(&inFile, "in", "", "input file")
+	convertCmd.Flags().StringVar(&outFile, "out", "", "output file")
+	_ = convertCmd.MarkFlagRequired("in")
+	_ = convertCmd.MarkFlagRequired("out")
+
+	convertCmd.InitDefaultHelpCmd()
+	return convertCmd
+}
+
+func runConvertCmd(_ *cobra.Command, _ []string) {
+	glb.FileMustExist(inFile)
+	glb.FileMustNotExist(outFile)
+
+	data, err := os.ReadFile(inFile)
+	glb.AssertNoError(err)
+
+	inHex, outHex := isHexFile(inFile), isHexFile(outFile)
+	glb.Assertf(inHex != outHex, "input and output must be in different formats: one file with '%s' extension, another binary", hexFileExtension)
+
+	out, txid, err := convertTx(data, inHex)
+	glb.AssertNoError(err)
+
+	err = os.WriteFile(outFile, out, 0666)
+	glb.AssertNoError(err)
+	glb.Infof("transaction %s has been converted from %s to %s format and saved to the file '%s'",
+		txid, formatName(inHex), formatName(outHex), outFile)
+}
+
+func isHexFile(fname string) bool {
+	return strings.EqualFold(filepath.Ext(fname), hexFileExtension)
+}
+
+func formatName(isHex bool) string {
+	if isHex {
+		return "hex"
+	}
+	return "binary"
+}
+
+// convertTx converts hex-encoded transaction to binary or vice versa. Returns converted data and transaction ID
+func convertTx(data []byte, fromHex bool) ([]byte, string, error) {
+	txBytes := data
+	if fromHex {
+		var err error
+		if txBytes, err = hex.DecodeString(string(bytes.TrimSpace(data))); err != nil {
+			return nil, "", fmt.Errorf("input is not a valid hex: %w", err)
+		}
+	}
+	tx, err := transaction.FromBytes(txBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("input is not a valid transaction: %w", err)
+	}
+	if fromHex {
+		return txBytes, tx.IDString(), nil
+	}
+	return []byte(hex.EncodeToString(txBytes)), tx.IDString(), nil
+}
@@ -0,0 +1,60 @@
+package tx_cmd
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/ledger/txbuilder"
+	"github.com/lunfardo314/proxima/multistate"
+	"github.com/lunfardo314/proxima/util/testutil"
+	"github.com/lunfardo314/unitrie/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	privKey := ledger.InitWithTestingLedgerIDData()
+	addr := ledger.AddressED25519FromPrivateKey(testutil.GetTestingPrivateKey(1))
+	stateStore := common.NewInMemoryKVStore()
+	multistate.InitStateStore(*ledger.L().ID, stateStore)
+	txBytes, txid, err := txbuilder.DistributeInitialSupplyExt(stateStore, privKey, []ledger.LockBalance{
+		{Lock: addr, Balance: 1_000_000},
+	})
+	require.NoError(t, err)
+
+	t.Run("binary to hex and back", func(t *testing.T) {
+		hexData, id, err := convertTx(txBytes, false)
+		require.NoError(t, err)
+		require.EqualValues(t, txid.String(), id)
+		require.EqualValues(t, hex.EncodeToString(txBytes), string(hexData))
+
+		binData, id, err := convertTx(hexData, true)
+		require.NoError(t, err)
+		require.EqualValues(t, txid.String(), id)
+		require.EqualValues(t, txBytes, binData)
+	})
+	t.Run("hex with whitespace", func(t *testing.T) {
+		binData, _, err := convertTx([]byte(" "+hex.EncodeToString(txBytes)+"\n"), true)
+		require.NoError(t, err)
+		require.EqualValues(t, txBytes, binData)
+	})
+	t.Run("invalid input", func(t *testing.T) {
+		_, _, err := convertTx([]byte("not a hex"), true)
+		require.Error(t, err)
+		t.Logf("expected error: %v", err)
+
+		_, _, err = convertTx([]byte("0123abcd"), true)
+		require.Error(t, err)
+		t.Logf("expected error: %v", err)
+
+		_, _, err = convertTx(txBytes[:len(txBytes)/2], false)
+		require.Error(t, err)
+		t.Logf("expected error: %v", err)
+	})
+	t.Run("file formats", func(t *testing.T) {
+		require.True(t, isHexFile("tx.hex"))
+		require.True(t, isHexFile("dir/tx.HEX"))
+		require.False(t, isHexFile("tx.bin"))
+		require.False(t, isHexFile("tx"))
+	})
+}
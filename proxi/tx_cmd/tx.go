@@ -0,0 +1,20 @@
+package tx_cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Init() *cobra.Command {
+	txCmd := &cobra.Command{
+		Use:   "tx [<subcommand>]",
+		Short: "specifies subcommands for offline manipulation of raw transactions",
+		Args:  cobra.NoArgs,
+		Run:   func(cmd *cobra.Command, _ []string) { _ = cmd.Help() },
+	}
+
+	txCmd.InitDefaultHelpCmd()
+	txCmd.AddCommand(
+		initConvertCmd(),
+	)
+	return txCmd
+}
@@ -119,19 +119,19 @@ func (b *InputBacklog) checkAndReferenceCandidate(wOut vertex.WrappedOutput) boo
 		b.TraceTx(&wOut.VID.ID, "[%s] backlog::checkAndReferenceCandidate: is branch", b.SequencerName, wOut.Index)
 		return false
 	}
-	if !wOut.VID.Reference() {
+	if !wOut.VID.Reference(TraceTag) {
 		b.TraceTx(&wOut.VID.ID, "[%s] backlog::checkAndReferenceCandidate: failed to reference", b.SequencerName, wOut.Index)
 		return false
 	}
 	if wOut.VID.GetTxStatus() == vertex.Bad {
-		wOut.VID.UnReference()
+		wOut.VID.UnReference(TraceTag)
 		b.TraceTx(&wOut.VID.ID, "[%s] backlog::checkAndReferenceCandidate: is BAD", b.SequencerName, wOut.Index)
 		return false
 	}
 	o, err := wOut.VID.OutputAt(wOut.Index)
 	if err != nil {
 		b.TraceTx(&wOut.VID.ID, "[%s] backlog::checkAndReferenceCandidate: OutputAt failed for #%d: %v", b.SequencerName, wOut.Index, err)
-		wOut.VID.UnReference()
+		wOut.VID.UnReference(TraceTag)
 		return false
 	}
 	if o != nil {
@@ -139,7 +139,7 @@ func (b *InputBacklog) checkAndReferenceCandidate(wOut vertex.WrappedOutput) boo
 			// filter out all chain constrained outputs
 			// TODO must be revisited with delegated accounts (delegation-locked on the current sequencer)
 			b.TraceTx(&wOut.VID.ID, "[%s] backlog::checkAndReferenceCandidate: #%d is chain-constrained", b.SequencerName, wOut.Index)
-			wOut.VID.UnReference()
+			wOut.VID.UnReference(TraceTag)
 			return false
 		}
 	}
@@ -229,7 +229,7 @@ func (b *InputBacklog) purgeBacklog(ttl time.Duration) int {
 	}
 
 	for _, wOut := range toDelete {
-		wOut.VID.UnReference()
+		wOut.VID.UnReference(TraceTag)
 		delete(b.outputs, wOut)
 		b.TraceTx(&wOut.VID.ID, "[%s] output #%d has been deleted from the backlog", b.SequencerName, wOut.Index)
 	}
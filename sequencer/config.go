@@ -29,7 +29,10 @@ type (
 )
 
 const (
-	DefaultMaxTagAlongInputs  = 20
+	// MaxTagAlongInputsProtocol maximum number of tag-along inputs allowed by the 256 inputs limit
+	// of the transaction. Chain and stem inputs are not counted
+	MaxTagAlongInputsProtocol = 254
+	DefaultMaxTagAlongInputs  = MaxTagAlongInputsProtocol
 	MinimumBacklogTTLSlots    = 10
 	MinimumMilestonesTTLSlots = 100 // 10
 )
@@ -116,8 +119,8 @@ func WithPace(pace int) ConfigOption {
 func WithMaxTagAlongInputs(maxInputs int) ConfigOption {
 	return func(o *ConfigOptions) {
 		if maxInputs >= 1 {
-			if maxInputs > 254 {
-				o.MaxTagAlongInputs = 254
+			if maxInputs > MaxTagAlongInputsProtocol {
+				o.MaxTagAlongInputs = MaxTagAlongInputsProtocol
 			} else {
 				o.MaxTagAlongInputs = maxInputs
 			}
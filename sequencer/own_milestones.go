@@ -10,7 +10,11 @@ import (
 	"github.com/lunfardo314/proxima/util/set"
 )
 
-const ownMilestonePurgePeriod = time.Second
+const (
+	ownMilestonePurgePeriod = time.Second
+	// ownMilestonesHolder is the name of the holder of vertex references, see vertex.ReferenceHolders
+	ownMilestonesHolder = "own milestones"
+)
 
 func (seq *Sequencer) FutureConeOwnMilestonesOrdered(rootOutput vertex.WrappedOutput, targetTs ledger.Time) []vertex.WrappedOutput {
 	seq.ownMilestonesMutex.RLock()
@@ -82,7 +86,7 @@ func (seq *Sequencer) AddOwnMilestone(vid *vertex.WrappedTx) {
 		return
 	}
 
-	vid.MustReference()
+	vid.MustReference(ownMilestonesHolder)
 
 	withTime := outputsWithTime{
 		consumed: set.New[vertex.WrappedOutput](),
@@ -121,7 +125,7 @@ func (seq *Sequencer) purgeOwnMilestones(ttl time.Duration) (int, int) {
 	}
 
 	for _, vid := range toDelete {
-		vid.UnReference()
+		vid.UnReference(ownMilestonesHolder)
 		delete(seq.ownMilestones, vid)
 	}
 	return len(toDelete), len(seq.ownMilestones)
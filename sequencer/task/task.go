@@ -15,6 +15,7 @@ import (
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
 	"github.com/lunfardo314/proxima/ledger/transaction"
+	"github.com/lunfardo314/proxima/ledger/txbuilder"
 	"github.com/lunfardo314/proxima/sequencer/backlog"
 	"github.com/lunfardo314/proxima/util"
 	"github.com/spf13/viper"
@@ -67,6 +68,8 @@ type (
 		strategy *Strategy
 		Name     string
 		Msg      string // how proposer ended. For debugging
+		// outputCache is shared among transactions made by the proposer. Used only from the proposer's goroutine
+		outputCache txbuilder.OutputCache
 	}
 
 	// ProposalGenerator returns incremental attacher as draft transaction or
@@ -189,9 +192,10 @@ func (p *proposal) String() string {
 func (t *Task) startProposers() {
 	for _, s := range allProposingStrategies() {
 		p := &Proposer{
-			Task:     t,
-			strategy: s,
-			Name:     t.Name + "-" + s.Name,
+			Task:        t,
+			strategy:    s,
+			Name:        t.Name + "-" + s.Name,
+			outputCache: make(txbuilder.OutputCache),
 		}
 		t.proposersWG.Add(1)
 		go func() {
@@ -229,23 +233,34 @@ func (t *Task) InsertTagAlongInputs(a *attacher.IncrementalAttacher) (numInserte
 	})
 	t.Tracef(TraceTagInsertTagAlongInputs, "%s. Pre-selected: %d", a.Name, len(preSelected))
 
-	for _, wOut := range preSelected {
-		select {
-		case <-t.ctx.Done():
-			return
-		default:
-		}
+	return insertUpTo(t.ctx, preSelected, t.MaxTagAlongInputs(), func(wOut vertex.WrappedOutput) bool {
 		t.TraceTx(&wOut.VID.ID, "InsertTagAlongInputs: pre-selected #%d", wOut.Index)
-		if success, err := a.InsertTagAlongInput(wOut); success {
-			numInserted++
+		success, err := a.InsertTagAlongInput(wOut)
+		if success {
 			t.Tracef(TraceTagInsertTagAlongInputs, "%s. Inserted %s", a.Name, wOut.IDShortString)
 			t.TraceTx(&wOut.VID.ID, "InsertTagAlongInputs %s. Inserted #%d", a.Name, wOut.Index)
 		} else {
 			t.Tracef(TraceTagInsertTagAlongInputs, "%s. Failed to insert %s: '%v'", a.Name, wOut.IDShortString, err)
 			t.TraceTx(&wOut.VID.ID, "InsertTagAlongInputs %s. Failed to insert #%d: '%v'", a.Name, wOut.Index, err)
 		}
-		if a.NumInputs() >= t.MaxTagAlongInputs() {
+		return success
+	})
+}
+
+// insertUpTo tries to insert outputs one by one until maxInputs are inserted successfully.
+// The cap applies to tag-along inputs only, independently of the protocol limit on total inputs
+func insertUpTo(ctx context.Context, outs []vertex.WrappedOutput, maxInputs int, insert func(wOut vertex.WrappedOutput) bool) (numInserted int) {
+	for _, wOut := range outs {
+		if numInserted >= maxInputs {
+			return
+		}
+		select {
+		case <-ctx.Done():
 			return
+		default:
+		}
+		if insert(wOut) {
+			numInserted++
 		}
 	}
 	return
@@ -0,0 +1,48 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lunfardo314/proxima/core/vertex"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertUpTo(t *testing.T) {
+	outs := make([]vertex.WrappedOutput, 10)
+	for i := range outs {
+		outs[i].Index = byte(i)
+	}
+	t.Run("stops at cap", func(t *testing.T) {
+		attempted := 0
+		n := insertUpTo(context.Background(), outs, 3, func(_ vertex.WrappedOutput) bool {
+			attempted++
+			return true
+		})
+		require.EqualValues(t, 3, n)
+		require.EqualValues(t, 3, attempted)
+	})
+	t.Run("failed insertions do not count", func(t *testing.T) {
+		attempted := 0
+		n := insertUpTo(context.Background(), outs, 3, func(wOut vertex.WrappedOutput) bool {
+			attempted++
+			return wOut.Index%2 == 0
+		})
+		require.EqualValues(t, 3, n)
+		require.EqualValues(t, 5, attempted)
+	})
+	t.Run("cap above available", func(t *testing.T) {
+		n := insertUpTo(context.Background(), outs, 254, func(_ vertex.WrappedOutput) bool {
+			return true
+		})
+		require.EqualValues(t, len(outs), n)
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		n := insertUpTo(ctx, outs, 3, func(_ vertex.WrappedOutput) bool {
+			return true
+		})
+		require.EqualValues(t, 0, n)
+	})
+}
@@ -1,12 +1,24 @@
 package tests
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/dominikbraun/graph"
+	"github.com/dominikbraun/graph/draw"
+	"github.com/lunfardo314/proxima/api"
 	"github.com/lunfardo314/proxima/core/attacher"
+	"github.com/lunfardo314/proxima/core/memdag"
+	"github.com/lunfardo314/proxima/core/txmetadata"
 	"github.com/lunfardo314/proxima/core/vertex"
 	"github.com/lunfardo314/proxima/core/workflow"
 	"github.com/lunfardo314/proxima/global"
@@ -18,7 +30,9 @@ import (
 	"github.com/lunfardo314/proxima/sequencer"
 	"github.com/lunfardo314/proxima/txstore"
 	"github.com/lunfardo314/proxima/util"
+	"github.com/lunfardo314/proxima/util/set"
 	"github.com/lunfardo314/proxima/util/testutil"
+	"github.com/lunfardo314/proxima/util/testutil/inittest"
 	"github.com/lunfardo314/unitrie/common"
 	"github.com/stretchr/testify/require"
 )
@@ -1290,3 +1304,1132 @@ func TestSeqChains(t *testing.T) {
 		)
 	})
 }
+
+func TestAttachTimeout(t *testing.T) {
+	const (
+		nConflicts            = 2
+		nChains               = 2
+		howLongConflictChains = 2
+		howLongSeqChains      = 3
+		attachTimeout         = 500 * time.Millisecond
+	)
+	testData := initLongConflictTestData(t, nConflicts, nChains, howLongConflictChains)
+	testData.makeSeqBeginnings(false)
+	testData.makeSeqChains(howLongSeqChains)
+
+	testData.txBytesAttach()
+	// predecessors of the last milestone are neither attached nor stored, so it can't become solid
+	txSequence := testData.seqChain[0]
+	var errAttach error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	start := time.Now()
+	vid := attacher.AttachTransaction(txSequence[len(txSequence)-1], testData.wrk,
+		attacher.WithAttachTimeout(attachTimeout),
+		attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, err error) {
+			errAttach = err
+			wg.Done()
+		}))
+	wg.Wait()
+
+	require.True(t, errors.Is(errAttach, attacher.ErrAttachTimeout))
+	require.True(t, time.Since(start) >= attachTimeout)
+	require.EqualValues(t, vertex.Bad.String(), vid.GetTxStatus().String())
+	testData.stopAndWait()
+}
+
+func TestConflictError(t *testing.T) {
+	attach := func(t *testing.T, testData *longConflictTestData, tx *transaction.Transaction) *vertex.WrappedTx {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		vid := attacher.AttachTransaction(tx, testData.wrk, attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, _ error) {
+			wg.Done()
+		}))
+		wg.Wait()
+		require.EqualValues(t, vertex.Good.String(), vid.GetTxStatus().String())
+		return vid
+	}
+	t.Run("past cone", func(t *testing.T) {
+		const nConflicts = 2
+		testData := initLongConflictTestData(t, nConflicts, nConflicts, 2)
+		testData.makeSeqBeginnings(false)
+		testData.txBytesAttach()
+
+		seqTx := testData.seqChain[0][0]
+		vidSeq := attach(t, testData, seqTx)
+
+		targetTs := seqTx.Timestamp().AddTicks(ledger.TransactionPaceSequencer())
+		a, err := attacher.NewIncrementalAttacher("test", testData.wrk, targetTs, vidSeq.SequencerWrappedOutput())
+		require.NoError(t, err)
+
+		// terminal outputs of conflict chains conflict with each other
+		tagAlong := make([]vertex.WrappedOutput, nConflicts)
+		for i, o := range testData.terminalOutputs {
+			txid := o.ID.TransactionID()
+			vid := testData.wrk.GetVertex(&txid)
+			require.True(t, vid != nil)
+			tagAlong[i] = vertex.WrappedOutput{VID: vid, Index: o.ID.Index()}
+		}
+		ok, err := a.InsertTagAlongInput(tagAlong[0])
+		require.True(t, ok)
+		require.NoError(t, err)
+
+		ok, err = a.InsertTagAlongInput(tagAlong[1])
+		require.False(t, ok)
+		util.RequireErrorWith(t, err, "conflicts with another consumer")
+		var conflictErr *attacher.ConflictError
+		require.True(t, errors.As(err, &conflictErr))
+		// conflicting transactions at the beginning of conflict chains 0 and 1 consume the fork output
+		conflictingTx0, err := transaction.FromBytes(testData.txBytesConflicting[0])
+		require.NoError(t, err)
+		conflictingTx1, err := transaction.FromBytes(testData.txBytesConflicting[1])
+		require.NoError(t, err)
+		require.EqualValues(t, testData.forkOutput.ID, conflictErr.OutputID)
+		require.ElementsMatch(t, []ledger.TransactionID{*conflictingTx0.ID(), *conflictingTx1.ID()}, conflictErr.Consumers)
+
+		a.Close()
+		testData.stopAndWait()
+	})
+	t.Run("baseline", func(t *testing.T) {
+		testData := initLongConflictTestData(t, 1, 1, 1)
+		testData.makeSeqBeginnings(false)
+		testData.txBytesAttach()
+
+		seqTx := testData.seqChain[0][0]
+		vidSeq := attach(t, testData, seqTx)
+		branch := testData.makeBranch(seqTx.SequencerOutput().MustAsChainOutput(), testData.distributionBranchTx)
+		vidBranch := attach(t, testData, branch)
+
+		targetTs := ledger.L().ID.EnsurePostBranchConsolidationConstraintTimestamp(branch.Timestamp().AddTicks(ledger.TransactionPaceSequencer()))
+		a, err := attacher.NewIncrementalAttacher("test", testData.wrk, targetTs, vidBranch.SequencerWrappedOutput())
+		require.NoError(t, err)
+
+		// sequencer output of the milestone is consumed by the branch, i.e. it is not in the baseline state.
+		// The consumer is not known to the attacher, so the error does not list any
+		consumed := vidSeq.SequencerWrappedOutput()
+		ok, err := a.InsertTagAlongInput(consumed)
+		require.False(t, ok)
+		util.RequireErrorWith(t, err, "already consumed", "(double spend)")
+		var conflictErr *attacher.ConflictError
+		require.True(t, errors.As(err, &conflictErr))
+		require.EqualValues(t, *consumed.DecodeID(), conflictErr.OutputID)
+		require.EqualValues(t, 0, len(conflictErr.Consumers))
+
+		a.Close()
+		testData.stopAndWait()
+	})
+}
+
+func TestIncrementalAttacherUndoLastInput(t *testing.T) {
+	const nConflicts = 3
+	testData := initLongConflictTestData(t, nConflicts, nConflicts, 2)
+	testData.makeSeqBeginnings(false)
+	testData.txBytesAttach()
+
+	seqTx := testData.seqChain[0][0]
+	var wg sync.WaitGroup
+	wg.Add(1)
+	vidSeq := attacher.AttachTransaction(seqTx, testData.wrk, attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, _ error) {
+		wg.Done()
+	}))
+	wg.Wait()
+	require.EqualValues(t, vertex.Good.String(), vidSeq.GetTxStatus().String())
+
+	targetTs := seqTx.Timestamp().AddTicks(ledger.TransactionPaceSequencer())
+	a, err := attacher.NewIncrementalAttacher("test", testData.wrk, targetTs, vidSeq.SequencerWrappedOutput())
+	require.NoError(t, err)
+
+	// terminal outputs of conflict chains conflict with each other
+	tagAlong := make([]vertex.WrappedOutput, nConflicts)
+	for i, o := range testData.terminalOutputs {
+		txid := o.ID.TransactionID()
+		vid := testData.wrk.GetVertex(&txid)
+		require.True(t, vid != nil)
+		tagAlong[i] = vertex.WrappedOutput{VID: vid, Index: o.ID.Index()}
+	}
+	util.RequireErrorWith(t, a.UndoLastInput(), "not enabled")
+	a.EnableUndo()
+	require.Error(t, a.UndoLastInput())
+
+	numInputs, coverage := a.NumInputs(), a.AccumulatedCoverage()
+	numReferences := tagAlong[0].VID.NumReferences()
+	ok, err := a.InsertTagAlongInput(tagAlong[0])
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.EqualValues(t, numInputs+1, a.NumInputs())
+
+	ok, err = a.InsertTagAlongInput(tagAlong[1])
+	require.False(t, ok)
+	require.Error(t, err)
+
+	require.NoError(t, a.UndoLastInput())
+	require.EqualValues(t, numInputs, a.NumInputs())
+	require.EqualValues(t, coverage, a.AccumulatedCoverage())
+	require.EqualValues(t, numReferences, tagAlong[0].VID.NumReferences())
+	require.Error(t, a.UndoLastInput())
+
+	// after undo, previously conflicting input can be inserted
+	ok, err = a.InsertTagAlongInput(tagAlong[1])
+	require.True(t, ok)
+	require.NoError(t, err)
+
+	// inputs inserted before the endorsement can't be removed
+	seqTx1 := testData.seqChain[1][0]
+	wg.Add(1)
+	vidSeq1 := attacher.AttachTransaction(seqTx1, testData.wrk, attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, _ error) {
+		wg.Done()
+	}))
+	wg.Wait()
+	require.NoError(t, a.InsertEndorsement(vidSeq1))
+	require.Error(t, a.UndoLastInput())
+	require.EqualValues(t, numInputs+1, a.NumInputs())
+
+	a.Close()
+	testData.stopAndWait()
+}
+
+// stateStoreAccessCountingEnv is the attacher environment which counts accesses to the state store
+// and collects state readers requested by the attacher
+type stateStoreAccessCountingEnv struct {
+	*workflow.Workflow
+	store        *stateStoreAccessCounter
+	mutex        sync.Mutex
+	stateReaders map[ledger.TransactionID][]global.IndexedStateReader
+}
+
+type stateStoreAccessCounter struct {
+	global.StateStore
+	numAccess atomic.Int64
+}
+
+func (e *stateStoreAccessCountingEnv) StateStore() global.StateStore {
+	return e.store
+}
+
+func (e *stateStoreAccessCountingEnv) GetStateReaderForTheBranch(branch *ledger.TransactionID) global.IndexedStateReader {
+	ret := e.Workflow.GetStateReaderForTheBranch(branch)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.stateReaders[*branch] = append(e.stateReaders[*branch], ret)
+	return ret
+}
+
+func (s *stateStoreAccessCounter) Get(key []byte) []byte {
+	s.numAccess.Add(1)
+	return s.StateStore.Get(key)
+}
+
+func (s *stateStoreAccessCounter) Has(key []byte) bool {
+	s.numAccess.Add(1)
+	return s.StateStore.Has(key)
+}
+
+func (s *stateStoreAccessCounter) Iterator(prefix []byte) common.KVIterator {
+	s.numAccess.Add(1)
+	return s.StateStore.Iterator(prefix)
+}
+
+func (s *stateStoreAccessCounter) BatchedWriter() common.KVBatchedWriter {
+	s.numAccess.Add(1)
+	return s.StateStore.BatchedWriter()
+}
+
+func TestAttachTxIDOptions(t *testing.T) {
+	t.Run("do not load branch", func(t *testing.T) {
+		testData := initLongConflictTestData(t, 2, 2, 1)
+		testData.makeSeqBeginnings(false)
+		testData.txBytesAttach()
+
+		// the baseline state reader is already in the cache of the workflow.
+		// The first call creates it, the second returns the cached one
+		baselineID := testData.distributionBranchTxID
+		testData.wrk.GetStateReaderForTheBranch(&baselineID)
+		cachedReader := testData.wrk.GetStateReaderForTheBranch(&baselineID)
+
+		// attacher with the option must not touch the state store. Baseline branch is already in the memDAG
+		env := &stateStoreAccessCountingEnv{
+			Workflow:     testData.wrk,
+			store:        &stateStoreAccessCounter{StateStore: testData.wrk.StateStore()},
+			stateReaders: make(map[ledger.TransactionID][]global.IndexedStateReader),
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		vid := attacher.AttachTransaction(testData.seqChain[0][0], env,
+			attacher.WithDoNotLoadBranch,
+			attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, _ error) {
+				wg.Done()
+			}))
+		wg.Wait()
+		require.EqualValues(t, vertex.Good.String(), vid.GetTxStatus().String())
+		require.EqualValues(t, 0, env.store.numAccess.Load())
+
+		// past cone is still checked against the state of the baseline, which is read only through
+		// the state reader cached by the workflow
+		require.True(t, vid.BaselineBranch().ID == baselineID)
+		require.EqualValues(t, 1, len(env.stateReaders))
+		require.True(t, len(env.stateReaders[baselineID]) > 0)
+		for _, rdr := range env.stateReaders[baselineID] {
+			require.True(t, rdr == cachedReader)
+		}
+		testData.stopAndWait()
+	})
+	t.Run("load branch", func(t *testing.T) {
+		testData := initWorkflowTest(t, 1)
+		txid := *ledger.GenesisTransactionID()
+		require.True(t, testData.wrk.GetVertex(&txid) == nil)
+
+		vid := attacher.AttachTxID(txid, testData.wrk)
+		require.True(t, vid.IsVirtualTx())
+		require.EqualValues(t, vertex.Good.String(), vid.GetTxStatus().String())
+		testData.stopAndWait()
+	})
+	t.Run("pull non-branch", func(t *testing.T) {
+		testData := initLongConflictTestData(t, 2, 2, 1)
+		testData.txBytesToStore()
+
+		txids := make([]ledger.TransactionID, 2)
+		for i := range txids {
+			tx, err := transaction.FromBytes(testData.txBytesConflicting[i])
+			require.NoError(t, err)
+			txids[i] = *tx.ID()
+		}
+		vidNoPull := attacher.AttachTxID(txids[0], testData.wrk)
+		vidPull := attacher.AttachTxID(txids[1], testData.wrk, attacher.WithPullNonBranch)
+
+		// transaction is loaded from the store
+		deadline := time.Now().Add(2 * time.Second)
+		for vidPull.IsVirtualTx() && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		require.False(t, vidPull.IsVirtualTx())
+		require.True(t, vidNoPull.IsVirtualTx())
+		testData.stopAndWait()
+	})
+}
+
+func TestBranchWithNonZeroTick(t *testing.T) {
+	testData := initWorkflowTestWithConflicts(t, 1, 1, false)
+
+	// sequencer-flagged transaction with the stem output index set, but with non-zero tick
+	txb := txbuilder.NewTransactionBuilder()
+	_, err := txb.ConsumeOutputWithID(testData.forkOutput)
+	require.NoError(t, err)
+	txb.PutSignatureUnlock(0)
+	_, err = txb.ProduceOutput(ledger.NewOutput(func(o *ledger.Output) {
+		o.WithAmount(testData.forkOutput.Output.Amount())
+		o.WithLock(testData.addr)
+	}))
+	require.NoError(t, err)
+	ts := testData.forkOutput.Timestamp().AddTicks(ledger.TransactionPaceSequencer())
+	require.True(t, ts.Tick() != 0)
+	txb.TransactionData.Timestamp = ts
+	txb.TransactionData.SequencerOutputIndex = 0
+	txb.TransactionData.StemOutputIndex = 0
+	txb.TransactionData.InputCommitment = txb.InputCommitment()
+	txb.SignED25519(testData.privKey)
+
+	tx, err := transaction.FromBytes(txb.TransactionData.Bytes())
+	require.NoError(t, err)
+	require.True(t, tx.IsSequencerMilestone())
+	require.False(t, tx.IsBranchTransaction())
+
+	err = testData.wrk.TxIn(tx, workflow.WithSourceType(txmetadata.SourceTypePeer))
+	util.RequireErrorWith(t, err, "branch transaction must have tick 0")
+
+	vid := testData.wrk.GetVertex(tx.ID())
+	require.True(t, vid != nil)
+	require.EqualValues(t, vertex.Bad.String(), vid.GetTxStatus().String())
+
+	testData.stopAndWait()
+}
+
+func TestInclusionScore(t *testing.T) {
+	testData := initWorkflowTest(t, 1)
+	err := testData.wrk.EnsureLatestBranches()
+	require.NoError(t, err)
+	testData.stopAndWait()
+
+	store := testData.wrk.StateStore()
+	const numerator, denominator, slotSpan = 2, 3, 2
+
+	weak, strong, err := multistate.InclusionScore(store, &testData.distributionBranchTxID, numerator, denominator, slotSpan)
+	require.NoError(t, err)
+	t.Logf("weak: %d, strong: %d", weak, strong)
+
+	expected := api.CalcTxInclusionScore(testData.wrk.GetTxInclusion(&testData.distributionBranchTxID, slotSpan), numerator, denominator)
+	require.EqualValues(t, expected.WeakScore, weak)
+	require.EqualValues(t, expected.StrongScore, strong)
+	require.EqualValues(t, 100, strong)
+
+	_, _, err = multistate.InclusionScore(store, &testData.distributionBranchTxID, 3, 2, slotSpan)
+	util.RequireErrorWith(t, err, "wrong threshold fraction")
+}
+
+func TestExportTxSequence(t *testing.T) {
+	testData := initWorkflowTestWithConflicts(t, 1, 1, true)
+	for _, txBytes := range testData.txBytesConflicting {
+		_, err := attacher.AttachTransactionFromBytes(txBytes, testData.wrk)
+		require.NoError(t, err)
+	}
+	branches := multistate.FetchLatestBranches(testData.wrk.StateStore())
+	require.EqualValues(t, 1, len(branches))
+
+	chainOut := branches[0].SequencerOutput.MustAsChainOutput()
+	inTS := []ledger.Time{chainOut.Timestamp()}
+	for _, o := range testData.conflictingOutputs {
+		inTS = append(inTS, o.Timestamp())
+	}
+	ts := ledger.MaximumTime(inTS...).AddTicks(ledger.TransactionPaceSequencer())
+	ts = ledger.L().ID.EnsurePostBranchConsolidationConstraintTimestamp(ts)
+	txBytes, err := txbuilder.MakeSequencerTransaction(txbuilder.MakeSequencerTransactionParams{
+		SeqName:          "test",
+		ChainInput:       chainOut,
+		Timestamp:        ts,
+		AdditionalInputs: testData.conflictingOutputs,
+		PrivateKey:       testData.genesisPrivKey,
+	})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	vid, err := attacher.AttachTransactionFromBytes(txBytes, testData.wrk, attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, _ error) {
+		wg.Done()
+	}))
+	require.NoError(t, err)
+	wg.Wait()
+	require.True(t, vertex.Good == vid.GetTxStatus(), "%v", vid.GetError())
+
+	var buf bytes.Buffer
+	n, err := testData.wrk.ExportTxSequence([]ledger.TransactionID{vid.ID}, &buf)
+	require.NoError(t, err)
+	require.True(t, n >= 2)
+
+	// the order must be topological: all dependencies which are in the sequence must precede the transaction
+	exported := make([]*transaction.Transaction, 0)
+	positions := make(map[ledger.TransactionID]int)
+	err = workflow.ReadTxSequence(&buf, func(txBytes []byte) error {
+		tx, err := transaction.FromBytes(txBytes)
+		if err != nil {
+			return err
+		}
+		positions[*tx.ID()] = len(exported)
+		exported = append(exported, tx)
+		return nil
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, n, len(exported))
+	require.EqualValues(t, vid.ID, *exported[len(exported)-1].ID())
+
+	for i, tx := range exported {
+		tx.ForEachInput(func(_ byte, oid *ledger.OutputID) bool {
+			if pos, found := positions[oid.TransactionID()]; found {
+				require.True(t, pos < i)
+			}
+			return true
+		})
+		tx.ForEachEndorsement(func(_ byte, txid *ledger.TransactionID) bool {
+			if pos, found := positions[*txid]; found {
+				require.True(t, pos < i)
+			}
+			return true
+		})
+	}
+	for _, txBytesConflicting := range testData.txBytesConflicting {
+		txid, err := transaction.IDFromTransactionBytes(txBytesConflicting)
+		require.NoError(t, err)
+		_, found := positions[txid]
+		require.True(t, found)
+	}
+	testData.stopAndWait()
+}
+
+func TestTxBytesInWaitStatusAttached(t *testing.T) {
+	testData := initLongConflictTestData(t, 1, 1, 1)
+	testData.makeSeqBeginnings(false)
+	testData.txBytesAttach()
+
+	seqTx := testData.seqChain[0][0]
+	status, err := testData.wrk.TxBytesInWaitStatus(seqTx.Bytes(), 5*time.Second)
+	require.NoError(t, err)
+	require.EqualValues(t, vertex.Good.String(), status.String())
+
+	// already attached milestone -> status of the existing vertex is returned without waiting,
+	// even when timeout is too short for the attachment callback
+	status, err = testData.wrk.TxBytesInWaitStatus(seqTx.Bytes(), time.Nanosecond)
+	require.NoError(t, err)
+	require.EqualValues(t, vertex.Good.String(), status.String())
+	require.EqualValues(t, 0, testData.wrk.NumInFlightTx())
+
+	testData.stopAndWait()
+}
+
+func TestTxBytesInWaitStatusShortTimeout(t *testing.T) {
+	testData := initLongConflictTestData(t, 1, 1, 1)
+	testData.makeSeqBeginnings(false)
+	testData.txBytesAttach()
+
+	// timeout fires long before the attacher finishes. It must not interrupt attachment of the milestone
+	seqTx := testData.seqChain[0][0]
+	status, err := testData.wrk.TxBytesInWaitStatus(seqTx.Bytes(), time.Nanosecond)
+	require.NoError(t, err)
+	require.True(t, status != vertex.Bad)
+
+	vid := testData.wrk.GetVertex(seqTx.ID())
+	require.True(t, vid != nil)
+	require.Eventually(t, func() bool { return vid.GetTxStatus() == vertex.Good }, 5*time.Second, 10*time.Millisecond)
+
+	testData.stopAndWait()
+}
+
+func TestFutureTimestampTolerance(t *testing.T) {
+	testData := initWorkflowTestWithConflicts(t, 1, 1, false)
+	tolerance := testData.wrk.MaxDurationInTheFuture()
+	require.EqualValues(t, 10*ledger.SlotDuration(), tolerance)
+	const margin = 500 * time.Millisecond
+
+	makeTx := func(ts ledger.Time) *transaction.Transaction {
+		td := txbuilder.NewTransferData(testData.privKey, testData.addr, ts)
+		td.WithAmount(testData.forkOutput.Output.Amount()).
+			WithTargetLock(testData.addr).
+			MustWithInputs(testData.forkOutput)
+		txBytes, err := txbuilder.MakeSimpleTransferTransaction(td)
+		require.NoError(t, err)
+		tx, err := transaction.FromBytes(txBytes)
+		require.NoError(t, err)
+		return tx
+	}
+
+	// beyond tolerance -> rejected
+	txFar := makeTx(ledger.TimeFromClockTime(time.Now().Add(tolerance + margin)))
+	err := testData.wrk.TxIn(txFar, workflow.WithSourceType(txmetadata.SourceTypeAPI))
+	util.RequireErrorWith(t, err, "timestamp too far in future")
+
+	// within tolerance -> accepted
+	txNear := makeTx(ledger.TimeFromClockTime(time.Now().Add(tolerance - margin)))
+	err = testData.wrk.TxIn(txNear, workflow.WithSourceType(txmetadata.SourceTypeAPI))
+	require.NoError(t, err)
+
+	testData.stopAndWait()
+}
+
+func TestBranchStateDiff(t *testing.T) {
+	testData := initWorkflowTest(t, 1)
+	testData.stopAndWait()
+
+	store := testData.wrk.StateStore()
+	genesisBranchID := *ledger.GenesisTransactionID()
+
+	diff, err := multistate.BranchStateDiff(store, genesisBranchID, testData.distributionBranchTxID)
+	require.NoError(t, err)
+	t.Logf("diff genesis -> distribution:\n%s", diff.Lines("   ").String())
+
+	// distribution transaction consumes genesis outputs and produces all its outputs
+	require.EqualValues(t, testData.distributionBranchTx.NumInputs(), len(diff.Removed))
+	require.EqualValues(t, testData.distributionBranchTx.NumProducedOutputs(), len(diff.Added))
+	for _, o := range diff.Added {
+		require.EqualValues(t, testData.distributionBranchTxID, o.ID.TransactionID())
+	}
+	require.EqualValues(t, []ledger.TransactionID{testData.distributionBranchTxID}, diff.CommittedTxIDs)
+	require.EqualValues(t, 0, len(diff.ForgottenTxIDs))
+
+	// reverse diff
+	diffBack, err := multistate.BranchStateDiff(store, testData.distributionBranchTxID, genesisBranchID)
+	require.NoError(t, err)
+	require.EqualValues(t, len(diff.Added), len(diffBack.Removed))
+	require.EqualValues(t, len(diff.Removed), len(diffBack.Added))
+
+	diffSame, err := multistate.BranchStateDiff(store, genesisBranchID, genesisBranchID)
+	require.NoError(t, err)
+	require.True(t, diffSame.IsEmpty())
+
+	_, err = multistate.BranchStateDiff(store, genesisBranchID, ledger.RandomTransactionID(true))
+	util.RequireErrorWith(t, err, "unknown branch")
+}
+
+func TestLatestBranchTime(t *testing.T) {
+	testData := initWorkflowTest(t, 1)
+	testData.stopAndWait()
+
+	// distribution branch is the latest committed branch. In the test ledger it is on slot 1, i.e. may be in the future
+	before := ledger.TimeNow().Time()
+	ts, age, err := multistate.LatestBranchTime(testData.wrk.StateStore())
+	after := ledger.TimeNow().Time()
+	require.NoError(t, err)
+	require.EqualValues(t, testData.distributionBranchTxID.Timestamp(), ts)
+	require.True(t, age >= before.Sub(ts.Time()))
+	require.True(t, age <= after.Sub(ts.Time()))
+	t.Logf("latest branch time: %s, age: %v", ts.String(), age)
+
+	_, _, err = multistate.LatestBranchTime(common.NewInMemoryKVStore())
+	util.RequireErrorWith(t, err, "no root records found")
+}
+
+func TestSyncStatus(t *testing.T) {
+	testData := initWorkflowTest(t, 1)
+	testData.stopAndWait()
+
+	latestSlot, latestHealthySlot, _ := testData.wrk.LatestBranchSlots()
+	status := testData.wrk.SyncStatus()
+	require.EqualValues(t, latestSlot, status.LatestSlot)
+	require.EqualValues(t, latestHealthySlot, status.LatestHealthySlot)
+	require.True(t, status.NowSlot <= ledger.TimeNow().Slot())
+	if status.NowSlot > status.LatestSlot {
+		require.EqualValues(t, status.NowSlot-status.LatestSlot, status.SlotsBehind)
+	} else {
+		require.EqualValues(t, 0, status.SlotsBehind)
+	}
+	t.Logf("sync status: %+v", status)
+}
+
+func TestReferencesPrunedState(t *testing.T) {
+	privKey := testutil.GetTestingPrivateKey()
+	par := ledger.DefaultIdentityData(privKey)
+	distrib := []ledger.LockBalance{
+		{Lock: ledger.AddressED25519FromPrivateKey(testutil.GetTestingPrivateKey(1)), Balance: 1_000_000},
+	}
+	// distribution transaction is built on the genesis state, which is not present in the node's state store
+	genesisStore := common.NewInMemoryKVStore()
+	multistate.InitStateStore(*par, genesisStore)
+	txBytes, err := txbuilder.MakeDistributionTransaction(genesisStore, privKey, distrib)
+	require.NoError(t, err)
+
+	// state store with the retention horizon after the genesis slot
+	stateStore := common.NewInMemoryKVStore()
+	batch := stateStore.BatchedWriter()
+	multistate.WriteEarliestSlotRecord(batch, 1)
+	multistate.WriteLatestSlotRecord(batch, 1)
+	require.NoError(t, batch.Commit())
+
+	env := newWorkflowDummyEnvironment(stateStore, txstore.NewSimpleTxBytesStore(common.NewInMemoryKVStore()))
+	wrk := workflow.Start(env, peering.NewPeersDummy(), workflow.OptionDoNotStartPruner)
+	require.EqualValues(t, 1, int(wrk.StateRetentionHorizon()))
+
+	waitCh := make(chan struct{})
+	vid, err := attacher.AttachTransactionFromBytes(txBytes, wrk, attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, _ error) {
+		close(waitCh)
+	}))
+	require.NoError(t, err)
+	<-waitCh
+
+	require.EqualValues(t, vertex.Bad, vid.GetTxStatus())
+	t.Logf("reason: %v", vid.GetError())
+	require.True(t, errors.Is(vid.GetError(), attacher.ErrReferencesPrunedState))
+
+	env.Stop()
+	env.WaitAllWorkProcessesStop()
+}
+
+func TestMinBaselineCoverage(t *testing.T) {
+	branchID := ledger.RandomTransactionID(true)
+	const best = 1_000_000
+
+	minCoverage := global.Fraction{Numerator: 1, Denominator: 2}
+	require.NoError(t, attacher.CheckBaselineCoverage(&branchID, best, best, minCoverage))
+	require.NoError(t, attacher.CheckBaselineCoverage(&branchID, best/2, best, minCoverage))
+	err := attacher.CheckBaselineCoverage(&branchID, best/10, best, minCoverage)
+	require.True(t, errors.Is(err, attacher.ErrLowBaselineCoverage))
+	t.Logf("expected error: %v", err)
+
+	// policy disabled by default
+	require.NoError(t, attacher.CheckBaselineCoverage(&branchID, best/10, best, global.Fraction{}))
+}
+
+func TestDeduplicateInFlightTx(t *testing.T) {
+	testData := initLongConflictTestData(t, 1, 1, 0)
+	testData.makeSeqBeginnings(false)
+	txBytes := testData.seqChain[0][0].Bytes()
+
+	// chain origins are not in the store yet, so the first submission stays in flight
+	const numSubmissions = 3
+	var wg sync.WaitGroup
+	vids := make([]*vertex.WrappedTx, numSubmissions)
+	errs := make([]error, numSubmissions)
+	wg.Add(numSubmissions)
+	for i := 0; i < numSubmissions; i++ {
+		idx := i
+		_, err := testData.wrk.TxBytesIn(txBytes, workflow.WithAttachmentCallback(func(vid *vertex.WrappedTx, err error) {
+			vids[idx], errs[idx] = vid, err
+			wg.Done()
+		}))
+		require.NoError(t, err)
+	}
+	require.EqualValues(t, numSubmissions-1, testData.wrk.NumDeduplicatedInFlightTx())
+
+	_, err := testData.txStore.PersistTxBytesWithMetadata(testData.chainOriginsTx.Bytes(), nil)
+	require.NoError(t, err)
+	wg.Wait()
+
+	for i := 0; i < numSubmissions; i++ {
+		require.NoError(t, errs[i])
+		require.True(t, vids[i] == vids[0])
+	}
+	require.EqualValues(t, vertex.Good, vids[0].GetTxStatus())
+
+	// resubmission of the already attached transaction calls the callback and does not stay in flight
+	for i := 0; i < 2; i++ {
+		require.Eventually(t, func() bool { return testData.wrk.NumInFlightTx() == 0 }, time.Second, 10*time.Millisecond)
+		resubmitted := make(chan *vertex.WrappedTx, 1)
+		_, err = testData.wrk.TxBytesIn(txBytes, workflow.WithAttachmentCallback(func(vid *vertex.WrappedTx, err error) {
+			require.NoError(t, err)
+			resubmitted <- vid
+		}))
+		require.NoError(t, err)
+		select {
+		case vid := <-resubmitted:
+			require.True(t, vid == vids[0])
+		case <-time.After(5 * time.Second):
+			t.Fatalf("attachment callback of the resubmitted transaction was not called")
+		}
+	}
+	require.Eventually(t, func() bool { return testData.wrk.NumInFlightTx() == 0 }, time.Second, 10*time.Millisecond)
+	require.EqualValues(t, numSubmissions-1, testData.wrk.NumDeduplicatedInFlightTx())
+	testData.stopAndWait()
+}
+
+func TestMakeGraphReportsErrors(t *testing.T) {
+	testData := initLongConflictTestData(t, 1, 1, 0)
+	testData.makeSeqBeginnings(false)
+	testData.txBytesToStore()
+
+	waitCh := make(chan struct{})
+	vid, err := attacher.AttachTransactionFromBytes(testData.seqChain[0][0].Bytes(), testData.wrk, attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, _ error) {
+		close(waitCh)
+	}))
+	require.NoError(t, err)
+	<-waitCh
+	testData.stopAndWait()
+	require.EqualValues(t, vertex.Good, vid.GetTxStatus())
+
+	gr, err := testData.wrk.MakeGraph()
+	require.NoError(t, err)
+	order, err := gr.Order()
+	require.NoError(t, err)
+
+	// vertex already in the DAG is added once more, together with its edges
+	gr, err = testData.wrk.MakeGraph(vid)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, graph.ErrVertexAlreadyExists))
+	require.True(t, errors.Is(err, graph.ErrEdgeAlreadyExists))
+	t.Logf("expected error: %v", err)
+	// the graph is still made
+	order1, err := gr.Order()
+	require.NoError(t, err)
+	require.EqualValues(t, order, order1)
+}
+
+func TestSetLatestRoot(t *testing.T) {
+	testData := initWorkflowTest(t, 1)
+	testData.stopAndWait()
+
+	store := testData.wrk.StateStore()
+	require.EqualValues(t, testData.distributionBranchTxID.Slot(), multistate.FetchLatestCommittedSlot(store))
+
+	genesisRootRecord, found := multistate.FetchRootRecord(store, *ledger.GenesisTransactionID())
+	require.True(t, found)
+
+	_, unknownRoot := multistate.InitStateStore(*ledger.DefaultIdentityData(testutil.GetTestingPrivateKey(5)), common.NewInMemoryKVStore())
+	err := multistate.SetLatestRoot(store, unknownRoot)
+	util.RequireErrorWith(t, err, "not found")
+
+	// roll back to genesis
+	err = multistate.SetLatestRoot(store, genesisRootRecord.Root)
+	require.NoError(t, err)
+
+	require.EqualValues(t, 0, multistate.FetchLatestCommittedSlot(store))
+	latest := multistate.FetchLatestRootRecords(store)
+	require.EqualValues(t, 1, len(latest))
+	require.True(t, ledger.CommitmentModel.EqualCommitments(genesisRootRecord.Root, latest[0].Root))
+	_, found = multistate.FetchRootRecord(store, testData.distributionBranchTxID)
+	require.False(t, found)
+	require.EqualValues(t, []ledger.TransactionID{*ledger.GenesisTransactionID()}, multistate.FetchLatestBranchTransactionIDs(store))
+}
+
+func TestMakeGraphPastConeBounded(t *testing.T) {
+	const howLongSeqChains = 10
+	testData := initLongConflictTestData(t, 1, 1, 0)
+	testData.makeSeqBeginnings(false)
+	testData.makeSeqChains(howLongSeqChains)
+	testData.txBytesAttach()
+
+	var wg sync.WaitGroup
+	var last *vertex.WrappedTx
+	for _, tx := range testData.seqChain[0] {
+		wg.Add(1)
+		last = attacher.AttachTransaction(tx, testData.wrk, attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, _ error) {
+			wg.Done()
+		}))
+	}
+	wg.Wait()
+	testData.stopAndWait()
+	require.EqualValues(t, vertex.Good, last.GetTxStatus())
+
+	numTruncated := func(gr graph.Graph[string, string]) (vertices, edges int) {
+		adjacency, err := gr.AdjacencyMap()
+		require.NoError(t, err)
+		for id, targets := range adjacency {
+			_, props, err := gr.VertexWithProperties(id)
+			require.NoError(t, err)
+			if props.Attributes["color"] == "orange" {
+				vertices++
+			}
+			for _, e := range targets {
+				if e.Properties.Attributes["color"] == "orange" {
+					edges++
+				}
+			}
+		}
+		return
+	}
+
+	full, err := memdag.MakeGraphPastConeBounded(last, math.MaxInt, 0)
+	require.NoError(t, err)
+	orderFull, err := full.Order()
+	require.NoError(t, err)
+	v, e := numTruncated(full)
+	require.EqualValues(t, 0, v)
+	require.EqualValues(t, 0, e)
+
+	byDepth, err := memdag.MakeGraphPastConeBounded(last, math.MaxInt, 2)
+	require.NoError(t, err)
+	orderByDepth, err := byDepth.Order()
+	require.NoError(t, err)
+	v, e = numTruncated(byDepth)
+	require.True(t, v > 0 && e >= v)
+	require.True(t, orderByDepth < orderFull)
+
+	const maxVertices = 3
+	byCount, err := memdag.MakeGraphPastConeBounded(last, maxVertices, 0)
+	require.NoError(t, err)
+	orderByCount, err := byCount.Order()
+	require.NoError(t, err)
+	v, e = numTruncated(byCount)
+	require.True(t, v > 0 && e >= v)
+	require.EqualValues(t, maxVertices+v, orderByCount)
+	t.Logf("vertices: full %d, depth 2: %d, max %d: %d (%d truncated)", orderFull, orderByDepth, maxVertices, orderByCount, v)
+}
+
+func TestMakeGraphSlotRange(t *testing.T) {
+	testData := initLongConflictTestData(t, 1, 1, 0)
+	testData.makeSeqBeginnings(false)
+	testData.makeSeqChains(5)
+	testData.txBytesAttach()
+	for _, tx := range testData.seqChain[0] {
+		_, err := testData.wrk.TxBytesStore().PersistTxBytesWithMetadata(tx.Bytes(), nil)
+		require.NoError(t, err)
+	}
+
+	// branch in the next slot
+	distribBD, ok := multistate.FetchBranchData(testData.wrk.StateStore(), testData.distributionBranchTxID)
+	require.True(t, ok)
+	chainIn := testData.seqChain[0][len(testData.seqChain[0])-1].SequencerOutput().MustAsChainOutput()
+	txBytesBranch, err := txbuilder.MakeSequencerTransaction(txbuilder.MakeSequencerTransactionParams{
+		SeqName:    "seq0",
+		ChainInput: chainIn,
+		StemInput:  distribBD.Stem,
+		Timestamp:  chainIn.Timestamp().NextSlotBoundary(),
+		PrivateKey: testData.privKeyAux,
+	})
+	require.NoError(t, err)
+
+	waitCh := make(chan struct{})
+	vidBranch, err := attacher.AttachTransactionFromBytes(txBytesBranch, testData.wrk, attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, _ error) {
+		close(waitCh)
+	}))
+	require.NoError(t, err)
+	<-waitCh
+	testData.stopAndWait()
+	require.EqualValues(t, vertex.Good, vidBranch.GetTxStatus())
+
+	check := func(fromSlot, toSlot ledger.Slot) (inRange, points int) {
+		gr, err := testData.wrk.MakeGraphSlotRange(fromSlot, toSlot)
+		require.NoError(t, err)
+		adjacency, err := gr.AdjacencyMap()
+		require.NoError(t, err)
+		for id := range adjacency {
+			_, props, err := gr.VertexWithProperties(id)
+			require.NoError(t, err)
+			if props.Attributes["shape"] == "point" {
+				points++
+			}
+		}
+		for _, vid := range testData.wrk.Vertices() {
+			_, found := adjacency[vid.IDVeryShort()]
+			require.EqualValues(t, fromSlot <= vid.Slot() && vid.Slot() <= toSlot, found)
+			if found {
+				inRange++
+			}
+		}
+		require.EqualValues(t, len(adjacency), inRange+points)
+		return
+	}
+	branchSlot := vidBranch.Slot()
+	inRangeAll, pointsAll := check(0, branchSlot)
+	t.Logf("full range: vertices %d, points %d", inRangeAll, pointsAll)
+
+	// only the branch
+	inRange, _ := check(branchSlot, branchSlot)
+	require.EqualValues(t, 1, inRange)
+
+	// all except the branch
+	inRange, _ = check(0, branchSlot-1)
+	require.EqualValues(t, inRangeAll-1, inRange)
+
+	inRange, points := check(branchSlot+1, branchSlot+10)
+	require.EqualValues(t, 0, inRange+points)
+}
+
+func TestMakeGraphWithColoring(t *testing.T) {
+	testData := initLongConflictTestData(t, 1, 1, 0)
+	testData.makeSeqBeginnings(false)
+	testData.makeSeqChains(5)
+	testData.txBytesAttach()
+	testData.stopAndWait()
+
+	attributesOf := func(coloring memdag.NodeColoring, vid *vertex.WrappedTx) map[string]string {
+		gr, err := testData.wrk.MakeGraphWithColoring(coloring)
+		require.NoError(t, err)
+		_, props, err := gr.VertexWithProperties(vid.IDVeryShort())
+		require.NoError(t, err)
+		return props.Attributes
+	}
+	count := 0
+	for _, vid := range testData.wrk.Vertices() {
+		if !vid.IsSequencerMilestone() {
+			continue
+		}
+		count++
+		attrSeq := attributesOf(memdag.ColorBySequencer, vid)
+		require.NotEqualValues(t, "ylorrd9", attrSeq["colorscheme"])
+
+		attrCov := attributesOf(memdag.ColorByCoverage, vid)
+		require.EqualValues(t, "ylorrd9", attrCov["colorscheme"])
+		level, err := strconv.Atoi(attrCov["fillcolor"])
+		require.NoError(t, err)
+		require.True(t, 1 <= level && level <= 9)
+	}
+	require.True(t, count > 0)
+}
+
+func TestMakeGraphDiff(t *testing.T) {
+	testData := initLongConflictTestData(t, 1, 1, 0)
+	testData.makeSeqBeginnings(false)
+	testData.makeSeqChains(5)
+	testData.txBytesAttach()
+	testData.stopAndWait()
+
+	vertices := testData.wrk.Vertices()
+	require.True(t, len(vertices) >= 3)
+	// A misses the first vertex, B misses the last one
+	a := set.New(vertices[1:]...)
+	b := set.New(vertices[:len(vertices)-1]...)
+
+	gr, err := memdag.MakeGraphDiff(a, b)
+	require.NoError(t, err)
+
+	fillColorOf := func(vid *vertex.WrappedTx) string {
+		_, props, err := gr.VertexWithProperties(vid.IDVeryShort())
+		require.NoError(t, err)
+		return props.Attributes["fillcolor"]
+	}
+	onlyA := fillColorOf(vertices[len(vertices)-1])
+	onlyB := fillColorOf(vertices[0])
+	both := fillColorOf(vertices[1])
+	require.NotEqualValues(t, onlyA, onlyB)
+	require.NotEqualValues(t, onlyA, both)
+	require.NotEqualValues(t, onlyB, both)
+	for _, vid := range vertices[1 : len(vertices)-1] {
+		require.EqualValues(t, both, fillColorOf(vid))
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	testData := initLongConflictTestData(t, 5, 5, 10)
+	testData.makeSeqBeginnings(false)
+	testData.makeSeqChains(5)
+	testData.txBytesAttach()
+	for _, txs := range testData.seqChain {
+		testData.attachTransactions(txs...)
+	}
+	testData.stopAndWait()
+
+	gr, err := testData.wrk.MakeGraph()
+	require.NoError(t, err)
+	numEdges, err := gr.Size()
+	require.NoError(t, err)
+	numNodes, err := gr.Order()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = testData.wrk.WriteDOT(&buf)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.EqualValues(t, "strict digraph {", lines[0])
+	require.EqualValues(t, "}", lines[len(lines)-1])
+	dotEdges, dotNodes := 0, 0
+	edgeSet := set.New[string]()
+	for _, line := range lines[1 : len(lines)-1] {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && fields[1] == "->" {
+			dotEdges++
+			require.True(t, edgeSet.InsertNew(fields[0]+fields[2]))
+		} else {
+			dotNodes++
+		}
+	}
+	t.Logf("nodes: %d, edges: %d", dotNodes, dotEdges)
+	require.EqualValues(t, numNodes, dotNodes)
+	require.EqualValues(t, numEdges, dotEdges)
+	for _, vid := range testData.wrk.Vertices() {
+		require.True(t, strings.Contains(buf.String(), strconv.Quote(vid.IDVeryShort())))
+	}
+}
+
+// makeTransferChainDAG makes chain of n transfer transactions and loads it from the transaction store
+// into the DAG, without attaching
+func makeTransferChainDAG(tb testing.TB, n int) *memdag.MemDAG {
+	distrib, privKeys, addrs := inittest.GenesisParamsWithPreDistribution(initBalance)
+	stateID := ledger.DefaultIdentityData(testutil.GetTestingPrivateKey())
+	stateStore := common.NewInMemoryKVStore()
+	txStore := txstore.NewSimpleTxBytesStore(common.NewInMemoryKVStore())
+	multistate.InitStateStore(*stateID, stateStore)
+	txBytes, err := txbuilder.DistributeInitialSupply(stateStore, testutil.GetTestingPrivateKey(), distrib)
+	require.NoError(tb, err)
+	_, err = txStore.PersistTxBytesWithMetadata(txBytes, nil)
+	require.NoError(tb, err)
+	distribTx, err := transaction.FromBytes(txBytes, transaction.MainTxValidationOptions...)
+	require.NoError(tb, err)
+
+	var prev *ledger.OutputWithID
+	distribTx.ForEachProducedOutput(func(idx byte, o *ledger.Output, oid *ledger.OutputID) bool {
+		if bytes.Equal(o.Lock().Bytes(), addrs[0].Bytes()) {
+			prev = &ledger.OutputWithID{ID: *oid, Output: o}
+			return false
+		}
+		return true
+	})
+	require.True(tb, prev != nil)
+
+	for i := 0; i < n; i++ {
+		trd := txbuilder.NewTransferData(privKeys[0], addrs[0], prev.Timestamp().AddTicks(ledger.TransactionPace())).
+			WithAmount(prev.Output.Amount()).
+			WithTargetLock(addrs[0]).
+			MustWithInputs(prev)
+		txBytes, err = txbuilder.MakeSimpleTransferTransaction(trd)
+		require.NoError(tb, err)
+		_, err = txStore.PersistTxBytesWithMetadata(txBytes, nil)
+		require.NoError(tb, err)
+		tx, err := transaction.FromBytes(txBytes, transaction.MainTxValidationOptions...)
+		require.NoError(tb, err)
+		prev = tx.MustProducedOutputWithIDAt(0)
+	}
+	return memdag.MakeDAGFromTxStore(txStore, 0, prev.ID.TransactionID())
+}
+
+// BenchmarkGraphRendering compares building the graph with MakeGraph and rendering it with draw.DOT
+// against streaming with WriteDOT, on the DAG with 50k vertices
+func BenchmarkGraphRendering(b *testing.B) {
+	dag := makeTransferChainDAG(b, 50_000)
+	b.Logf("DAG with %d vertices", dag.NumVertices())
+
+	b.Run("MakeGraph", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			gr, err := dag.MakeGraph()
+			require.NoError(b, err)
+			err = draw.DOT(gr, io.Discard)
+			require.NoError(b, err)
+		}
+	})
+	b.Run("WriteDOT", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			err := dag.WriteDOT(io.Discard)
+			require.NoError(b, err)
+		}
+	})
+}
+
+func TestIterateStems(t *testing.T) {
+	testData := initWorkflowTest(t, 1)
+	testData.stopAndWait()
+	store := testData.wrk.StateStore()
+
+	collect := func(slotsBack int) []*ledger.OutputWithID {
+		ret := make([]*ledger.OutputWithID, 0)
+		multistate.IterateStems(store, slotsBack, func(slot ledger.Slot, stem *ledger.OutputWithID) bool {
+			require.EqualValues(t, stem.ID.Slot(), slot)
+			ret = append(ret, stem)
+			return true
+		})
+		return ret
+	}
+	// genesis and distribution branches
+	stems := collect(0)
+	require.EqualValues(t, 2, len(stems))
+	require.EqualValues(t, testData.distributionBranchTxID, stems[0].ID.TransactionID())
+	require.EqualValues(t, ledger.GenesisStemOutputID(), stems[1].ID)
+	for i := 1; i < len(stems); i++ {
+		require.True(t, stems[i-1].ID.Slot() >= stems[i].ID.Slot())
+	}
+	require.EqualValues(t, 1, len(collect(1)))
+
+	count := 0
+	multistate.IterateStems(store, 0, func(_ ledger.Slot, _ *ledger.OutputWithID) bool {
+		count++
+		return false
+	})
+	require.EqualValues(t, 1, count)
+
+	tree := multistate.MakeTree(store)
+	order, err := tree.Order()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, order)
+	size, err := tree.Size()
+	require.NoError(t, err)
+	require.EqualValues(t, 1, size)
+}
+
+func TestIncrementalAttacherCoverageDelta(t *testing.T) {
+	const nConflicts = 2
+	testData := initLongConflictTestData(t, nConflicts, nConflicts, 1)
+	testData.makeSeqBeginnings(false)
+	testData.txBytesAttach()
+
+	seqTx := testData.seqChain[0][0]
+	var wg sync.WaitGroup
+	wg.Add(1)
+	vidSeq := attacher.AttachTransaction(seqTx, testData.wrk, attacher.WithAttachmentCallback(func(_ *vertex.WrappedTx, _ error) {
+		wg.Done()
+	}))
+	wg.Wait()
+	require.EqualValues(t, vertex.Good.String(), vidSeq.GetTxStatus().String())
+
+	targetTs := seqTx.Timestamp().AddTicks(ledger.TransactionPaceSequencer())
+	a, err := attacher.NewIncrementalAttacher("test", testData.wrk, targetTs, vidSeq.SequencerWrappedOutput())
+	require.NoError(t, err)
+
+	a.EnableUndo()
+
+	// past cone of the extended milestone contributes on top of the baseline
+	baselinePart := a.AccumulatedCoverage() - a.CoverageDelta()
+	delta := a.CoverageDelta()
+	require.True(t, delta > 0)
+
+	// terminal output of the conflict chain is rooted through the fork output
+	o := testData.terminalOutputs[0]
+	txid := o.ID.TransactionID()
+	vid := testData.wrk.GetVertex(&txid)
+	require.True(t, vid != nil)
+	ok, err := a.InsertTagAlongInput(vertex.WrappedOutput{VID: vid, Index: o.ID.Index()})
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.EqualValues(t, baselinePart, a.AccumulatedCoverage()-a.CoverageDelta())
+	require.EqualValues(t, delta+testData.forkOutput.Output.Amount(), a.CoverageDelta())
+
+	require.NoError(t, a.UndoLastInput())
+	require.EqualValues(t, delta, a.CoverageDelta())
+
+	a.Close()
+	testData.stopAndWait()
+}
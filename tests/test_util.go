@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/lunfardo314/proxima/core/attacher"
 	"github.com/lunfardo314/proxima/core/memdag"
 	"github.com/lunfardo314/proxima/core/txmetadata"
@@ -48,6 +49,11 @@ func (w *workflowDummyEnvironment) PullFromNPeers(nPeers int, txid *ledger.Trans
 	return 0
 }
 
+func (w *workflowDummyEnvironment) PullFromPeer(_ peer.ID, txid *ledger.TransactionID, _, _ int) (int, bool) {
+	w.Log().Warnf(">>>>>> PullFromPeer not implemented: %s", txid.StringShort())
+	return 0, false
+}
+
 func (w *workflowDummyEnvironment) GetOwnSequencerID() *ledger.ChainID {
 	panic("not implemented")
 }
@@ -1,6 +1,9 @@
 package txstore
 
 import (
+	"encoding/hex"
+	"fmt"
+
 	"github.com/lunfardo314/proxima/core/txmetadata"
 	"github.com/lunfardo314/proxima/global"
 	"github.com/lunfardo314/proxima/ledger"
@@ -121,3 +124,35 @@ func (d DummyTxBytesStore) GetTxBytesWithMetadata(_ *ledger.TransactionID) []byt
 func (s DummyTxBytesStore) HasTxBytes(_ *ledger.TransactionID) bool {
 	return false
 }
+
+// OrphanedTransactions returns IDs of transactions in the txStore DB which are not committed and which are
+// older than the horizon slot. Transactions in the horizon slot and later are never considered orphaned,
+// because they still can be committed
+func OrphanedTransactions(store common.Traversable, isCommitted func(txid *ledger.TransactionID) bool, horizonSlot ledger.Slot) ([]ledger.TransactionID, error) {
+	ret := make([]ledger.TransactionID, 0)
+	var err error
+	store.Iterator(nil).IterateKeys(func(k []byte) bool {
+		var txid ledger.TransactionID
+		if txid, err = ledger.TransactionIDFromBytes(k); err != nil {
+			err = fmt.Errorf("OrphanedTransactions: wrong key %s in the txStore: %w", hex.EncodeToString(k), err)
+			return false
+		}
+		if txid.Slot() < horizonSlot && !isCommitted(&txid) {
+			ret = append(ret, txid)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// DeleteTransactions deletes transaction bytes from the txStore DB in one batch
+func DeleteTransactions(store common.BatchedUpdatable, txids []ledger.TransactionID) error {
+	batch := store.BatchedWriter()
+	for i := range txids {
+		batch.Set(txids[i][:], nil)
+	}
+	return batch.Commit()
+}
@@ -0,0 +1,71 @@
+package txstore
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/lunfardo314/proxima/ledger"
+	"github.com/lunfardo314/proxima/util/set"
+	"github.com/lunfardo314/unitrie/common"
+	"github.com/stretchr/testify/require"
+)
+
+func txIDInSlot(slot ledger.Slot) ledger.TransactionID {
+	var hash ledger.TransactionIDShort
+	_, _ = rand.Read(hash[:])
+	return ledger.NewTransactionID(ledger.NewLedgerTime(slot, 1), hash, false)
+}
+
+func TestOrphanedTransactions(t *testing.T) {
+	const horizonSlot = 10
+
+	store := common.NewInMemoryKVStore()
+	committed := set.New[ledger.TransactionID]()
+	orphaned := set.New[ledger.TransactionID]()
+	recent := set.New[ledger.TransactionID]()
+	for slot := ledger.Slot(1); slot <= 2*horizonSlot; slot++ {
+		for i := 0; i < 3; i++ {
+			txid := txIDInSlot(slot)
+			store.Set(txid[:], []byte{0, 1, 2})
+			switch {
+			case i == 0:
+				committed.Insert(txid)
+			case slot < horizonSlot:
+				orphaned.Insert(txid)
+			default:
+				recent.Insert(txid)
+			}
+		}
+	}
+	isCommitted := func(txid *ledger.TransactionID) bool {
+		return committed.Contains(*txid)
+	}
+
+	orphans, err := OrphanedTransactions(store, isCommitted, horizonSlot)
+	require.NoError(t, err)
+	require.EqualValues(t, len(orphaned), len(orphans))
+	for _, txid := range orphans {
+		require.True(t, orphaned.Contains(txid))
+	}
+
+	err = DeleteTransactions(store, orphans)
+	require.NoError(t, err)
+
+	txStore := NewSimpleTxBytesStore(store)
+	orphaned.ForEach(func(txid ledger.TransactionID) bool {
+		require.False(t, txStore.HasTxBytes(&txid))
+		return true
+	})
+	committed.ForEach(func(txid ledger.TransactionID) bool {
+		require.True(t, txStore.HasTxBytes(&txid))
+		return true
+	})
+	recent.ForEach(func(txid ledger.TransactionID) bool {
+		require.True(t, txStore.HasTxBytes(&txid))
+		return true
+	})
+
+	orphans, err = OrphanedTransactions(store, isCommitted, horizonSlot)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, len(orphans))
+}
@@ -0,0 +1,77 @@
+package graphviz
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/dominikbraun/graph"
+	"github.com/dominikbraun/graph/draw"
+)
+
+// output formats of SaveGraphAs
+const (
+	FormatDOT = "gv"
+	FormatSVG = "svg"
+	FormatPNG = "png"
+)
+
+// ErrGraphvizNotInstalled returned when graph can't be rendered because 'dot' is not found. DOT file is saved anyway
+var ErrGraphvizNotInstalled = errors.New("graphviz is not installed")
+
+// SaveGraphAs saves graph to the file <fname>.gv in DOT format. If format is FormatSVG or FormatPNG, it also
+// renders the graph to <fname>.<format> with the graphviz 'dot' command.
+// Empty format means FormatDOT
+func SaveGraphAs(gr graph.Graph[string, string], fname, format string) error {
+	if err := checkFormat(format); err != nil {
+		return fmt.Errorf("SaveGraphAs: %w", err)
+	}
+	dot, err := DOT(gr)
+	if err != nil {
+		return err
+	}
+	return SaveDOTAs(dot, fname, format)
+}
+
+// DOT returns graph in DOT format
+func DOT(gr graph.Graph[string, string]) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := draw.DOT(gr, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveDOTAs is SaveGraphAs for the graph which is already in DOT format, for example received from the node
+func SaveDOTAs(dot []byte, fname, format string) error {
+	if err := checkFormat(format); err != nil {
+		return fmt.Errorf("SaveDOTAs: %w", err)
+	}
+	dotFileName := fname + "." + FormatDOT
+	if err := os.WriteFile(dotFileName, dot, 0666); err != nil {
+		return err
+	}
+	if format == "" || format == FormatDOT {
+		return nil
+	}
+
+	dotPath, err := exec.LookPath("dot")
+	if err != nil {
+		return fmt.Errorf("SaveDOTAs: %w. Graph saved to '%s' in DOT format", ErrGraphvizNotInstalled, dotFileName)
+	}
+	out, err := exec.Command(dotPath, "-T"+format, "-o", fname+"."+format, dotFileName).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("SaveDOTAs: rendering '%s' failed: %w: %s", dotFileName, err, string(out))
+	}
+	return nil
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case "", FormatDOT, FormatSVG, FormatPNG:
+		return nil
+	}
+	return fmt.Errorf("wrong format '%s'. Must be '%s', '%s' or '%s'", format, FormatDOT, FormatSVG, FormatPNG)
+}
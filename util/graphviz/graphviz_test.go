@@ -0,0 +1,65 @@
+package graphviz
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/dominikbraun/graph"
+	"github.com/lunfardo314/proxima/util"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestGraph(t *testing.T) graph.Graph[string, string] {
+	gr := graph.New(graph.StringHash, graph.Directed(), graph.Acyclic())
+	require.NoError(t, gr.AddVertex("a"))
+	require.NoError(t, gr.AddVertex("b"))
+	require.NoError(t, gr.AddEdge("a", "b"))
+	return gr
+}
+
+func TestSaveGraphAs(t *testing.T) {
+	gr := makeTestGraph(t)
+	fname := filepath.Join(t.TempDir(), "test")
+
+	t.Run("dot", func(t *testing.T) {
+		require.NoError(t, SaveGraphAs(gr, fname, ""))
+		require.FileExists(t, fname+".gv")
+	})
+	t.Run("wrong format", func(t *testing.T) {
+		err := SaveGraphAs(gr, fname, "pdf")
+		util.RequireErrorWith(t, err, "wrong format")
+	})
+	t.Run("graphviz not installed", func(t *testing.T) {
+		t.Setenv("PATH", "")
+		_ = os.Remove(fname + ".gv")
+		err := SaveGraphAs(gr, fname, FormatSVG)
+		require.True(t, errors.Is(err, ErrGraphvizNotInstalled))
+		require.FileExists(t, fname+".gv")
+		require.NoFileExists(t, fname+".svg")
+	})
+	t.Run("svg", func(t *testing.T) {
+		if _, err := exec.LookPath("dot"); err != nil {
+			t.Skip("graphviz is not installed")
+		}
+		require.NoError(t, SaveGraphAs(gr, fname, FormatSVG))
+		require.FileExists(t, fname+".svg")
+	})
+}
+
+func TestSaveDOTAs(t *testing.T) {
+	dot, err := DOT(makeTestGraph(t))
+	require.NoError(t, err)
+	require.Contains(t, string(dot), "digraph")
+
+	fname := filepath.Join(t.TempDir(), "test")
+	require.NoError(t, SaveDOTAs(dot, fname, FormatDOT))
+	saved, err := os.ReadFile(fname + ".gv")
+	require.NoError(t, err)
+	require.EqualValues(t, dot, saved)
+
+	err = SaveDOTAs(dot, fname, "pdf")
+	util.RequireErrorWith(t, err, "wrong format")
+}
@@ -70,6 +70,17 @@ func (q *Queue[T]) Push(e T, priority ...bool) {
 	}
 }
 
+// TryPush places element into the queue only if the queue has less than maxLen elements.
+// Returns false if element was not pushed. The length is tracked asynchronously,
+// so the queue may slightly exceed maxLen under concurrent pushes
+func (q *Queue[T]) TryPush(e T, maxLen int, priority ...bool) bool {
+	if q.Len() >= maxLen {
+		return false
+	}
+	q.Push(e, priority...)
+	return true
+}
+
 func (q *Queue[T]) Len() int {
 	return int(q.len.Load())
 }
@@ -198,3 +198,24 @@ func TestTwoQueues(t *testing.T) {
 	t.Logf("----------\n%d", counter.Load())
 	require.EqualValues(t, limit, counter.Load())
 }
+
+func TestTryPush(t *testing.T) {
+	release := make(chan struct{})
+	var counter atomic.Int32
+	q := New[int](func(e int) {
+		<-release
+		counter.Inc()
+	})
+	require.True(t, q.TryPush(0, 1))
+	for i := 1; i < 10; i++ {
+		q.Push(i)
+	}
+	// one element is being consumed, the rest is in the buffer
+	require.Eventually(t, func() bool { return q.Len() == 9 }, time.Second, time.Millisecond)
+	require.False(t, q.TryPush(10, 9))
+	require.True(t, q.TryPush(10, 10))
+
+	close(release)
+	require.Eventually(t, func() bool { return counter.Load() == 11 }, time.Second, time.Millisecond)
+	q.Close(false)
+}
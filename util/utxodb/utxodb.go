@@ -103,6 +103,10 @@ func (u *UTXODB) GenesisChainID() *ledger.ChainID {
 func (u *UTXODB) Root() common.VCommitment {
 	return u.state.Root()
 }
+func (u *UTXODB) StateStore() global.StateStore {
+	return u.store
+}
+
 func (u *UTXODB) StateReader() *multistate.Readable {
 	return u.state.Readable()
 }